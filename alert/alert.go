@@ -0,0 +1,443 @@
+// Package alert turns a strip into an andon light. Sources raise alerts of
+// a level, the strip shows the look of the most severe one, and once every
+// alert has cleared or expired the strip goes back to what it showed
+// before the first. Layers, a gauge or a follow wait under the alerts
+// (see LampWithClient.Suspend).
+package alert
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"lampwith-tag/lamp"
+	"lampwith-tag/scene"
+)
+
+// Level is the severity of an alert. A higher level wins.
+type Level int
+
+const (
+	Info Level = iota + 1
+	Warning
+	Critical
+)
+
+var levelNames = map[Level]string{
+	Info:     "info",
+	Warning:  "warning",
+	Critical: "critical",
+}
+
+// Levels lists the levels, least severe first.
+var Levels = []Level{Info, Warning, Critical}
+
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// ParseLevel parses info, warning or critical.
+func ParseLevel(s string) (Level, error) {
+	for l, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return l, nil
+		}
+	}
+	return 0, fmt.Errorf("alert: unknown level %q, want info, warning or critical", s)
+}
+
+// DefaultLooks is what the levels show: steady blue for info, amber
+// breathing for a warning and red strobe for critical.
+func DefaultLooks() map[Level]lamp.Options {
+	fill := func(mode int, color string, period time.Duration) lamp.Options {
+		return lamp.Options{
+			ControlMode:       mode,
+			ControlPercentage: 100,
+			ControlPosition:   1,
+			ControlColor:      color,
+			ControlPeriod:     period,
+		}
+	}
+	return map[Level]lamp.Options{
+		Info:     fill(lamp.ModeNormal, "0,80,255", 0),
+		Warning:  fill(lamp.ModeBreathe, "255,120,0", 2*time.Second),
+		Critical: fill(lamp.ModeStrobe, "255,0,0", 250*time.Millisecond),
+	}
+}
+
+// Alert is an active alert.
+type Alert struct {
+	Level  Level
+	Source string
+	Raised time.Time
+	// Expires is the zero time for an alert that stays until cleared.
+	Expires time.Time
+}
+
+type entry struct {
+	Alert
+	timer *time.Timer
+}
+
+// base is what the strip showed before the first alert, and what it goes
+// back to when they clear.
+type base struct {
+	opts lamp.Options
+	seq  *scene.Sequence
+	// done is passed to the player with seq.
+	done func(error)
+}
+
+// LayerZ is the Z of the alert layer, above the other layers of a
+// compositor. See Manager.UseLayers.
+const LayerZ = 1000
+
+// layerName is the name of the alert layer.
+const layerName = "alert"
+
+// Manager shows the alerts on a strip.
+type Manager struct {
+	lc     *lamp.LampWithClient
+	player *scene.Player
+	layers *lamp.Compositor
+
+	mu sync.Mutex
+	// looks holds the options each level shows.
+	looks  map[Level]lamp.Options
+	alerts map[string]*entry
+	// shown is the alert on the strip, nil when it shows base.
+	shown *entry
+	base  *base
+	// layered is set while the alerts show as a layer.
+	layered bool
+}
+
+// NewManager returns a manager for lc showing DefaultLooks. player, if
+// not nil, is stopped while an alert shows and its scene started again
+// when the alerts clear.
+func NewManager(lc *lamp.LampWithClient, player *scene.Player) *Manager {
+	return &Manager{lc: lc, player: player, looks: DefaultLooks(), alerts: map[string]*entry{}}
+}
+
+// UseLayers shows the alerts as the top layer of c while c is on the
+// strip, so the layers under them keep running where the alert leaves
+// LEDs clear. Clearing the alerts removes the layer.
+func (m *Manager) UseLayers(c *lamp.Compositor) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.layers = c
+}
+
+// SetLook makes level show opts. It takes effect from the next alert of
+// that level.
+func (m *Manager) SetLook(level Level, opts lamp.Options) error {
+	if _, ok := levelNames[level]; !ok {
+		return fmt.Errorf("alert: unknown level %v", level)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.looks[level] = opts
+	return nil
+}
+
+// Raise raises the alert of source, replacing the one it raised before.
+// The alert expires after ttl, or stays until Clear when ttl is 0. The
+// strip shows it if no other alert is more severe; among alerts of the
+// same level the latest wins.
+func (m *Manager) Raise(level Level, source string, ttl time.Duration) error {
+	if _, ok := levelNames[level]; !ok {
+		return fmt.Errorf("alert: unknown level %v", level)
+	}
+	if source == "" {
+		return fmt.Errorf("alert: no source")
+	}
+	if ttl < 0 {
+		return fmt.Errorf("alert: ttl %v is negative", ttl)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(source)
+	e := &entry{Alert: Alert{Level: level, Source: source, Raised: time.Now()}}
+	if ttl > 0 {
+		e.Expires = e.Raised.Add(ttl)
+		e.timer = time.AfterFunc(ttl, func() { m.expire(e) })
+	}
+	m.alerts[source] = e
+	return m.update()
+}
+
+// Clear clears the alert of source, or every alert for "".
+func (m *Manager) Clear(source string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if source == "" {
+		for s := range m.alerts {
+			m.remove(s)
+		}
+	} else if !m.remove(source) {
+		return fmt.Errorf("alert: no alert from %q", source)
+	}
+	return m.update()
+}
+
+// Active returns the active alerts, the one the strip shows first.
+func (m *Manager) Active() []Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	alerts := make([]Alert, 0, len(m.alerts))
+	for _, e := range m.alerts {
+		alerts = append(alerts, e.Alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return before(alerts[i], alerts[j]) })
+	return alerts
+}
+
+// Showing reports whether an alert is on the strip.
+func (m *Manager) Showing() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.shown != nil
+}
+
+// State returns the state of the strip. While an alert shows, its options
+// are the ones the strip goes back to when the alerts clear.
+func (m *Manager) State() lamp.State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.lc.State()
+	if m.base != nil {
+		s.Options = m.base.opts
+	}
+	return s
+}
+
+// SetOptions sets the options without sending them, as
+// LampWithClient.SetOptions. While an alert shows they are the ones the
+// strip goes back to.
+func (m *Manager) SetOptions(opts lamp.Options) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.base == nil {
+		return m.lc.SetOptions(opts)
+	}
+	if err := opts.Validate(m.lc.State().Quantity); err != nil {
+		return err
+	}
+	m.base.opts = opts
+	return nil
+}
+
+// Apply sends opts to the strip, as LampWithClient.Apply. While an alert
+// shows, opts become what the strip goes back to when the alerts clear
+// instead, and the scene, layers, gauge or follow it was to go back to
+// are dropped, as the command would have stopped them.
+//
+// The console, gRPC, the web panel and the scheduler send through the
+// manager, so a change made during an alert neither hides the alert nor
+// gets lost when it clears.
+func (m *Manager) Apply(opts lamp.Options) error {
+	if err := opts.Validate(m.lc.State().Quantity); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.takeOver(); err != nil {
+		return err
+	}
+	if m.base == nil {
+		return m.lc.Apply(opts)
+	}
+	m.base.opts, m.base.seq, m.base.done = opts, nil, nil
+	m.lc.Drop()
+	return nil
+}
+
+// Play stops the effect on the strip and plays seq on the player. While
+// an alert shows, seq becomes the scene the strip goes back to when the
+// alerts clear instead. done is passed to the player.
+func (m *Manager) Play(seq *scene.Sequence, done func(error)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.player == nil {
+		return fmt.Errorf("alert: no scene player")
+	}
+	if err := m.takeOver(); err != nil {
+		return err
+	}
+	if m.base == nil {
+		m.lc.StopMarquee()
+		m.player.Play(seq, done)
+		return nil
+	}
+	m.base.seq, m.base.done = seq, done
+	m.lc.Drop()
+	return nil
+}
+
+// Scene returns the scene the player plays, or while an alert shows the
+// one the strip goes back to.
+func (m *Manager) Scene() *scene.Sequence {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.base != nil {
+		return m.base.seq
+	}
+	if m.player == nil {
+		return nil
+	}
+	return m.player.Sequence()
+}
+
+// StopScene stops the scene the player plays, or while an alert shows the
+// one the strip goes back to.
+func (m *Manager) StopScene() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.base != nil {
+		m.base.seq, m.base.done = nil, nil
+	}
+	if m.player != nil {
+		m.player.Stop()
+	}
+}
+
+// takeOver readies an alert shown as a layer for a command that takes the
+// strip from the layers: the alert shows on the whole strip and the
+// strip goes back to what the command sends. m.mu must be held.
+func (m *Manager) takeOver() error {
+	if !m.layered || m.shown == nil {
+		return nil
+	}
+	m.layered = false
+	m.layers.Remove(layerName)
+	m.base = &base{opts: m.lc.State().Options}
+	m.lc.Suspend()
+	m.lc.Drop()
+	return m.lc.Apply(m.looks[m.shown.Level])
+}
+
+// before reports whether a wins over b.
+func before(a, b Alert) bool {
+	if a.Level != b.Level {
+		return a.Level > b.Level
+	}
+	return a.Raised.After(b.Raised)
+}
+
+func (m *Manager) expire(e *entry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.alerts[e.Source] != e {
+		return
+	}
+	delete(m.alerts, e.Source)
+	slog.Info("alert expired", "level", e.Level, "source", e.Source)
+	if err := m.update(); err != nil {
+		slog.Warn("alert: updating the strip failed", "err", err)
+	}
+}
+
+func (m *Manager) remove(source string) bool {
+	e, ok := m.alerts[source]
+	if !ok {
+		return false
+	}
+	if e.timer != nil {
+		e.timer.Stop()
+	}
+	delete(m.alerts, source)
+	return true
+}
+
+// update puts the winning alert on the strip, or base when none is left.
+func (m *Manager) update() error {
+	var top *entry
+	for _, e := range m.alerts {
+		if top == nil || before(e.Alert, top.Alert) {
+			top = e
+		}
+	}
+
+	switch {
+	case top == nil && m.layered:
+		m.shown, m.layered = nil, false
+		m.layers.Remove(layerName)
+		return nil
+	case top == nil && m.base == nil:
+		return nil
+	case top == nil:
+		b := m.base
+		m.shown, m.base = nil, nil
+		if b.seq != nil {
+			m.lc.Drop()
+		}
+		// 等待的图层、仪表或跟随信号重新开始, 否则发送报警前的选项
+		if err := m.lc.SetOptions(b.opts); err != nil {
+			m.lc.Resume()
+			return err
+		}
+		if !m.lc.Resume() {
+			if err := m.lc.Exec(); err != nil {
+				return err
+			}
+		}
+		if b.seq != nil && m.player != nil {
+			m.player.Play(b.seq, b.done)
+		}
+		return nil
+	case m.shown != nil && m.shown.Level == top.Level:
+		// 同级别的外观相同, 不必重发
+		m.shown = top
+		return nil
+	}
+
+	// 图层正在显示时报警作为最上层, 不替换整条灯带
+	if m.layered || (m.base == nil && m.layers != nil && m.layers.Showing()) {
+		m.layered = true
+		l := lamp.Layer{Name: layerName, Options: m.looks[top.Level], Opacity: 1, Z: LayerZ}
+		if err := m.layers.Set(l); err != nil {
+			m.shown = nil
+			return err
+		}
+		m.shown = top
+		return nil
+	}
+
+	if m.base == nil {
+		m.base = &base{opts: m.lc.State().Options}
+		if m.player != nil {
+			m.base.seq = m.player.Sequence()
+		}
+		// 图层、仪表或跟随信号在报警下等待, 清除后重新开始
+		m.lc.Suspend()
+	}
+	if m.player != nil {
+		m.player.Stop()
+	}
+	if err := m.lc.Apply(m.looks[top.Level]); err != nil {
+		m.shown = nil
+		return err
+	}
+	m.shown = top
+	return nil
+}
@@ -0,0 +1,112 @@
+// Package config reads config.json, the hand-edited settings of the tool.
+// presets.json and state.json live next to it.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"lampwith-tag/port"
+)
+
+// Config is config.json.
+type Config struct {
+	// Ports holds settings per port name (COM3, /dev/ttyUSB0). The entry
+	// "*" applies to ports without their own entry.
+	Ports map[string]Port `json:"ports,omitempty"`
+
+	// Pins ties the strips to their adapters. When set, only ports matching
+	// a pin are used, in pin order, whatever number they got at startup.
+	Pins []port.Match `json:"pins,omitempty"`
+
+	// Location is where sunrise and sunset are computed for the schedule.
+	Location *Location `json:"location,omitempty"`
+	// Schedule is the rules applied at set times, see package schedule.
+	Schedule []Rule `json:"schedule,omitempty"`
+
+	// Alerts names the preset each alert level shows, by level (info,
+	// warning, critical). Levels left out keep their default look.
+	Alerts map[string]string `json:"alerts,omitempty"`
+}
+
+// Location is a place on earth, in degrees. North and east are positive.
+type Location struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Rule runs a preset or a scene at set times.
+type Rule struct {
+	Name string `json:"name"`
+	// When is a cron line ("0 8 * * 1-5") or sunrise or sunset with an
+	// optional offset and days of the week ("sunset-15m", "sunrise 1-5").
+	When string `json:"when"`
+	// Until, when set, ends a window in the same syntax: the strips go
+	// back to what they showed before When.
+	Until string `json:"until,omitempty"`
+
+	// Preset or Scene is what the rule runs.
+	Preset string `json:"preset,omitempty"`
+	Scene  string `json:"scene,omitempty"`
+}
+
+// Port is the settings of one serial port.
+type Port struct {
+	RS485 port.RS485 `json:"rs485"`
+}
+
+// Dir is the directory holding the tool's files.
+func Dir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "."
+	}
+	return filepath.Join(dir, "lampwith-tag")
+}
+
+// DefaultPath is config.json in Dir.
+func DefaultPath() string {
+	return filepath.Join(Dir(), "config.json")
+}
+
+// Load reads the config file. A missing file yields an empty config.
+func Load(path string) (Config, error) {
+	var c Config
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+
+	if err := json.Unmarshal(b, &c); err != nil {
+		return Config{}, fmt.Errorf("config: %s: %v", path, err)
+	}
+	return c, nil
+}
+
+// Port returns the settings for the port called name.
+func (c Config) Port(name string) Port {
+	if p, ok := c.Ports[name]; ok {
+		return p
+	}
+	return c.Ports["*"]
+}
+
+// Pinned reports whether the port d may be used.
+func (c Config) Pinned(d port.Descriptor) bool {
+	if len(c.Pins) == 0 {
+		return true
+	}
+	for _, m := range c.Pins {
+		if d.Matches(m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,247 @@
+// Package daemon keeps a lamp controller attached to every serial port that
+// has a strip behind it, following ports as adapters are plugged in and
+// pulled out.
+package daemon
+
+import (
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"lampwith-tag/lamp"
+	"lampwith-tag/port"
+)
+
+// AttachFunc opens the port called name and returns its controller, or an
+// error when there is no strip behind it.
+type AttachFunc func(name string) (*lamp.LampWithClient, error)
+
+// Strip is an attached controller.
+type Strip struct {
+	// Port is the name the port had when it was last seen.
+	Port string
+	// ID is the stable name of the port (see port.StableName). A strip
+	// that comes back under another name is recognised by it.
+	ID     string
+	Lamp   *lamp.LampWithClient
+	Online bool
+}
+
+// Event reports a strip going online or offline, or a port that could not
+// be attached (Err set).
+type Event struct {
+	Port   string
+	Online bool
+	Err    error
+}
+
+// maxRetry is the longest wait between attempts to attach a port.
+const maxRetry = 5 * time.Minute
+
+// Daemon tracks the strips.
+type Daemon struct {
+	attach AttachFunc
+
+	// Retry is the wait before a port that failed to attach is tried
+	// again. It doubles with every failure, up to five minutes. 0 never
+	// tries again.
+	Retry time.Duration
+
+	mu     sync.Mutex
+	strips map[string]*Strip // by ID
+	// failed holds the ports that failed to attach, by name.
+	failed map[string]*retry
+
+	subMu sync.Mutex
+	subs  map[chan Event]struct{}
+}
+
+// New returns a daemon attaching ports with attach.
+func New(attach AttachFunc) *Daemon {
+	return &Daemon{
+		attach: attach,
+		strips: map[string]*Strip{},
+		failed: map[string]*retry{},
+		subs:   map[chan Event]struct{}{},
+	}
+}
+
+// retry is when a port that failed to attach is tried next.
+type retry struct {
+	next time.Time
+	wait time.Duration
+}
+
+// Run handles port events until the channel is closed, and tries the
+// ports that failed to attach again every Retry.
+func (d *Daemon) Run(events <-chan port.Event) {
+	var tick <-chan time.Time
+	if d.Retry > 0 {
+		t := time.NewTicker(d.Retry)
+		defer t.Stop()
+		tick = t.C
+	}
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			d.Handle(ev)
+		case now := <-tick:
+			d.retryFailed(now)
+		}
+	}
+}
+
+// retryFailed handles the failed ports whose wait is over as if they had
+// just been added.
+func (d *Daemon) retryFailed(now time.Time) {
+	var due []string
+	d.mu.Lock()
+	for name, r := range d.failed {
+		if !now.Before(r.next) {
+			due = append(due, name)
+		}
+	}
+	d.mu.Unlock()
+
+	sort.Strings(due)
+	for _, name := range due {
+		d.Handle(port.Event{Name: name, Added: true})
+	}
+}
+
+// fail records that name failed to attach and publishes err.
+func (d *Daemon) fail(name string, err error) {
+	if d.Retry > 0 {
+		d.mu.Lock()
+		r := d.failed[name]
+		if r == nil {
+			r = &retry{wait: d.Retry}
+			d.failed[name] = r
+		} else {
+			r.wait = min(2*r.wait, maxRetry)
+		}
+		r.next = time.Now().Add(r.wait)
+		d.mu.Unlock()
+	}
+	d.publish(Event{Port: name, Err: err})
+}
+
+// Handle attaches an added port, or takes the strip of a removed port
+// offline. A strip that comes back gets its last state sent again.
+func (d *Daemon) Handle(ev port.Event) {
+	if !ev.Added {
+		d.mu.Lock()
+		delete(d.failed, ev.Name)
+		d.mu.Unlock()
+		d.remove(ev.Name)
+		return
+	}
+
+	id := port.StableName(ev.Name)
+
+	d.mu.Lock()
+	s, ok := d.strips[id]
+	if ok && s.Online {
+		d.mu.Unlock()
+		return
+	}
+	d.mu.Unlock()
+
+	if ok {
+		// The controller reopens the port by itself. Resend sends the last
+		// state, starts the layers, gauge or follow again and tells whether
+		// the strip answers.
+		if err := s.Lamp.Resend(); err != nil {
+			d.fail(ev.Name, err)
+			return
+		}
+		d.mu.Lock()
+		s.Port, s.Online = ev.Name, true
+		delete(d.failed, ev.Name)
+		d.mu.Unlock()
+		d.publish(Event{Port: ev.Name, Online: true})
+		return
+	}
+
+	lc, err := d.attach(ev.Name)
+	if err != nil {
+		d.fail(ev.Name, err)
+		return
+	}
+	d.mu.Lock()
+	d.strips[id] = &Strip{Port: ev.Name, ID: id, Lamp: lc, Online: true}
+	delete(d.failed, ev.Name)
+	d.mu.Unlock()
+	d.publish(Event{Port: ev.Name, Online: true})
+}
+
+func (d *Daemon) remove(name string) {
+	d.mu.Lock()
+	var found *Strip
+	for _, s := range d.strips {
+		if s.Port == name && s.Online {
+			s.Online = false
+			found = s
+		}
+	}
+	d.mu.Unlock()
+	if found == nil {
+		return
+	}
+
+	found.Lamp.StopMarquee()
+	if c, ok := found.Lamp.Client.(io.Closer); ok {
+		c.Close()
+	}
+	d.publish(Event{Port: name})
+}
+
+// Strips returns the known strips, online or not, ordered by port name.
+func (d *Daemon) Strips() []Strip {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	strips := make([]Strip, 0, len(d.strips))
+	for _, s := range d.strips {
+		strips = append(strips, *s)
+	}
+	sort.Slice(strips, func(i, j int) bool { return strips[i].Port < strips[j].Port })
+	return strips
+}
+
+// Subscribe returns a channel receiving strip events and a function that
+// cancels the subscription. Events are dropped for a subscriber that falls
+// behind.
+func (d *Daemon) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 16)
+
+	d.subMu.Lock()
+	d.subs[ch] = struct{}{}
+	d.subMu.Unlock()
+
+	return ch, func() {
+		d.subMu.Lock()
+		if _, ok := d.subs[ch]; ok {
+			delete(d.subs, ch)
+			close(ch)
+		}
+		d.subMu.Unlock()
+	}
+}
+
+func (d *Daemon) publish(ev Event) {
+	d.subMu.Lock()
+	defer d.subMu.Unlock()
+
+	for ch := range d.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
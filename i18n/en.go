@@ -0,0 +1,252 @@
+package i18n
+
+var en = map[string]string{
+	"config.read_failed":   "Cannot read the config: %v\n",
+	"port.list":            "Serial ports:\n",
+	"port.list_failed":     "Cannot read %s: %v\n",
+	"port.none":            "No serial port with a strip found\n",
+	"port.none_pinned":     "No serial port matching the pins in the config has a strip\n",
+	"port.not_pinned":      "does not match the pins in the config",
+	"bus.header":           "baud\tframe time\tframes/s (8N1, 5ms controller turnaround)\n",
+	"bus.budget":           "%d\t%v\t%.1f\n",
+	"rs485.available":      "%v: RS-485 mode available\n",
+	"rs485.unavailable":    "%v: RS-485 mode not available: %v\n",
+	"quantity.prompt":      "Number of LEDs on the strip (default %d): ",
+	"quantity.default":     "Invalid input, using the default of [%d] LEDs\n\n",
+	"quantity.set":         "Using [%d] LEDs\n\n",
+	"preset.load_failed":   "Cannot read the presets: %v\n",
+	"input.error":          "Input error: %v\n",
+	"scene.error":          "Scene error: %v",
+	"scene.stopped":        "\nScene [%s] stopped: %v\n> ",
+	"scene.playing":        "Playing scene [%s], type stop to stop\n",
+	"percent.set":          "Percentage: %d\n",
+	"position.set":         "Position: %d\n",
+	"ranges.set":           "Ranges: %s\n",
+	"ranges.none":          "none (percentage)",
+	"rgb.black":            "Color set to (0), the LEDs will be dark!\n",
+	"rgb.set":              "Color: r,g,b=%s\n",
+	"hint.option_exec":     "Type 'show' to show the current settings or 'run' to apply them.\n",
+	"preset.save_failed":   "Cannot save the preset: %v",
+	"preset.saved":         "Saved preset [%s]: %s\n",
+	"preset.delete_failed": "Cannot delete the preset: %v",
+	"preset.deleted":       "Deleted preset [%s]\n",
+	"preset.not_found":     "No preset [%s]",
+	"describe.off":         "all off",
+	"describe.named":       "%s: %s",
+	"describe.single":      "%s: LED %d r,g,b=%s",
+	"describe.ranges":      "%s: LEDs %s r,g,b=%s",
+	"describe.fill":        "%s: %d%% r,g,b=%s",
+	"describe.period":      "%s, every %s",
+	"color.red":            "red",
+	"color.green":          "green",
+	"color.blue":           "blue",
+	"mode.normal":          "steady",
+	"mode.breathe":         "breathe",
+	"mode.strobe":          "strobe",
+	"mode.single":          "single LED",
+	"mode.marquee":         "marquee",
+	"mode.unknown":         "unknown",
+	"options.current": `Current settings:
+	mode: %s
+	percentage: %d
+	ranges: %s
+	position: %d
+	color: r,g,b=%s
+	period: %s
+	fade: %s
+`,
+	"help.presets": `Usage:
+	Presets, type the name to run one (h for help, q to quit):
+	name				    description
+`,
+	"help.commands": `
+	command							 description
+`,
+	"mode.set":                "Mode: %s\n",
+	"period.set":              "Period: %s\n",
+	"period.value":            "%s (%sHz)",
+	"period.default":          "%s (%sHz, default)",
+	"period.none":             "none (the mode has no speed)",
+	"fade.set":                "Fade: %s\n",
+	"fade.off":                "off",
+	"quantity.changed":        "LEDs on the strip: %d\n",
+	"repl.usage":              "Usage: %s",
+	"repl.control_error":      "Control error: %v",
+	"repl.unknown_command":    "Unknown command %q, type help for the list of commands",
+	"repl.unclosed_quote":     "Quote %s is not closed",
+	"repl.not_a_number":       "%s must be a whole number, not %q",
+	"repl.missing_value":      "Missing argument",
+	"repl.missing_name":       "Missing preset name",
+	"repl.missing_rule":       "Missing rule name",
+	"repl.unknown_rule":       "No rule %q, see schedule list",
+	"repl.no_schedule":        "No schedule is running",
+	"schedule.header":         "Schedule:\n",
+	"schedule.none":           "No schedule rules, add them to \"schedule\" in config.json\n",
+	"schedule.preset":         "preset %s",
+	"schedule.scene":          "scene %s",
+	"schedule.next":           "next %s",
+	"schedule.window":         "next %s until %s",
+	"schedule.skips":          "skips %s",
+	"schedule.is_paused":      "paused",
+	"schedule.never":          "never",
+	"schedule.all":            "all rules",
+	"schedule.paused":         "Paused %s\n",
+	"schedule.resumed":        "Resumed %s\n",
+	"schedule.skipped":        "%s skips its start at %s\n",
+	"schedule.ran":            "Ran %s\n",
+	"repl.missing_source":     "Missing alert source",
+	"repl.extra_args":         "Too many arguments",
+	"repl.no_alerts":          "Alerts are not available",
+	"repl.unknown_alert":      "No alert from %q, see alert list",
+	"repl.unknown_level":      "Unknown level %q, use info, warning or critical",
+	"repl.bad_ttl":            "TTL %q must be a duration such as 30s or 5m",
+	"alert.header":            "Alerts:\n",
+	"alert.none":              "No alerts\n",
+	"alert.sticky":            "until cleared",
+	"alert.expires":           "expires in %v",
+	"alert.shown":             "(shown)",
+	"alert.all":               "all alerts",
+	"alert.raised":            "Raised %s alert from %s\n",
+	"alert.cleared":           "Cleared %s\n",
+	"repl.no_layers":          "Layers are not available",
+	"repl.missing_layer":      "Missing layer name",
+	"repl.unknown_layer":      "No layer %q, see layer list",
+	"repl.bad_opacity":        "Opacity %q must be between 0% and 100%",
+	"layer.header":            "Layers, top first:\n",
+	"layer.none":              "No layers\n",
+	"layer.scene":             "scene %s",
+	"layer.set":               "Layer %s set\n",
+	"layer.playing":           "Playing %s on layer %s\n",
+	"layer.removed":           "Removed layer %s\n",
+	"layer.cleared":           "Removed all layers\n",
+	"repl.follow_stdin":       "The console reads its commands from stdin; follow stdin with the -follow flag",
+	"repl.bad_number":         "%s must be a number, not %q",
+	"repl.bad_every":          "Interval %q must be a duration such as 500ms or 2s",
+	"repl.bad_span":           "min and max are both %v",
+	"follow.error":            "Cannot follow: %v",
+	"follow.started":          "Following %s, type stop to stop\n",
+	"repl.no_gauge":           "The gauge is not available",
+	"repl.bad_threshold":      "Threshold %q must be value:color, ex: 70:amber",
+	"gauge.current":           "Gauge at %s of %s to %s, filled %s, ease %s, colors from:\n",
+	"gauge.forward":           "from LED 1",
+	"gauge.reverse":           "from the last LED",
+	"gauge.configured":        "Gauge settings changed\n",
+	"repl.percent_range":      "Percentage %d must be between 1 and 100",
+	"repl.position_range":     "Position %d must be between 1 and %d",
+	"repl.quantity_range":     "Number of LEDs %d must be between 1 and 255",
+	"repl.bad_color":          "Unknown color %q, use a color name, #rrggbb or r,g,b (each channel 0 to 255)",
+	"repl.unknown_setting":    "Unknown setting %q, use one of %s",
+	"repl.unknown_mode":       "Unknown mode %q, use normal, breathe, strobe, single or marquee",
+	"repl.range_mode":         "Ranges work in normal, breathe and strobe mode, not in %s",
+	"repl.unknown_subcommand": "Unknown subcommand %q",
+	"help.aliases":            "\talias: %s\n",
+	"help.cmd.set":            "change a setting. ex: set color red, set color #ff8000, set color 255 0 0, set percent 20, set ranges 10-20, set position 5, set period 2s (breathe 1s-255s, strobe 10ms-2.55s), set speed 4Hz, set fade 500ms (crossfade on every change, off to cut)",
+	"help.cmd.mode":           "set the mode, at its default speed. ex: mode breathe",
+	"help.cmd.apply":          "set the mode and its settings and run them, apply may be left out. ex: single 5 #ff0000, breathe 50% green",
+	"help.cmd.range":          "light one or more spans of LEDs and turn the others off. ex: range 10-20 red breathe, range 1-5,25- blue, range -20% green (the last 20%), set ranges off goes back to the percentage",
+	"help.cmd.run":            "apply the current settings",
+	"help.cmd.show":           "show the current settings",
+	"help.cmd.play":           "play a scene file (JSON), the name may contain spaces. ex: play scenes/andon.json",
+	"help.cmd.stop":           "stop the scene",
+	"help.cmd.preset":         "save, list, delete or run a preset. ex: preset save warm",
+	"help.cmd.schedule":       "list the schedule in config.json, pause or resume a rule (all without a name), skip its next start or run it now. ex: schedule pause morning",
+	"help.cmd.layer":          "stack layers: each shows a mode on its LEDs and blends over the ones under it (replace, add or multiply, with an opacity); alerts show on top while layers run. ex: layer bg play scenes/andon.json, layer pick normal 10-12 white blend=add opacity=60%, layer remove pick",
+	"help.cmd.alert":          "raise an alert: the strip shows the most severe one (info blue, warning amber breathing, critical red strobe) and goes back to what it showed when all have cleared or expired. ex: alert critical press-3 5m, alert clear press-3",
+	"help.cmd.follow":         "drive the strip from a signal: the loudness of a WAV file, or a number polled from a URL (plain, a JSON field or a Prometheus metric after #). brightness dims one color, color mixes from the first color to the second; min and max set the span of the signal, curve shapes it (log, or an exponent). ex: follow song.wav red curve=log, follow http://plc/metrics#line_rate color green red max=120",
+	"help.cmd.gauge":          "show a value as a bar: the bar takes the color of the highest threshold it reaches (green, amber from 70%, red from 90% of the range by default), its last LED dims by the part of it the value covers, and it moves smoothly to each new value; any command that sends takes the strip back. ex: gauge 73, gauge value=73 min=0 max=100, gauge reverse 0:green 50:amber 80:red ease=1s",
+	"help.cmd.help":           "show help, or the usage of one command. ex: help set",
+	"help.cmd.quit":           "quit",
+	"help.short_forms": `
+	The short forms still work: sma to sme set the mode, percent=20, position=5, rgb=255,0,0, period=2s, speed=4Hz, fade=500ms, option and exec.
+	Separate commands on one line with ;, ex: set color red; set percent 20; run. Nothing is sent unless the whole line is valid.
+	Tab completes commands, the arrow keys walk the history.
+`,
+	"web.title":          "Strip control panel",
+	"web.online":         "Connected",
+	"web.offline":        "Not connected",
+	"web.preview":        "Live preview",
+	"web.color":          "Color",
+	"web.brightness":     "Brightness",
+	"web.percent":        "Length",
+	"web.ranges_hint":    "LEDs lit instead of the length, set on the console; changing the length clears them",
+	"web.period":         "Period",
+	"web.period_hint":    "breathe 1s-255s, strobe 10ms-2.55s, ex: 2s, 250ms, 4Hz, default",
+	"web.period_default": "default",
+	"web.fade":           "Fade",
+	"web.fade_hint":      "crossfade on every change, ex: 500ms, 2s",
+	"web.fade_off":       "off",
+	"web.normal":         "Steady",
+	"web.breathe":        "Breathe",
+	"web.strobe":         "Strobe",
+	"web.single":         "Single LED",
+	"web.marquee":        "Marquee",
+	"web.exec":           "Run",
+	"web.off":            "All off",
+	"web.pixels":         "Single LEDs",
+
+	"err.port":                      "lamp: %s: %v (check that the adapter is plugged in and no other program uses the port)",
+	"err.port_any":                  "lamp: serial port: %v (check that the adapter is plugged in and no other program uses the port)",
+	"err.timeout":                   "lamp: no answer from the controller: %v (check power, wiring, slave ID and baud rate)",
+	"err.frame":                     "lamp: garbled answer from the controller: %v (check cable length, termination and that only one master is on the bus)",
+	"err.exception":                 "lamp: controller rejected function %d: %s",
+	"err.exception.1":               "lamp: controller rejected function %d: %s (the controller does not support this request)",
+	"err.exception.2":               "lamp: controller rejected function %d: %s (the controller has no such register, check the model)",
+	"err.exception.3":               "lamp: controller rejected function %d: %s (a value is out of range for the controller, check quantity, position and mode)",
+	"err.exception.4":               "lamp: controller rejected function %d: %s (the controller failed, power cycle it)",
+	"err.exception.5":               "lamp: controller rejected function %d: %s (the controller is still working on the request)",
+	"err.exception.6":               "lamp: controller rejected function %d: %s (the controller is busy, retry later)",
+	"err.exception.8":               "lamp: controller rejected function %d: %s (the controller's memory is faulty)",
+	"err.exception.10":              "lamp: controller rejected function %d: %s (check the gateway configuration)",
+	"err.exception.11":              "lamp: controller rejected function %d: %s (check the device behind the gateway)",
+	"err.link_gave_up":              "link: giving up after %d attempts: %v",
+	"err.option.period_negative":    "period %s must not be negative",
+	"err.option.period_range":       "%s period %s must be between %s and %s",
+	"err.option.not_frequency":      "%s %q is not a frequency",
+	"err.option.not_period":         "%s %q is not a duration (500ms, 2s) or a frequency (2Hz)",
+	"err.option.not_positive":       "%s %q must be more than 0",
+	"err.option.frequency_positive": "frequency %gHz must be more than 0",
+	"err.option.blend_name":         "blend %q is not replace, add or multiply",
+	"err.option.layer_name":         "layer has no name",
+	"err.option.opacity":            "opacity %v must be between 0 and 1",
+	"err.option.blend":              "unknown blend %v",
+	"err.option.fade_curve":         "fade curve %q is not linear or perceptual",
+	"err.option.fade_duration":      "fade %q is not a duration such as 500ms or 2s",
+	"err.option.gauge_range":        "gauge min %v must be below max %v",
+	"err.option.gauge_threshold":    "gauge threshold %v is not a number",
+	"err.option.gauge_order":        "gauge thresholds must go up, %v comes after %v",
+	"err.option.gauge_ease":         "gauge ease %v must be between 0 and %v",
+	"err.option.gauge_value":        "gauge value %v is not a number",
+	"err.option.range_syntax":       "range %q is not N, N-M, N-, -N or a percentage of the strip",
+	"err.option.range_percent":      "percentage %q must be between 0 and 100",
+	"err.option.range_outside":      "range %q is outside LEDs 1 to %d or empty",
+	"err.option.color_hex":          "color %q is not #rrggbb",
+	"err.option.color_name":         "unknown color %q, use a name, #rrggbb or r,g,b",
+	"err.option.follow_style":       "style %q is not brightness or color",
+	"err.option.mode_name":          "unknown mode %q",
+	"err.option.quantity":           "quantity %d must be between 1 and 255",
+	"err.option.mode":               "unknown mode %d",
+	"err.option.percentage":         "percentage %d must be between 1 and 100",
+	"err.option.position":           "position %d must be between 1 and %d",
+	"err.option.fade":               "fade %s must be between 0 and %s",
+	"err.option.color_rgb":          "color %q is not r,g,b",
+	"err.option.color_channel":      "color channel %q must be between 0 and 255",
+	"err.meter.file":                "meter: %s: %v",
+	"err.meter.wav":                 "not a WAV file",
+	"err.meter.wav_data":            "no data chunk: %v",
+	"err.meter.wav_fmt_size":        "fmt chunk of %d bytes, want 16 to %d",
+	"err.meter.wav_fmt_short":       "short fmt chunk",
+	"err.meter.wav_pcm":             "format %#x is not PCM",
+	"err.meter.wav_bits":            "%d-bit samples, want 8 or 16",
+	"err.meter.wav_rate":            "no channels or sample rate",
+	"err.meter.wav_too_big":         "%d channels at %d Hz, want at most %d at %d Hz",
+	"err.meter.wav_data_first":      "data before fmt chunk",
+	"err.meter.audio_format":        "meter: %d channels at %d Hz, want 1 to %d at up to %d Hz",
+	"err.meter.pcm_rate":            "meter: %q: bad sample rate",
+	"err.meter.pcm_channels":        "meter: %q: bad channel count",
+	"err.meter.spec":                "meter: %q is not a .wav file, pcm, - or an http URL",
+	"err.meter.curve":               "meter: curve %q is not linear, log or an exponent above 0",
+	"err.meter.http_status":         "status %s",
+	"err.meter.no_field":            "no field %q",
+	"err.meter.not_number":          "field %q is not a number",
+	"err.meter.no_metric":           "no metric %s",
+}
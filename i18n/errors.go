@@ -0,0 +1,60 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Message is an error with a catalog entry: its message key and the args
+// that fill it in. The library packages return Messages so that the
+// console can show them in its language, while Error stays in English for
+// logs and gRPC.
+type Message interface {
+	error
+	Message() (key string, args []any)
+}
+
+// English returns m in English, for the Error method of m.
+func English(m Message) string {
+	key, args := m.Message()
+	return format("en", key, args)
+}
+
+// Error returns err in the current locale. The first Message along its
+// chain is looked up in the catalog, and so are the errors among its args;
+// an error without a Message is returned as it is.
+func Error(err error) string {
+	var m Message
+	if !errors.As(err, &m) {
+		return err.Error()
+	}
+	key, args := m.Message()
+	return format(Locale(), key, args)
+}
+
+// format formats the message key of locale with args, telling the errors
+// among them in locale as well.
+func format(locale, key string, args []any) string {
+	msg, ok := catalogs[locale][key]
+	if !ok {
+		if msg, ok = en[key]; !ok {
+			msg = key
+		}
+	}
+	if len(args) == 0 {
+		return msg
+	}
+
+	told := make([]any, len(args))
+	for i, a := range args {
+		told[i] = a
+		if err, ok := a.(error); ok {
+			if locale == "en" {
+				told[i] = err.Error()
+			} else {
+				told[i] = Error(err)
+			}
+		}
+	}
+	return fmt.Sprintf(msg, told...)
+}
@@ -0,0 +1,96 @@
+// Package i18n holds the console messages in every supported language.
+//
+// Messages are looked up by key; a key missing from the current locale
+// falls back to English, then to the key itself.
+package i18n
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var catalogs = map[string]map[string]string{
+	"en": en,
+	"zh": zh,
+}
+
+var (
+	mu     sync.RWMutex
+	locale = "zh"
+)
+
+// Locales lists the supported locales.
+func Locales() []string {
+	l := make([]string, 0, len(catalogs))
+	for k := range catalogs {
+		l = append(l, k)
+	}
+	sort.Strings(l)
+	return l
+}
+
+// Catalog returns the messages of locale by key.
+func Catalog(locale string) map[string]string {
+	return catalogs[locale]
+}
+
+// Detect picks the locale: lang as it is if set, for SetLocale to reject
+// an unknown one, else LC_ALL, LC_MESSAGES or LANG. Without any of them
+// it is Chinese, the language the tool started in; any other environment
+// setting than Chinese gives English.
+func Detect(lang string) string {
+	if lang != "" {
+		return lang
+	}
+	for _, v := range []string{os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v == "" {
+			continue
+		}
+		if strings.HasPrefix(strings.ToLower(v), "zh") {
+			return "zh"
+		}
+		return "en"
+	}
+	return "zh"
+}
+
+// SetLocale switches the language of T.
+func SetLocale(l string) error {
+	if _, ok := catalogs[l]; !ok {
+		return fmt.Errorf("unknown language %q, want one of %s", l, strings.Join(Locales(), ", "))
+	}
+
+	mu.Lock()
+	locale = l
+	mu.Unlock()
+	return nil
+}
+
+// Locale returns the current locale.
+func Locale() string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return locale
+}
+
+// T returns the message key in the current locale, formatted with args
+// like fmt.Sprintf. Without args the message is returned as it is. Errors
+// among args are told in the current locale too, see Error.
+func T(key string, args ...any) string {
+	return format(Locale(), key, args)
+}
+
+// Printf prints the message key to stdout.
+func Printf(key string, args ...any) {
+	fmt.Print(T(key, args...))
+}
+
+// Fprintf prints the message key to w.
+func Fprintf(w io.Writer, key string, args ...any) {
+	fmt.Fprint(w, T(key, args...))
+}
@@ -0,0 +1,252 @@
+package i18n
+
+var zh = map[string]string{
+	"config.read_failed":   "读取配置失败: %v\n",
+	"port.list":            "本地串口列表:\n",
+	"port.list_failed":     "读取 %s 失败: %v\n",
+	"port.none":            "没有找到能与灯带通信的串口\n",
+	"port.none_pinned":     "没有找到与配置中 pins 匹配且能与灯带通信的串口\n",
+	"port.not_pinned":      "与配置中的 pins 不匹配",
+	"bus.header":           "波特率\t每帧耗时\t每秒帧数(8N1, 控制器响应 5ms)\n",
+	"bus.budget":           "%d\t%v\t%.1f\n",
+	"rs485.available":      "%v: RS-485 模式可用\n",
+	"rs485.unavailable":    "%v: RS-485 模式不可用: %v\n",
+	"quantity.prompt":      "请输入灯带的数量(默认 %d): ",
+	"quantity.default":     "不合法的输入, 使用默认灯带数量 [%d], 控制开始\n\n",
+	"quantity.set":         "输入数量 [%d], 控制开始\n\n",
+	"preset.load_failed":   "读取预设失败: %v\n",
+	"input.error":          "错误输入: %v\n",
+	"scene.error":          "场景错误: %v",
+	"scene.stopped":        "\n场景 [%s] 停止: %v\n> ",
+	"scene.playing":        "播放场景 [%s], 输入 stop 停止\n",
+	"percent.set":          "使用百分比: %d\n",
+	"position.set":         "设置位置: %d\n",
+	"ranges.set":           "设置范围: %s\n",
+	"ranges.none":          "无(按百分比)",
+	"rgb.black":            "颜色值设为(0) !!!!!!\n",
+	"rgb.set":              "设置颜色: r,g,b=%s\n",
+	"hint.option_exec":     "输入 'show' 显示当前设置, 或者 'run' 执行.\n",
+	"preset.save_failed":   "保存预设失败: %v",
+	"preset.saved":         "已保存预设 [%s]: %s\n",
+	"preset.delete_failed": "删除预设失败: %v",
+	"preset.deleted":       "已删除预设 [%s]\n",
+	"preset.not_found":     "没有预设 [%s]",
+	"describe.off":         "所有灯灭",
+	"describe.named":       "%s：%s",
+	"describe.single":      "%s：第%d颗 r,g,b=%s",
+	"describe.ranges":      "%s：灯珠 %s r,g,b=%s",
+	"describe.fill":        "%s：%d%% r,g,b=%s",
+	"describe.period":      "%s, 周期 %s",
+	"color.red":            "红",
+	"color.green":          "绿",
+	"color.blue":           "蓝",
+	"mode.normal":          "常亮",
+	"mode.breathe":         "呼吸",
+	"mode.strobe":          "频闪",
+	"mode.single":          "单颗灯控制",
+	"mode.marquee":         "跑马灯",
+	"mode.unknown":         "未知",
+	"options.current": `当前操作:
+	模式: %s
+	百分比: %d
+	范围: %s
+	位置: %d
+	颜色: r,g,b=%s
+	周期: %s
+	渐变: %s
+`,
+	"help.presets": `用法:
+	以下为预设, 输入名称执行(输入 h 帮助,输入 q 退出):
+	名称				    描述
+`,
+	"help.commands": `
+	命令							 描述
+`,
+	"mode.set":                "设置模式: %s\n",
+	"period.set":              "设置周期: %s\n",
+	"period.value":            "%s (%sHz)",
+	"period.default":          "%s (%sHz, 默认)",
+	"period.none":             "无(该模式没有速度)",
+	"fade.set":                "设置渐变: %s\n",
+	"fade.off":                "关",
+	"quantity.changed":        "灯带数量: %d\n",
+	"repl.usage":              "用法: %s",
+	"repl.control_error":      "控制错误: %v",
+	"repl.unknown_command":    "未知命令 %q, 输入 help 查看所有命令",
+	"repl.unclosed_quote":     "引号 %s 没有闭合",
+	"repl.not_a_number":       "%s 应该是整数, 不是 %q",
+	"repl.missing_value":      "缺少参数",
+	"repl.missing_name":       "缺少预设名称",
+	"repl.missing_rule":       "缺少规则名称",
+	"repl.unknown_rule":       "没有规则 %q, 请看 schedule list",
+	"repl.no_schedule":        "没有运行定时规则",
+	"schedule.header":         "定时规则:\n",
+	"schedule.none":           "没有定时规则, 可以添加到 config.json 的 \"schedule\" 中\n",
+	"schedule.preset":         "预设 %s",
+	"schedule.scene":          "场景 %s",
+	"schedule.next":           "下次 %s",
+	"schedule.window":         "下次 %s 到 %s",
+	"schedule.skips":          "跳过 %s",
+	"schedule.is_paused":      "已暂停",
+	"schedule.never":          "不会执行",
+	"schedule.all":            "全部规则",
+	"schedule.paused":         "已暂停 %s\n",
+	"schedule.resumed":        "已恢复 %s\n",
+	"schedule.skipped":        "%s 跳过 %s 的这一次\n",
+	"schedule.ran":            "已执行 %s\n",
+	"repl.missing_source":     "缺少报警来源",
+	"repl.extra_args":         "参数过多",
+	"repl.no_alerts":          "报警不可用",
+	"repl.unknown_alert":      "没有来自 %q 的报警, 请看 alert list",
+	"repl.unknown_level":      "未知的级别 %q, 可用 info、warning 或 critical",
+	"repl.bad_ttl":            "有效期 %q 必须是时长, 例如 30s 或 5m",
+	"alert.header":            "报警:\n",
+	"alert.none":              "没有报警\n",
+	"alert.sticky":            "直到清除",
+	"alert.expires":           "%v 后过期",
+	"alert.shown":             "(显示中)",
+	"alert.all":               "全部报警",
+	"alert.raised":            "已发出 %s 报警, 来源 %s\n",
+	"alert.cleared":           "已清除 %s\n",
+	"repl.no_layers":          "图层不可用",
+	"repl.missing_layer":      "缺少图层名称",
+	"repl.unknown_layer":      "没有图层 %q, 请看 layer list",
+	"repl.bad_opacity":        "不透明度 %q 必须在 0% 到 100% 之间",
+	"layer.header":            "图层(从上到下):\n",
+	"layer.none":              "没有图层\n",
+	"layer.scene":             "场景 %s",
+	"layer.set":               "已设置图层 %s\n",
+	"layer.playing":           "正在播放 %s (图层 %s)\n",
+	"layer.removed":           "已删除图层 %s\n",
+	"layer.cleared":           "已删除全部图层\n",
+	"repl.follow_stdin":       "控制台从标准输入读取命令, 跟随标准输入请用 -follow 参数",
+	"repl.bad_number":         "%s 应该是数字, 不是 %q",
+	"repl.bad_every":          "间隔 %q 必须是时长, 例如 500ms 或 2s",
+	"repl.bad_span":           "min 和 max 都是 %v",
+	"follow.error":            "无法跟随: %v",
+	"follow.started":          "正在跟随 %s, 输入 stop 停止\n",
+	"repl.no_gauge":           "仪表不可用",
+	"repl.bad_threshold":      "阈值 %q 应该是 数值:颜色, 例如 70:amber",
+	"gauge.current":           "仪表当前 %s, 范围 %s 到 %s, %s, 过渡 %s, 颜色阈值:\n",
+	"gauge.forward":           "从第 1 颗开始",
+	"gauge.reverse":           "从最后一颗开始",
+	"gauge.configured":        "仪表设置已更改\n",
+	"repl.percent_range":      "百分比 %d 应该在 1 和 100 之间",
+	"repl.position_range":     "位置 %d 应该在 1 和 %d 之间",
+	"repl.quantity_range":     "灯带数量 %d 应该在 1 和 255 之间",
+	"repl.bad_color":          "无法识别的颜色 %q, 可以是颜色名称、#rrggbb 或 r,g,b(每个通道 0 到 255)",
+	"repl.unknown_setting":    "未知的设置 %q, 可以是 %s",
+	"repl.unknown_mode":       "未知的模式 %q, 可以是 normal、breathe、strobe、single 或 marquee",
+	"repl.range_mode":         "范围只能用于 normal、breathe 和 strobe 模式, 不能用于 %s",
+	"repl.unknown_subcommand": "未知的子命令 %q",
+	"help.aliases":            "\t别名: %s\n",
+	"help.cmd.set":            "修改设置。例如: set color red, set color #ff8000, set color 255 0 0, set percent 20, set ranges 10-20, set position 5, set period 2s(呼吸 1s-255s, 闪烁 10ms-2.55s), set speed 4Hz, set fade 500ms(每次变化都渐变过渡, off 为直接切换)",
+	"help.cmd.mode":           "设置模式, 速度恢复为默认。例如: mode breathe",
+	"help.cmd.apply":          "设置模式和参数并立即执行, apply 可以省略。例如: single 5 #ff0000, breathe 50% green",
+	"help.cmd.range":          "点亮一段或几段灯珠, 其余熄灭。例如: range 10-20 red breathe, range 1-5,25- blue, range -20% green(最后 20%), set ranges off 恢复按百分比",
+	"help.cmd.run":            "使用当前设置执行控制",
+	"help.cmd.show":           "显示当前设置",
+	"help.cmd.play":           "播放场景文件(JSON), 文件名可以包含空格。例如: play scenes/andon.json",
+	"help.cmd.stop":           "停止播放场景",
+	"help.cmd.preset":         "保存、列出、删除或执行预设。例如: preset save warm",
+	"help.cmd.schedule":       "列出 config.json 中的定时规则, 暂停或恢复某条规则(不写名称为全部), 跳过下一次或立即执行。例如: schedule pause morning",
+	"help.cmd.layer":          "叠加图层: 每层在自己的灯珠上显示一种模式, 按混合方式(replace 覆盖、add 相加、multiply 相乘)和不透明度叠在下层之上; 图层显示时报警在最上层。例如: layer bg play scenes/andon.json, layer pick normal 10-12 white blend=add opacity=60%, layer remove pick",
+	"help.cmd.alert":          "发出报警: 灯带显示最严重的报警(info 蓝色, warning 琥珀色呼吸, critical 红色频闪), 全部清除或过期后恢复之前的显示。例如: alert critical press-3 5m, alert clear press-3",
+	"help.cmd.follow":         "由信号驱动灯带: WAV 文件的响度, 或从 URL 轮询的数值(纯数字, # 后的 JSON 字段或 Prometheus 指标)。brightness 调节一种颜色的亮度, color 从第一种颜色渐变到第二种; min 和 max 设定信号的范围, curve 调整曲线(log 或指数)。例如: follow song.wav red curve=log, follow http://plc/metrics#line_rate color green red max=120",
+	"help.cmd.gauge":          "以进度条显示数值: 进度条取达到的最高阈值的颜色(默认绿色, 范围 70% 起琥珀色, 90% 起红色), 最后一颗按覆盖的比例调暗, 新的数值平滑过渡; 任何发送的命令都会收回灯带。例如: gauge 73, gauge value=73 min=0 max=100, gauge reverse 0:green 50:amber 80:red ease=1s",
+	"help.cmd.help":           "显示帮助, 或者某个命令的用法。例如: help set",
+	"help.cmd.quit":           "退出",
+	"help.short_forms": `
+	旧的写法仍然可用: sma 到 sme 设置模式, percent=20, position=5, rgb=255,0,0, period=2s, speed=4Hz, fade=500ms, option 和 exec.
+	用 ; 分隔一行中的多个命令, 例如: set color red; set percent 20; run, 整行检查无误后才会发送.
+	按 Tab 补全命令, 上下键翻看历史.
+`,
+	"web.title":          "灯带控制面板",
+	"web.online":         "已连接",
+	"web.offline":        "未连接",
+	"web.preview":        "实时预览",
+	"web.color":          "颜色",
+	"web.brightness":     "亮度",
+	"web.percent":        "比例",
+	"web.ranges_hint":    "在命令行设置的点亮范围, 代替比例; 修改比例会清除",
+	"web.period":         "周期",
+	"web.period_hint":    "呼吸 1s-255s, 频闪 10ms-2.55s, 例如 2s, 250ms, 4Hz, default",
+	"web.period_default": "默认",
+	"web.fade":           "渐变",
+	"web.fade_hint":      "每次变化的渐变时间, 例如 500ms, 2s",
+	"web.fade_off":       "关",
+	"web.normal":         "常亮",
+	"web.breathe":        "呼吸",
+	"web.strobe":         "频闪",
+	"web.single":         "单颗灯",
+	"web.marquee":        "跑马灯",
+	"web.exec":           "执行",
+	"web.off":            "全部熄灭",
+	"web.pixels":         "单颗灯控制",
+
+	"err.port":                      "串口 %s: %v（请检查适配器是否插好、是否有其他程序占用串口）",
+	"err.port_any":                  "串口: %v（请检查适配器是否插好、是否有其他程序占用串口）",
+	"err.timeout":                   "控制器没有应答: %v（请检查电源、接线、从站地址和波特率）",
+	"err.frame":                     "控制器的应答有误: %v（请检查线缆长度、终端电阻, 以及总线上是否只有一个主站）",
+	"err.exception":                 "控制器拒绝了功能码 %d: %s",
+	"err.exception.1":               "控制器拒绝了功能码 %d: %s（控制器不支持这个请求）",
+	"err.exception.2":               "控制器拒绝了功能码 %d: %s（控制器没有这个寄存器, 请检查型号）",
+	"err.exception.3":               "控制器拒绝了功能码 %d: %s（数值超出控制器的范围, 请检查灯珠数量、位置和模式）",
+	"err.exception.4":               "控制器拒绝了功能码 %d: %s（控制器故障, 请断电重启）",
+	"err.exception.5":               "控制器拒绝了功能码 %d: %s（控制器仍在处理请求）",
+	"err.exception.6":               "控制器拒绝了功能码 %d: %s（控制器忙, 请稍后重试）",
+	"err.exception.8":               "控制器拒绝了功能码 %d: %s（控制器存储器故障）",
+	"err.exception.10":              "控制器拒绝了功能码 %d: %s（请检查网关配置）",
+	"err.exception.11":              "控制器拒绝了功能码 %d: %s（请检查网关后面的设备）",
+	"err.link_gave_up":              "尝试 %d 次后放弃: %v",
+	"err.option.period_negative":    "周期 %s 不能为负",
+	"err.option.period_range":       "%s 的周期 %s 必须在 %s 到 %s 之间",
+	"err.option.not_frequency":      "%s %q 不是频率",
+	"err.option.not_period":         "%s %q 不是时长 (500ms, 2s) 或频率 (2Hz)",
+	"err.option.not_positive":       "%s %q 必须大于 0",
+	"err.option.frequency_positive": "频率 %gHz 必须大于 0",
+	"err.option.blend_name":         "混合方式 %q 不是 replace、add 或 multiply",
+	"err.option.layer_name":         "图层没有名称",
+	"err.option.opacity":            "不透明度 %v 必须在 0 到 1 之间",
+	"err.option.blend":              "未知的混合方式 %v",
+	"err.option.fade_curve":         "渐变曲线 %q 不是 linear 或 perceptual",
+	"err.option.fade_duration":      "渐变 %q 不是 500ms 或 2s 这样的时长",
+	"err.option.gauge_range":        "仪表最小值 %v 必须小于最大值 %v",
+	"err.option.gauge_threshold":    "仪表阈值 %v 不是数字",
+	"err.option.gauge_order":        "仪表阈值必须递增, %v 在 %v 之后",
+	"err.option.gauge_ease":         "仪表过渡时间 %v 必须在 0 到 %v 之间",
+	"err.option.gauge_value":        "仪表数值 %v 不是数字",
+	"err.option.range_syntax":       "范围 %q 不是 N、N-M、N-、-N 或灯带的百分比",
+	"err.option.range_percent":      "百分比 %q 必须在 0 到 100 之间",
+	"err.option.range_outside":      "范围 %q 超出第 1 到 %d 颗灯珠或为空",
+	"err.option.color_hex":          "颜色 %q 不是 #rrggbb",
+	"err.option.color_name":         "未知的颜色 %q, 请用颜色名、#rrggbb 或 r,g,b",
+	"err.option.follow_style":       "样式 %q 不是 brightness 或 color",
+	"err.option.mode_name":          "未知的模式 %q",
+	"err.option.quantity":           "灯珠数量 %d 必须在 1 到 255 之间",
+	"err.option.mode":               "未知的模式 %d",
+	"err.option.percentage":         "百分比 %d 必须在 1 到 100 之间",
+	"err.option.position":           "位置 %d 必须在 1 到 %d 之间",
+	"err.option.fade":               "渐变 %s 必须在 0 到 %s 之间",
+	"err.option.color_rgb":          "颜色 %q 不是 r,g,b",
+	"err.option.color_channel":      "颜色通道 %q 必须在 0 到 255 之间",
+	"err.meter.file":                "信号 %s: %v",
+	"err.meter.wav":                 "不是 WAV 文件",
+	"err.meter.wav_data":            "没有 data 块: %v",
+	"err.meter.wav_fmt_size":        "fmt 块有 %d 字节, 应为 16 到 %d",
+	"err.meter.wav_fmt_short":       "fmt 块不完整",
+	"err.meter.wav_pcm":             "格式 %#x 不是 PCM",
+	"err.meter.wav_bits":            "%d 位采样, 应为 8 或 16 位",
+	"err.meter.wav_rate":            "没有声道数或采样率",
+	"err.meter.wav_too_big":         "%d 声道 %d Hz, 最多 %d 声道 %d Hz",
+	"err.meter.wav_data_first":      "data 块在 fmt 块之前",
+	"err.meter.audio_format":        "音频 %d 声道 %d Hz, 应为 1 到 %d 声道, 最高 %d Hz",
+	"err.meter.pcm_rate":            "信号 %q: 采样率无效",
+	"err.meter.pcm_channels":        "信号 %q: 声道数无效",
+	"err.meter.spec":                "信号 %q 不是 .wav 文件、pcm、- 或 http 地址",
+	"err.meter.curve":               "曲线 %q 不是 linear、log 或大于 0 的指数",
+	"err.meter.http_status":         "HTTP 状态 %s",
+	"err.meter.no_field":            "没有字段 %q",
+	"err.meter.not_number":          "字段 %q 不是数字",
+	"err.meter.no_metric":           "没有指标 %s",
+}
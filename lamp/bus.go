@@ -0,0 +1,119 @@
+package lamp
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Bus is the timing of the serial line, used to tell how many frames an
+// effect can send.
+type Bus struct {
+	Baud     int
+	DataBits int
+	StopBits int
+	// Parity is "N", "E" or "O".
+	Parity string
+	// Turnaround is the time the controller takes to answer.
+	Turnaround time.Duration
+}
+
+// DefaultBus is the line of the strip controller: 19200 baud, 8N1.
+func DefaultBus() Bus {
+	return Bus{Baud: 19200, DataBits: 8, StopBits: 1, Parity: "N", Turnaround: 5 * time.Millisecond}
+}
+
+// CharTime is the time one character takes on the line.
+func (b Bus) CharTime() time.Duration {
+	bits := 1 + b.DataBits + b.StopBits
+	if b.Parity != "" && b.Parity != "N" {
+		bits++
+	}
+	return time.Duration(bits) * time.Second / time.Duration(b.Baud)
+}
+
+// Gap is the silence between RTU frames: 3.5 characters, and 1.75ms above
+// 19200 baud (Modbus over serial line, 2.5.1.1).
+func (b Bus) Gap() time.Duration {
+	if b.Baud > 19200 {
+		return 1750 * time.Microsecond
+	}
+	return 7 * b.CharTime() / 2
+}
+
+// WriteTime is the time one WriteMultipleRegisters of n registers takes:
+// the request (9 bytes and 2 per register), the 8 byte response, a gap
+// after each and the controller's turnaround.
+func (b Bus) WriteTime(n int) time.Duration {
+	chars := 9 + 2*n + 8
+	return time.Duration(chars)*b.CharTime() + 2*b.Gap() + b.Turnaround
+}
+
+// WritesPerSecond is how many control frames (3 registers) the line
+// carries per second.
+func (b Bus) WritesPerSecond() float64 {
+	return float64(time.Second) / float64(b.WriteTime(3))
+}
+
+// pacer times the frames of a background effect. When the bus cannot
+// keep up, frames are dropped so the effect keeps its speed.
+type pacer struct {
+	effect   string
+	interval time.Duration
+	start    time.Time
+	frame    int
+	dropped  int
+
+	bus Bus
+	// writes is the most control frames a frame took so far.
+	writes int
+	warned bool
+}
+
+// newPacer paces an effect sending writes control frames every interval,
+// warning if the bus is too slow for it.
+func (lc *LampWithClient) newPacer(effect string, writes int, interval time.Duration) *pacer {
+	p := &pacer{effect: effect, interval: interval, start: time.Now(), bus: lc.Bus}
+	p.fits(writes)
+	return p
+}
+
+// fits notes a frame of writes control frames, for effects whose frames
+// differ, and warns once if the bus is too slow for it.
+func (p *pacer) fits(writes int) {
+	if p.warned || writes <= p.writes {
+		return
+	}
+	p.writes = writes
+	if need := time.Duration(writes) * p.bus.WriteTime(3); need > p.interval {
+		p.warned = true
+		slog.Warn("effect is too fast for the bus, frames will be dropped",
+			"effect", p.effect, "frame", p.interval, "bus_time", need,
+			"max_fps", float64(time.Second)/float64(need), "baud", p.bus.Baud)
+	}
+}
+
+// next waits for the next frame and returns its number. A frame that is
+// already due is returned at once; the frames it overtook are dropped. It
+// returns false once stop is closed.
+func (p *pacer) next(stop <-chan bool) (int, bool) {
+	select {
+	case <-time.After(time.Until(p.due())):
+		return p.frame, true
+	case <-stop:
+		return p.frame, false
+	}
+}
+
+// due moves on to the next frame and returns when it is due, for effects
+// that wait on more than stop. Frames already overtaken are dropped.
+func (p *pacer) due() time.Time {
+	p.frame++
+	if due := int(time.Since(p.start) / p.interval); due >= p.frame {
+		if n := due - p.frame; n > 0 {
+			p.dropped += n
+			slog.Debug("effect frames dropped", "effect", p.effect, "dropped", n, "total", p.dropped)
+		}
+		p.frame = due
+	}
+	return p.start.Add(time.Duration(p.frame) * p.interval)
+}
@@ -0,0 +1,55 @@
+package lamp
+
+import (
+	"encoding/hex"
+	"sort"
+	"strings"
+)
+
+var colorNames = map[string][3]byte{
+	"off":     {0, 0, 0},
+	"black":   {0, 0, 0},
+	"white":   {255, 255, 255},
+	"red":     {255, 0, 0},
+	"green":   {0, 255, 0},
+	"blue":    {0, 0, 255},
+	"yellow":  {255, 255, 0},
+	"amber":   {255, 191, 0},
+	"orange":  {255, 128, 0},
+	"cyan":    {0, 255, 255},
+	"magenta": {255, 0, 255},
+	"purple":  {128, 0, 255},
+	"warm":    {255, 160, 60},
+}
+
+// ColorNames lists the names ParseColorSpec knows, sorted.
+func ColorNames() []string {
+	names := make([]string, 0, len(colorNames))
+	for n := range colorNames {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseColorSpec parses a color written as a name (red, amber, ...), as
+// #rrggbb or as r,g,b.
+func ParseColorSpec(s string) (r, g, b byte, err error) {
+	s = strings.TrimSpace(s)
+	if c, ok := colorNames[strings.ToLower(s)]; ok {
+		return c[0], c[1], c[2], nil
+	}
+
+	if strings.HasPrefix(s, "#") {
+		v, err := hex.DecodeString(s[1:])
+		if err != nil || len(v) != 3 {
+			return 0, 0, 0, optionError("color", "err.option.color_hex", s)
+		}
+		return v[0], v[1], v[2], nil
+	}
+
+	if !strings.Contains(s, ",") {
+		return 0, 0, 0, optionError("color", "err.option.color_name", s)
+	}
+	return ParseColor(s)
+}
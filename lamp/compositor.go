@@ -0,0 +1,399 @@
+package lamp
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Blend is how a layer combines with the layers under it.
+type Blend int
+
+const (
+	// BlendReplace covers what is under the layer.
+	BlendReplace Blend = iota
+	// BlendAdd adds the light of the layer to what is under it.
+	BlendAdd
+	// BlendMultiply tints what is under the layer with its color.
+	BlendMultiply
+)
+
+var blendNames = []string{BlendReplace: "replace", BlendAdd: "add", BlendMultiply: "multiply"}
+
+func (b Blend) String() string {
+	if int(b) < len(blendNames) {
+		return blendNames[b]
+	}
+	return strconv.Itoa(int(b))
+}
+
+// ParseBlend parses "replace", "add" or "multiply".
+func ParseBlend(s string) (Blend, error) {
+	for b, n := range blendNames {
+		if n == s {
+			return Blend(b), nil
+		}
+	}
+	return 0, optionError("blend", "err.option.blend_name", s)
+}
+
+// Layer is one layer of a Compositor.
+type Layer struct {
+	Name string
+	// Options is what the layer shows, as Exec would show it on the strip
+	// alone: the fill modes on a percentage or ranges, single on one LED
+	// and marquee running along the strip. The LEDs it leaves off are
+	// clear, the layers under it show there. ControlFade is not used.
+	Options
+	Blend Blend
+	// Opacity is how much the layer shows, 0 to 1.
+	Opacity float64
+	// Z stacks the layers: higher is on top, layers with the same Z in the
+	// order they were added.
+	Z int
+}
+
+// Compositor draws a stack of layers into one frame and pushes the LEDs
+// that changed, so a background, highlights on a few LEDs and alerts can
+// show at once. Breathe, strobe and marquee layers are animated in
+// software, the controller only ever gets steady colors.
+//
+// The compositor runs as the background effect of the strip: a command
+// sent to the strip directly (Exec, Apply, Marquee) takes the strip over
+// until the layers change again. While the strip is held (see Suspend)
+// the layers wait.
+type Compositor struct {
+	lc *LampWithClient
+
+	mu      sync.Mutex
+	layers  []Layer
+	start   time.Time
+	running bool
+	changed chan struct{}
+	effect  *effect
+}
+
+// NewCompositor returns a compositor for the strip, without layers.
+func (lc *LampWithClient) NewCompositor() *Compositor {
+	c := &Compositor{lc: lc, start: time.Now(), changed: make(chan struct{}, 1)}
+	c.effect = &effect{run: c.run}
+	return c
+}
+
+// Set adds l on top of the layers with its Z, or replaces the layer with
+// its name where it is, and shows the layers.
+func (c *Compositor) Set(l Layer) error {
+	if l.Name == "" {
+		return optionError("layer", "err.option.layer_name")
+	}
+	if l.Opacity < 0 || l.Opacity > 1 || math.IsNaN(l.Opacity) {
+		return optionError("opacity", "err.option.opacity", l.Opacity)
+	}
+	if int(l.Blend) < 0 || int(l.Blend) >= len(blendNames) {
+		return optionError("blend", "err.option.blend", l.Blend)
+	}
+	if err := l.Validate(c.lc.State().Quantity); err != nil {
+		return fmt.Errorf("layer %q: %w", l.Name, err)
+	}
+
+	c.mu.Lock()
+	if i := c.index(l.Name); i >= 0 {
+		c.layers[i] = l
+	} else {
+		c.layers = append(c.layers, l)
+	}
+	// 稳定排序保持同一 Z 的添加顺序
+	sort.SliceStable(c.layers, func(i, j int) bool { return c.layers[i].Z < c.layers[j].Z })
+	c.mu.Unlock()
+
+	c.show()
+	return nil
+}
+
+// Remove removes the layer called name and reports whether there was one.
+// Removing the last layer leaves the strip dark.
+func (c *Compositor) Remove(name string) bool {
+	c.mu.Lock()
+	i := c.index(name)
+	if i >= 0 {
+		c.layers = append(c.layers[:i], c.layers[i+1:]...)
+	}
+	c.mu.Unlock()
+
+	if i >= 0 {
+		c.show()
+	}
+	return i >= 0
+}
+
+// Clear removes every layer.
+func (c *Compositor) Clear() {
+	c.mu.Lock()
+	c.layers = nil
+	c.mu.Unlock()
+	c.show()
+}
+
+// Show puts the layers back on the strip after a command took it over.
+func (c *Compositor) Show() {
+	c.show()
+}
+
+// Layer returns the layer called name.
+func (c *Compositor) Layer(name string) (Layer, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if i := c.index(name); i >= 0 {
+		return c.layers[i], true
+	}
+	return Layer{}, false
+}
+
+// Layers returns the layers, the bottom one first.
+func (c *Compositor) Layers() []Layer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Layer(nil), c.layers...)
+}
+
+// Showing reports whether the layers are on the strip.
+func (c *Compositor) Showing() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.running
+}
+
+func (c *Compositor) index(name string) int {
+	for i, l := range c.layers {
+		if l.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// show starts drawing the layers on the strip, or wakes the running
+// compositor to draw the new ones.
+func (c *Compositor) show() {
+	c.mu.Lock()
+	running := c.running
+	c.mu.Unlock()
+
+	if running {
+		select {
+		case c.changed <- struct{}{}:
+		default:
+		}
+		return
+	}
+	// startBackground 会等待正在运行的效果退出, 不能持有 c.mu
+	c.lc.startBackground(c.effect)
+}
+
+func (c *Compositor) run(stop <-chan bool) {
+	c.mu.Lock()
+	c.running = true
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.running = false
+		c.mu.Unlock()
+	}()
+
+	fb := c.lc.NewFramebuffer()
+	var p *pacer
+	for {
+		frame, tick := c.Frame(time.Since(c.start))
+		if fb.Len() != len(frame) {
+			fb.Reset()
+		}
+		for i, col := range frame {
+			fb.Set(i, col)
+		}
+		writes := fb.writes()
+		if err := fb.Push(); err != nil && !errors.Is(err, ErrFrameSize) {
+			slog.Warn("effect frame failed", "effect", "layers", "err", err)
+		}
+
+		// 只有动画图层需要定时重绘, 否则等图层改变; 总线跟不上时跳过几帧
+		var next <-chan time.Time
+		switch {
+		case tick == 0:
+			p = nil
+		case p == nil || p.interval != tick:
+			p = c.lc.newPacer("layers", writes, tick)
+			fallthrough
+		default:
+			p.fits(writes)
+			next = time.After(time.Until(p.due()))
+		}
+		select {
+		case <-stop:
+			return
+		case <-c.changed:
+		case <-next:
+		}
+	}
+}
+
+// Frame draws the layers as they are at t after the compositor was made.
+// tick is when the frame next changes on its own, 0 if it only changes
+// with the layers.
+func (c *Compositor) Frame(t time.Duration) (frame []Color, tick time.Duration) {
+	layers := c.Layers()
+	q := c.lc.State().Quantity
+
+	frame = make([]Color, q)
+	for _, l := range layers {
+		lit, col, lt := l.paint(q, t)
+		for i, on := range lit {
+			if on {
+				frame[i] = l.Blend.mix(frame[i], col, l.Opacity)
+			}
+		}
+		if lt > 0 && (tick == 0 || lt < tick) {
+			tick = lt
+		}
+	}
+	if tick > 0 {
+		tick = max(tick, fadeStep)
+	}
+	return frame, tick
+}
+
+// marqueeStep is how long a marquee layer lights each LED, as the marquee
+// effect does.
+const marqueeStep = 500 * time.Millisecond
+
+// paint returns the LEDs l lights at t and their color, with the period
+// its animation redraws at.
+func (l Layer) paint(q int, t time.Duration) (lit []bool, col Color, tick time.Duration) {
+	r, g, b, _ := ParseColor(l.ControlColor)
+	col = Color{R: r, G: g, B: b}
+	lit = make([]bool, q)
+	light := func(from, to int) {
+		for i := max(from, 1); i <= to && i <= q; i++ {
+			lit[i-1] = true
+		}
+	}
+
+	switch {
+	case l.ControlMode == ModeSingle:
+		light(l.ControlPosition, l.ControlPosition)
+		return lit, col, 0
+	case l.ControlMode == ModeMarquee:
+		ranges, err := ParseRanges(l.ControlRanges, q)
+		if l.ControlRanges == "" || err != nil {
+			ranges = []Range{{From: 1, To: q - 1}}
+		}
+		var positions []int
+		for _, rg := range ranges {
+			for i := rg.From; i <= rg.To; i++ {
+				positions = append(positions, i)
+			}
+		}
+		if len(positions) > 0 {
+			i := positions[int(t/marqueeStep)%len(positions)]
+			light(i, i)
+		}
+		return lit, col, marqueeStep
+	case l.ControlRanges != "":
+		ranges, _ := ParseRanges(l.ControlRanges, q)
+		for _, rg := range ranges {
+			light(rg.From, rg.To)
+		}
+	default:
+		light(1, l.ControlPercentage*q/100)
+	}
+
+	period := l.Period()
+	if period <= 0 {
+		return lit, col, 0
+	}
+	phase := float64(t%period) / float64(period)
+	switch l.ControlMode {
+	case ModeBreathe:
+		// 与 animateRanges 一样从暗开始, 半个周期亮到最高
+		level := 2 * phase
+		if level > 1 {
+			level = 2 - level
+		}
+		const breatheSteps = 20
+		col = FadeLinear.Mix(Color{}, col, level)
+		return lit, col, period / (2 * breatheSteps)
+	default:
+		if phase >= 0.5 {
+			col = Color{}
+		}
+		return lit, col, period / 2
+	}
+}
+
+// mix blends col over base with opacity.
+func (b Blend) mix(base, col Color, opacity float64) Color {
+	switch b {
+	case BlendAdd:
+		add := func(x, y byte) byte {
+			return byte(min(255, math.Round(float64(x)+float64(y)*opacity)))
+		}
+		return Color{R: add(base.R, col.R), G: add(base.G, col.G), B: add(base.B, col.B)}
+	case BlendMultiply:
+		mul := func(x, y byte) byte { return byte(int(x) * int(y) / 255) }
+		col = Color{R: mul(base.R, col.R), G: mul(base.G, col.G), B: mul(base.B, col.B)}
+	}
+	return FadeLinear.Mix(base, col, opacity)
+}
+
+// Target returns the layer called name as a strip a scene player can
+// drive: each step sets what the layer shows and keeps its blend, opacity
+// and Z. A missing layer is added on top, opaque.
+func (c *Compositor) Target(name string) *LayerTarget {
+	return &LayerTarget{c: c, name: name}
+}
+
+// LayerTarget is a layer driven like a strip, see Compositor.Target.
+type LayerTarget struct {
+	c    *Compositor
+	name string
+}
+
+// State returns the options of the layer, or of the strip while there
+// is no layer.
+func (t *LayerTarget) State() State {
+	s := t.c.lc.State()
+	if l, ok := t.c.Layer(t.name); ok {
+		s.Options = l.Options
+	}
+	return s
+}
+
+// Apply sets what the layer shows.
+func (t *LayerTarget) Apply(opts Options) error {
+	l, ok := t.c.Layer(t.name)
+	if !ok {
+		l = Layer{Name: t.name, Opacity: 1}
+		for _, o := range t.c.Layers() {
+			l.Z = max(l.Z, o.Z)
+		}
+	}
+	l.Options = opts
+	return t.c.Set(l)
+}
+
+// FadeDone returns a closed channel: layers do not crossfade.
+func (t *LayerTarget) FadeDone() <-chan struct{} {
+	done := make(chan struct{})
+	close(done)
+	return done
+}
+
+// StopMarquee does nothing, a layer runs no effect of its own.
+func (t *LayerTarget) StopMarquee() {}
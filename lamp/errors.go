@@ -0,0 +1,189 @@
+package lamp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/goburrow/modbus"
+	"github.com/goburrow/serial"
+
+	"lampwith-tag/i18n"
+)
+
+// The errors below sort what can go wrong between the program and the
+// strip. Control and Ping return them; callers tell them apart with
+// errors.As. Each message says what to check, and is in the i18n catalog
+// for the console (see i18n.Message).
+
+// PortError is a serial port that cannot be opened or went away.
+type PortError struct {
+	Port string
+	Err  error
+}
+
+func (e *PortError) Error() string { return i18n.English(e) }
+
+func (e *PortError) Message() (string, []any) {
+	if e.Port == "" {
+		return "err.port_any", []any{e.Err}
+	}
+	return "err.port", []any{e.Port, e.Err}
+}
+
+func (e *PortError) Unwrap() error { return e.Err }
+
+// TimeoutError is a request without an answer. Usually no controller is
+// listening on the port.
+type TimeoutError struct {
+	Err error
+}
+
+func (e *TimeoutError) Error() string { return i18n.English(e) }
+
+func (e *TimeoutError) Message() (string, []any) { return "err.timeout", []any{e.Err} }
+
+func (e *TimeoutError) Unwrap() error { return e.Err }
+
+// FrameError is a garbled answer: a bad CRC, a short frame or a frame from
+// another slave.
+type FrameError struct {
+	Err error
+}
+
+func (e *FrameError) Error() string { return i18n.English(e) }
+
+func (e *FrameError) Message() (string, []any) { return "err.frame", []any{e.Err} }
+
+func (e *FrameError) Unwrap() error { return e.Err }
+
+// ExceptionError is a request the controller answered with a Modbus
+// exception.
+type ExceptionError struct {
+	Function byte
+	Code     byte
+	Err      error
+}
+
+// Exception codes, Modbus application protocol section 7.
+const (
+	ExceptionIllegalFunction   = 1
+	ExceptionIllegalAddress    = 2
+	ExceptionIllegalValue      = 3
+	ExceptionDeviceFailure     = 4
+	ExceptionAcknowledge       = 5
+	ExceptionDeviceBusy        = 6
+	ExceptionMemoryParity      = 8
+	ExceptionGatewayPath       = 10
+	ExceptionGatewayNoResponse = 11
+)
+
+// exceptions names the exception codes after the Modbus specification.
+// The catalog holds a hint for each under "err.exception.<code>".
+var exceptions = map[byte]string{
+	ExceptionIllegalFunction:   "illegal function",
+	ExceptionIllegalAddress:    "illegal data address",
+	ExceptionIllegalValue:      "illegal data value",
+	ExceptionDeviceFailure:     "slave device failure",
+	ExceptionAcknowledge:       "acknowledge",
+	ExceptionDeviceBusy:        "slave device busy",
+	ExceptionMemoryParity:      "memory parity error",
+	ExceptionGatewayPath:       "gateway path unavailable",
+	ExceptionGatewayNoResponse: "gateway target device failed to respond",
+}
+
+// Name is the exception's name from the Modbus specification.
+func (e *ExceptionError) Name() string {
+	if name, ok := exceptions[e.Code]; ok {
+		return name
+	}
+	return fmt.Sprintf("exception %d", e.Code)
+}
+
+func (e *ExceptionError) Error() string { return i18n.English(e) }
+
+func (e *ExceptionError) Message() (string, []any) {
+	if _, ok := exceptions[e.Code]; ok {
+		return fmt.Sprintf("err.exception.%d", e.Code), []any{e.Function, e.Name()}
+	}
+	return "err.exception", []any{e.Function, e.Name()}
+}
+
+func (e *ExceptionError) Unwrap() error { return e.Err }
+
+// OptionError is a bad option value. It matches ErrInvalidOption.
+type OptionError struct {
+	Option string
+	// Msg says what is wrong, in English. Key and Args are its catalog
+	// entry.
+	Msg  string
+	Key  string
+	Args []any
+}
+
+func (e *OptionError) Error() string {
+	return ErrInvalidOption.Error() + ": " + e.Msg
+}
+
+func (e *OptionError) Unwrap() error { return ErrInvalidOption }
+
+func (e *OptionError) Message() (string, []any) { return e.Key, e.Args }
+
+// optionError returns the OptionError of option with the catalog message
+// key filled in with args.
+func optionError(option, key string, args ...any) error {
+	e := &OptionError{Option: option, Key: key, Args: args}
+	e.Msg = i18n.English(e)
+	return e
+}
+
+// Classify turns an error from the Modbus client into one of the errors
+// above. Errors it cannot place, and errors already classified, are
+// returned as they are.
+func Classify(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var (
+		portErr  *PortError
+		timeout  *TimeoutError
+		frameErr *FrameError
+		excErr   *ExceptionError
+		optErr   *OptionError
+	)
+	if errors.As(err, &portErr) || errors.As(err, &timeout) || errors.As(err, &frameErr) ||
+		errors.As(err, &excErr) || errors.As(err, &optErr) {
+		return err
+	}
+
+	var mbErr *modbus.ModbusError
+	if errors.As(err, &mbErr) {
+		return &ExceptionError{Function: mbErr.FunctionCode &^ 0x80, Code: mbErr.ExceptionCode, Err: err}
+	}
+	if errors.Is(err, serial.ErrTimeout) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return &TimeoutError{Err: err}
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || isFrameMessage(err.Error()) {
+		return &FrameError{Err: err}
+	}
+	// An unplugged USB adapter fails reads and writes like this.
+	if errors.Is(err, syscall.EIO) || errors.Is(err, syscall.ENODEV) || errors.Is(err, syscall.ENXIO) || errors.Is(err, syscall.EBADF) {
+		return &PortError{Err: err}
+	}
+	return err
+}
+
+// isFrameMessage recognises the framing errors of goburrow/modbus, which
+// are plain strings.
+func isFrameMessage(msg string) bool {
+	for _, s := range []string{"response crc", "response length", "response slave id", "does not match"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package lamp
+
+import (
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxFade is the longest crossfade.
+const MaxFade = time.Minute
+
+// fadeStep is the shortest interval between crossfade frames. Fades that
+// need more writes per frame than fit in it take longer steps.
+const fadeStep = 50 * time.Millisecond
+
+// FadeCurve is the space a crossfade mixes colors in.
+type FadeCurve int
+
+const (
+	// FadeLinear mixes the channel values, which are linear in the light
+	// the LEDs give off.
+	FadeLinear FadeCurve = iota
+	// FadePerceptual mixes perceived lightness (gamma 2.2), so a fade
+	// looks even to the eye instead of rushing through the dark end.
+	FadePerceptual
+)
+
+var fadeCurveNames = []string{FadeLinear: "linear", FadePerceptual: "perceptual"}
+
+func (c FadeCurve) String() string {
+	if int(c) < len(fadeCurveNames) {
+		return fadeCurveNames[c]
+	}
+	return strconv.Itoa(int(c))
+}
+
+// ParseFadeCurve parses "linear" or "perceptual".
+func ParseFadeCurve(s string) (FadeCurve, error) {
+	for c, n := range fadeCurveNames {
+		if n == s {
+			return FadeCurve(c), nil
+		}
+	}
+	return 0, optionError("fade", "err.option.fade_curve", s)
+}
+
+// Mix returns the color a fraction t of the way from a to b.
+func (c FadeCurve) Mix(a, b Color, t float64) Color {
+	mix := func(x, y byte) byte {
+		return byte(math.Round(float64(x) + (float64(y)-float64(x))*t))
+	}
+	if c == FadePerceptual {
+		mix = func(x, y byte) byte {
+			lx, ly := math.Pow(float64(x)/255, 1/2.2), math.Pow(float64(y)/255, 1/2.2)
+			return byte(math.Round(255 * math.Pow(lx+(ly-lx)*t, 2.2)))
+		}
+	}
+	return Color{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B)}
+}
+
+// ParseFade parses a fade time such as 500ms or 2s; a bare number is
+// milliseconds. "off" and 0 are no fade.
+func ParseFade(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "off" {
+		return 0, nil
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		s += "ms"
+		if n == 0 {
+			return 0, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, optionError("fade", "err.option.fade_duration", s)
+	}
+	return d, nil
+}
+
+// FadeDone returns a channel closed once the running crossfade has sent
+// its target or was cancelled. It is closed already when no fade runs.
+func (lc *LampWithClient) FadeDone() <-chan struct{} {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if lc.fadeDone == nil {
+		done := make(chan struct{})
+		close(done)
+		lc.fadeDone = done
+	}
+	return lc.fadeDone
+}
+
+// crossfade fades from what the strip shows to s in the background, then
+// sends s. Breathe and strobe fade to their full color first.
+func (lc *LampWithClient) crossfade(s State) error {
+	r, g, b, err := ParseColor(s.ControlColor)
+	if err != nil {
+		return err
+	}
+	if s.ControlRanges != "" && s.ControlMode != ModeSingle {
+		if _, err := ParseRanges(s.ControlRanges, s.Quantity); err != nil {
+			return err
+		}
+	}
+
+	fb := lc.NewFramebuffer()
+	to := target(s, fb.Front(), Color{R: r, G: g, B: b})
+
+	done := make(chan struct{})
+	lc.mu.Lock()
+	lc.fadeDone = done
+	lc.mu.Unlock()
+
+	lc.startEffect(func(stop <-chan bool) {
+		var effect func(stop <-chan bool)
+		if lc.fade(fb, to, s.ControlFade, stop) {
+			var err error
+			if effect, err = lc.send(s); err != nil {
+				slog.Warn("effect frame failed", "effect", "fade", "err", err)
+			}
+		}
+		close(done)
+
+		if effect != nil {
+			effect(stop)
+		}
+	})
+	return nil
+}
+
+// target is what the strip shows once s is sent, breathe and strobe at
+// their full color.
+func target(s State, front []Color, c Color) []Color {
+	to := make([]Color, len(front))
+	switch {
+	case s.ControlMode == ModeSingle:
+		copy(to, front)
+		if i := s.ControlPosition - 1; i >= 0 && i < len(to) {
+			to[i] = c
+		}
+	case s.ControlRanges != "":
+		ranges, _ := ParseRanges(s.ControlRanges, s.Quantity)
+		for _, r := range ranges {
+			for i := r.From; i <= r.To && i <= len(to); i++ {
+				to[i-1] = c
+			}
+		}
+	default:
+		n := s.ControlPercentage * s.Quantity / 100
+		for i := 0; i < n && i < len(to); i++ {
+			to[i] = c
+		}
+	}
+	return to
+}
+
+// fade steps fb from what the strip shows to to over d. Frames come every
+// fadeStep, or as often as the bus carries the writes one frame takes:
+// whole-strip fades are one fill per frame, others a write per LED. It
+// reports whether the fade ran to the end.
+func (lc *LampWithClient) fade(fb *Framebuffer, to []Color, d time.Duration, stop <-chan bool) bool {
+	from := fb.Front()
+	if len(from) != len(to) {
+		return true
+	}
+
+	// 以中间一帧估算每帧的写入次数
+	mid := &Framebuffer{lc: lc, back: make([]Color, len(to)), Buffer: fb.Buffer}
+	for i := range to {
+		mid.back[i] = lc.FadeCurve.Mix(from[i], to[i], 0.5)
+	}
+	writes := max(mid.writes(), 1)
+	interval := max(fadeStep, time.Duration(writes)*lc.Bus.WriteTime(3))
+
+	p := lc.newPacer("fade", writes, interval)
+	for frame, ok := 0, true; ok; frame, ok = p.next(stop) {
+		t := float64(time.Duration(frame)*interval) / float64(d)
+		if t >= 1 {
+			return true
+		}
+		for i := range to {
+			fb.Set(i, lc.FadeCurve.Mix(from[i], to[i], t))
+		}
+		if err := fb.Push(); err != nil {
+			slog.Warn("effect frame failed", "effect", "fade", "err", err)
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package lamp
+
+import (
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+)
+
+// FollowStyle is how Follow shows the level of a signal.
+type FollowStyle int
+
+const (
+	// FollowBrightness lights the LEDs in Color, as bright as the level.
+	FollowBrightness FollowStyle = iota
+	// FollowColor lights the LEDs in a color between Color at level 0 and
+	// To at level 1.
+	FollowColor
+)
+
+var followStyleNames = []string{FollowBrightness: "brightness", FollowColor: "color"}
+
+func (s FollowStyle) String() string {
+	if int(s) < len(followStyleNames) {
+		return followStyleNames[s]
+	}
+	return strconv.Itoa(int(s))
+}
+
+// ParseFollowStyle parses "brightness" or "color".
+func ParseFollowStyle(s string) (FollowStyle, error) {
+	for st, n := range followStyleNames {
+		if n == s {
+			return FollowStyle(st), nil
+		}
+	}
+	return 0, optionError("style", "err.option.follow_style", s)
+}
+
+// Follow is an effect driven by a signal, see LampWithClient.Follow.
+type Follow struct {
+	// Level returns the signal now, mapped to 0..1. Values outside are
+	// clamped.
+	Level func() float64
+	Style FollowStyle
+	Color Color
+	To    Color
+	// Ranges limits the effect to some LEDs, see ParseRanges. Empty is
+	// the whole strip.
+	Ranges string
+	// Interval is the time between frames, at least fadeStep (50ms). 0 is
+	// fadeStep.
+	Interval time.Duration
+
+	// Done, if not nil, ends the effect when it is closed, as when the
+	// signal runs out.
+	Done <-chan struct{}
+	// Stopped, if not nil, is called once the effect has ended for good,
+	// however it ended. Waiting while the strip is held (see Suspend) does
+	// not end it.
+	Stopped func()
+}
+
+// Follow runs f in the background, like Marquee, until the signal is done
+// or the next Exec, Marquee or StopMarquee. Each frame reads the level and
+// pushes the LEDs that changed.
+func (lc *LampWithClient) Follow(f Follow) error {
+	s := lc.State()
+	var ranges []Range
+	if f.Ranges != "" {
+		var err error
+		if ranges, err = ParseRanges(f.Ranges, s.Quantity); err != nil {
+			return err
+		}
+	} else {
+		ranges = []Range{{From: 1, To: s.Quantity}}
+	}
+
+	lc.startBackground(&effect{run: func(stop <-chan bool) { lc.follow(f, ranges, stop) }, end: f.Stopped})
+	return nil
+}
+
+func (lc *LampWithClient) follow(f Follow, ranges []Range, stop <-chan bool) {
+	// 整条灯带时每帧一次填充, 否则每颗一次写入
+	writes := 1
+	if len(ranges) != 1 || ranges[0].From != 1 {
+		writes = 0
+		for _, r := range ranges {
+			writes += r.To - r.From + 1
+		}
+	}
+	interval := max(f.Interval, fadeStep)
+	p := lc.newPacer("follow", writes, interval)
+
+	fb := lc.NewFramebuffer()
+	fb.Fill(Color{})
+	for ok := true; ok; _, ok = p.next(stop) {
+		select {
+		case <-f.Done:
+			return
+		default:
+		}
+
+		level := f.Level()
+		if math.IsNaN(level) {
+			level = 0
+		}
+		level = min(max(level, 0), 1)
+
+		var c Color
+		switch f.Style {
+		case FollowColor:
+			c = lc.FadeCurve.Mix(f.Color, f.To, level)
+		default:
+			c = lc.FadeCurve.Mix(Color{}, f.Color, level)
+		}
+
+		if fb.Len() != len(lc.State().Pixels) {
+			fb.Reset()
+			fb.Fill(Color{})
+		}
+		for _, r := range ranges {
+			for i := r.From; i <= r.To; i++ {
+				fb.Set(i-1, c)
+			}
+		}
+		if err := fb.Push(); err != nil {
+			slog.Warn("effect frame failed", "effect", "follow", "err", err)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package lamp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Color is the color of one LED.
+type Color struct {
+	R, G, B byte
+}
+
+// Framebuffer is a color per LED, drawn off screen and sent with Push.
+// Index 0 is the LED at position 1.
+//
+// Push compares the frame with what the strip shows, as tracked from
+// every frame sent (State().Pixels), and sends only the difference. The
+// stock controller can only fill the first N LEDs or set one LED per
+// request, so Push picks the cheaper of writing each changed LED and one
+// fill followed by the LEDs the fill got wrong. Controllers with a pixel
+// buffer take the whole difference in a few writes, see Buffer.
+type Framebuffer struct {
+	lc   *LampWithClient
+	back []Color
+
+	// Buffer is the first holding register of the controller's pixel
+	// buffer, or 0 when it has none. The buffer holds 3 bytes per LED in
+	// g,r,b order, packed two bytes to a register.
+	Buffer uint16
+}
+
+// ErrFrameSize is returned by Push for a frame of another size than the
+// strip. Reset resizes the frame.
+var ErrFrameSize = errors.New("lamp: framebuffer size does not match the strip")
+
+// maxRegisters is the most registers one WriteMultipleRegisters takes.
+const maxRegisters = 123
+
+// NewFramebuffer returns a frame the size of the strip, holding what the
+// strip shows.
+func (lc *LampWithClient) NewFramebuffer() *Framebuffer {
+	fb := &Framebuffer{lc: lc}
+	fb.Reset()
+	return fb
+}
+
+// Reset resizes the frame to the strip and copies what the strip shows
+// into it.
+func (fb *Framebuffer) Reset() {
+	fb.back = fb.Front()
+}
+
+// Len is the number of LEDs.
+func (fb *Framebuffer) Len() int { return len(fb.back) }
+
+// At returns the color of LED i in the frame.
+func (fb *Framebuffer) At(i int) Color { return fb.back[i] }
+
+// Set colors LED i. Indexes outside the strip are ignored.
+func (fb *Framebuffer) Set(i int, c Color) {
+	if i >= 0 && i < len(fb.back) {
+		fb.back[i] = c
+	}
+}
+
+// Front returns what the strip shows, the frame Push compares with.
+func (fb *Framebuffer) Front() []Color {
+	pixels := fb.lc.State().Pixels
+	front := make([]Color, len(pixels))
+	for i, p := range pixels {
+		front[i] = Color{R: p.R, G: p.G, B: p.B}
+	}
+	return front
+}
+
+// Fill colors every LED.
+func (fb *Framebuffer) Fill(c Color) {
+	for i := range fb.back {
+		fb.back[i] = c
+	}
+}
+
+// Push sends the frame to the strip. The frame stays as it is, so the
+// next one can be drawn over it.
+func (fb *Framebuffer) Push() error {
+	front := fb.lc.State().Pixels
+	if len(front) != len(fb.back) {
+		return fmt.Errorf("%w: %d LEDs, the strip has %d", ErrFrameSize, len(fb.back), len(front))
+	}
+
+	var changed []int
+	for i, c := range fb.back {
+		if !shows(front[i], c) {
+			changed = append(changed, i)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	if fb.Buffer != 0 {
+		return fb.pushBuffer(changed[0], changed[len(changed)-1])
+	}
+
+	// 一帧填充加修正比逐颗写更少时先填充
+	fill, n, fixes := fb.bestFill()
+	if 1+len(fixes) < len(changed) {
+		if err := fb.lc.Control([]byte{ModeNormal, byte(n), fill.G, fill.R, fill.B, 0}); err != nil {
+			return err
+		}
+		changed = fixes
+	}
+	for _, i := range changed {
+		c := fb.back[i]
+		if err := fb.lc.Control([]byte{ModeSingle, byte(i + 1), c.G, c.R, c.B, 0}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writes is the number of writes Push would send now.
+func (fb *Framebuffer) writes() int {
+	front := fb.lc.State().Pixels
+	if len(front) != len(fb.back) {
+		return 0
+	}
+
+	first, last, changed := -1, 0, 0
+	for i, c := range fb.back {
+		if !shows(front[i], c) {
+			if first < 0 {
+				first = i
+			}
+			last = i
+			changed++
+		}
+	}
+	switch {
+	case changed == 0:
+		return 0
+	case fb.Buffer != 0:
+		regs := (3*(last+1)+1)/2 - 3*first/2
+		return (regs + maxRegisters - 1) / maxRegisters
+	}
+	_, _, fixes := fb.bestFill()
+	return min(changed, 1+len(fixes))
+}
+
+// shows reports whether p is c, lit steadily.
+func shows(p Pixel, c Color) bool {
+	steady := p.Mode == ModeNormal || p.Mode == ModeSingle || p.Mode == 0
+	return steady && p.R == c.R && p.G == c.G && p.B == c.B
+}
+
+// bestFill finds the fill frame, a color on the first n LEDs and the rest
+// off, that leaves the fewest LEDs to fix, and returns those LEDs.
+func (fb *Framebuffer) bestFill() (fill Color, n int, fixes []int) {
+	var (
+		black = Color{}
+		q     = len(fb.back)
+		best  = q + 1
+	)
+	// 后 q-n 颗中不是黑色的数量
+	offAfter := make([]int, q+1)
+	for i := q - 1; i >= 0; i-- {
+		offAfter[i] = offAfter[i+1]
+		if fb.back[i] != black {
+			offAfter[i]++
+		}
+	}
+
+	seen := map[Color]bool{}
+	for _, c := range fb.back {
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+
+		wrong := 0
+		for k := 0; k <= q; k++ {
+			if cost := wrong + offAfter[k]; cost < best {
+				best, fill, n = cost, c, k
+			}
+			if k < q && fb.back[k] != c {
+				wrong++
+			}
+		}
+	}
+
+	for i, c := range fb.back {
+		if (i < n && c != fill) || (i >= n && c != black) {
+			fixes = append(fixes, i)
+		}
+	}
+	return fill, n, fixes
+}
+
+// pushBuffer writes LEDs from to to into the pixel buffer.
+func (fb *Framebuffer) pushBuffer(from, to int) error {
+	// 一个寄存器两个字节, 起止对齐到寄存器
+	start, end := 3*from/2, (3*(to+1)+1)/2
+
+	for reg := start; reg < end; reg += maxRegisters {
+		count := min(maxRegisters, end-reg)
+		value := make([]byte, 2*count)
+		for j := range value {
+			led, ch := (2*reg+j)/3, (2*reg+j)%3
+			if led >= len(fb.back) {
+				break
+			}
+			c := fb.back[led]
+			value[j] = [3]byte{c.G, c.R, c.B}[ch]
+		}
+
+		lc := fb.lc
+		lc.busMu.Lock()
+		_, err := lc.Client.WriteMultipleRegisters(fb.Buffer+uint16(reg), uint16(count), value)
+		lc.busMu.Unlock()
+		if err = Classify(err); err != nil {
+			lc.publish(Event{State: lc.State(), Err: err})
+			return err
+		}
+	}
+
+	fb.lc.mu.Lock()
+	for i := from; i <= to && i < len(fb.lc.pixels); i++ {
+		c := fb.back[i]
+		fb.lc.pixels[i] = Pixel{R: c.R, G: c.G, B: c.B, Mode: ModeSingle}
+	}
+	fb.lc.mu.Unlock()
+
+	fb.lc.publish(Event{State: fb.lc.State()})
+	return nil
+}
@@ -0,0 +1,283 @@
+package lamp
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+)
+
+// Threshold colors a Gauge from the value At up.
+type Threshold struct {
+	At    float64
+	Color Color
+}
+
+// DefaultThresholds are green, amber from 70% of min..max and red from 90%.
+func DefaultThresholds(min, max float64) []Threshold {
+	return []Threshold{
+		{At: min, Color: Color{G: 255}},
+		{At: min + 0.7*(max-min), Color: Color{R: 255, G: 191}},
+		{At: min + 0.9*(max-min), Color: Color{R: 255}},
+	}
+}
+
+// GaugeSettings is how a Gauge shows its value.
+type GaugeSettings struct {
+	// Min shows as an empty bar, Max as a full one.
+	Min, Max float64
+	// Thresholds color the whole bar by its value: the last threshold the
+	// value reaches, the first below them all. They are in order of At.
+	// Empty is DefaultThresholds.
+	Thresholds []Threshold
+	// Reverse fills the bar from the last LED down.
+	Reverse bool
+	// Ease is how long the bar takes to move to a new value, 0 to jump.
+	Ease time.Duration
+}
+
+// DefaultGaugeSettings shows 0 to 100 with the default thresholds and
+// moves in half a second.
+var DefaultGaugeSettings = GaugeSettings{Min: 0, Max: 100, Ease: 500 * time.Millisecond}
+
+// Validate checks the range, the order of the thresholds and the ease.
+func (s GaugeSettings) Validate() error {
+	if !(s.Min < s.Max) || math.IsInf(s.Min, 0) || math.IsInf(s.Max, 0) {
+		return optionError("gauge", "err.option.gauge_range", s.Min, s.Max)
+	}
+	for i, t := range s.Thresholds {
+		if math.IsNaN(t.At) || math.IsInf(t.At, 0) {
+			return optionError("gauge", "err.option.gauge_threshold", t.At)
+		}
+		if i > 0 && t.At < s.Thresholds[i-1].At {
+			return optionError("gauge", "err.option.gauge_order", t.At, s.Thresholds[i-1].At)
+		}
+	}
+	if s.Ease < 0 || s.Ease > MaxFade {
+		return optionError("gauge", "err.option.gauge_ease", s.Ease, MaxFade)
+	}
+	return nil
+}
+
+// colorAt returns the color of the bar at v.
+func (s GaugeSettings) colorAt(v float64) Color {
+	th := s.Thresholds
+	if len(th) == 0 {
+		th = DefaultThresholds(s.Min, s.Max)
+	}
+	c := th[0].Color
+	for _, t := range th {
+		if v >= t.At {
+			c = t.Color
+		}
+	}
+	return c
+}
+
+// Gauge shows a value as a bar along the strip, a progress bar or a level.
+// The last LED of the bar is dimmed by how much of it the value covers,
+// and the bar moves smoothly to each new value.
+//
+// Like a Compositor the gauge runs as the background effect of the strip:
+// a command sent to the strip directly takes the strip over until the
+// next Set, and while the strip is held the gauge waits.
+type Gauge struct {
+	lc *LampWithClient
+
+	mu       sync.Mutex
+	settings GaugeSettings
+	// value is where the bar goes, from where it was at set.
+	value, from float64
+	set         time.Time
+	running     bool
+	changed     chan struct{}
+	effect      *effect
+}
+
+// NewGauge returns a gauge for the strip with DefaultGaugeSettings.
+func (lc *LampWithClient) NewGauge() *Gauge {
+	g := &Gauge{lc: lc, settings: DefaultGaugeSettings, changed: make(chan struct{}, 1)}
+	g.effect = &effect{run: g.run, end: func() {
+		g.mu.Lock()
+		g.running = false
+		g.mu.Unlock()
+	}}
+	return g
+}
+
+// Configure changes how the gauge shows its value. A showing gauge is
+// redrawn at once.
+func (g *Gauge) Configure(s GaugeSettings) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	s.Thresholds = append([]Threshold(nil), s.Thresholds...)
+
+	g.mu.Lock()
+	g.settings = s
+	running := g.running
+	g.mu.Unlock()
+
+	if running {
+		g.wake()
+	}
+	return nil
+}
+
+// Settings returns how the gauge shows its value.
+func (g *Gauge) Settings() GaugeSettings {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	s := g.settings
+	s.Thresholds = append([]Threshold(nil), s.Thresholds...)
+	return s
+}
+
+// Set moves the bar to v, over the ease of the settings, and shows the
+// gauge if something else had the strip. A gauge that was not showing
+// grows from empty. Values outside min..max show as an empty or full bar.
+func (g *Gauge) Set(v float64) error {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return optionError("gauge", "err.option.gauge_value", v)
+	}
+
+	now := time.Now()
+	g.mu.Lock()
+	running := g.running
+	if running {
+		g.from, _ = g.position(now)
+	} else {
+		g.from = g.settings.Min
+	}
+	g.value, g.set = v, now
+	// 先标记为运行中, 连续的 Set 只唤醒同一个效果
+	g.running = true
+	g.mu.Unlock()
+
+	if running {
+		g.wake()
+		return nil
+	}
+	// startBackground 会等待正在运行的效果退出, 不能持有 g.mu
+	g.lc.startBackground(g.effect)
+	return nil
+}
+
+// Show puts the gauge back on the strip at its last value after a command
+// took it over.
+func (g *Gauge) Show() {
+	g.mu.Lock()
+	running := g.running
+	if !running {
+		g.from, g.set = g.value, time.Now()
+		g.running = true
+	}
+	g.mu.Unlock()
+
+	if !running {
+		g.lc.startBackground(g.effect)
+	}
+}
+
+// Value returns the last value set.
+func (g *Gauge) Value() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.value
+}
+
+// Showing reports whether the gauge is on the strip, or waits for the
+// strip to be released (see Suspend).
+func (g *Gauge) Showing() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.running
+}
+
+func (g *Gauge) wake() {
+	select {
+	case g.changed <- struct{}{}:
+	default:
+	}
+}
+
+// position returns the value the bar shows at now, and whether it is still
+// moving. g.mu must be held.
+func (g *Gauge) position(now time.Time) (float64, bool) {
+	ease := g.settings.Ease
+	if ease <= 0 || now.Sub(g.set) >= ease {
+		return g.value, false
+	}
+	t := float64(now.Sub(g.set)) / float64(ease)
+	return g.from + (g.value-g.from)*t, true
+}
+
+func (g *Gauge) run(stop <-chan bool) {
+	fb := g.lc.NewFramebuffer()
+	var p *pacer
+	for {
+		g.mu.Lock()
+		v, moving := g.position(time.Now())
+		g.mu.Unlock()
+
+		frame := g.Frame(v)
+		if fb.Len() != len(frame) {
+			fb.Reset()
+		}
+		for i, col := range frame {
+			fb.Set(i, col)
+		}
+		writes := fb.writes()
+		if err := fb.Push(); err != nil && !errors.Is(err, ErrFrameSize) {
+			slog.Warn("effect frame failed", "effect", "gauge", "err", err)
+		}
+
+		// 移动中按渐变步长重绘, 否则等下一个值; 总线跟不上时跳过几帧
+		var next <-chan time.Time
+		switch {
+		case !moving:
+			p = nil
+		case p == nil:
+			p = g.lc.newPacer("gauge", writes, fadeStep)
+			fallthrough
+		default:
+			p.fits(writes)
+			next = time.After(time.Until(p.due()))
+		}
+		select {
+		case <-stop:
+			return
+		case <-g.changed:
+		case <-next:
+		}
+	}
+}
+
+// Frame draws the bar for v with the current settings.
+func (g *Gauge) Frame(v float64) []Color {
+	s := g.Settings()
+	q := g.lc.State().Quantity
+
+	frame := make([]Color, q)
+	lit := min(max((v-s.Min)/(s.Max-s.Min), 0), 1) * float64(q)
+	full := int(lit)
+	c := s.colorAt(v)
+	for i := 0; i < q; i++ {
+		led := i
+		if s.Reverse {
+			led = q - 1 - i
+		}
+		switch {
+		case i < full:
+			frame[led] = c
+		case i == full:
+			// 最后一颗按覆盖的比例调暗
+			frame[led] = g.lc.FadeCurve.Mix(Color{}, c, lit-float64(full))
+		}
+	}
+	return frame
+}
@@ -0,0 +1,105 @@
+package lamp
+
+// effect is a background effect. A long-lived one (layers, a gauge or a
+// follow) runs until a command takes the strip over, and can be paused
+// and started again.
+type effect struct {
+	run  func(stop <-chan bool)
+	long bool
+	// end, if not nil, is called once the effect has ended for good, not
+	// when it was paused.
+	end func()
+	// paused is set while the effect stops to start again. lc.mu guards it.
+	paused bool
+}
+
+// startBackground runs the long-lived effect e, stopping any running
+// effect. While the strip is held, e waits for Resume instead, and
+// replaces what was waiting.
+func (lc *LampWithClient) startBackground(e *effect) {
+	e.long = true
+
+	lc.mu.Lock()
+	if !lc.holding {
+		lc.mu.Unlock()
+		lc.launch(e)
+		return
+	}
+	old := lc.held
+	lc.held = e
+	lc.mu.Unlock()
+
+	if old != nil && old != e && old.end != nil {
+		old.end()
+	}
+}
+
+// Suspend stops the layers, gauge or follow running on the strip and
+// holds them, so that something else can show for a while: Resume starts
+// them again. Layers, a gauge or a follow started while the strip is held
+// wait for Resume as well. The alert manager holds the strip while an
+// alert shows.
+func (lc *LampWithClient) Suspend() {
+	lc.mu.Lock()
+	if lc.holding {
+		lc.mu.Unlock()
+		return
+	}
+	lc.holding = true
+	e := lc.background
+	if e != nil {
+		e.paused = true
+	}
+	lc.held = e
+	lc.mu.Unlock()
+
+	if e != nil {
+		lc.StopMarquee()
+	}
+}
+
+// Resume ends the hold of Suspend and starts what waited for it. It
+// reports whether anything did.
+func (lc *LampWithClient) Resume() bool {
+	lc.mu.Lock()
+	e := lc.held
+	lc.held, lc.holding = nil, false
+	lc.mu.Unlock()
+
+	if e == nil {
+		return false
+	}
+	lc.launch(e)
+	return true
+}
+
+// Drop ends what waits for Resume for good, as a command sent to the
+// strip would have. The strip stays held.
+func (lc *LampWithClient) Drop() {
+	lc.mu.Lock()
+	e := lc.held
+	lc.held = nil
+	lc.mu.Unlock()
+
+	if e != nil && e.end != nil {
+		e.end()
+	}
+}
+
+// Resend sends the state to the strip again after the controller lost it,
+// as after a reconnect: Exec, then the layers, gauge or follow running on
+// the strip start again and redraw over it.
+func (lc *LampWithClient) Resend() error {
+	lc.mu.Lock()
+	e := lc.background
+	if e != nil {
+		e.paused = true
+	}
+	lc.mu.Unlock()
+
+	err := lc.Exec()
+	if e != nil {
+		lc.startBackground(e)
+	}
+	return err
+}
@@ -0,0 +1,571 @@
+package lamp
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goburrow/modbus"
+)
+
+const (
+	ModeNormal  = 3
+	ModeBreathe = 4
+	ModeStrobe  = 5
+	ModeSingle  = 6
+	ModeMarquee = 7
+)
+
+var modeNames = map[int]string{
+	ModeNormal:  "normal",
+	ModeBreathe: "breathe",
+	ModeStrobe:  "strobe",
+	ModeSingle:  "single",
+	ModeMarquee: "marquee",
+}
+
+// ModeName returns the name files and commands use for mode.
+func ModeName(mode int) string {
+	if n, ok := modeNames[mode]; ok {
+		return n
+	}
+	return strconv.Itoa(mode)
+}
+
+// ParseMode is the inverse of ModeName.
+func ParseMode(name string) (int, error) {
+	for m, n := range modeNames {
+		if n == name {
+			return m, nil
+		}
+	}
+	return 0, optionError("mode", "err.option.mode_name", name)
+}
+
+// ErrInvalidOption is wrapped by every error caused by a bad option value.
+var ErrInvalidOption = errors.New("lamp: invalid option")
+
+// Options are the settings exec turns into a control frame.
+type Options struct {
+	ControlMode       int
+	ControlPercentage int
+	ControlPosition   int
+	ControlColor      string
+	// ControlRanges limits the fill modes to some LEDs, see ParseRanges.
+	// Empty means the first ControlPercentage percent.
+	ControlRanges string
+	// ControlPeriod is the breath of breathe and the flash cycle of
+	// strobe, 0 for the mode's default. See DefaultPeriod.
+	ControlPeriod time.Duration
+	// ControlFade crossfades from what the strip shows to the options
+	// when they are executed, 0 cuts at once. See Exec.
+	ControlFade time.Duration
+}
+
+// Pixel is the last known output of one LED.
+type Pixel struct {
+	R, G, B byte
+	Mode    int
+}
+
+// State is a snapshot of the controller.
+type State struct {
+	Options
+	Quantity int
+
+	// Pixels holds what each LED shows, decoded from the frames sent so
+	// far. Pixels[0] is the LED at position 1.
+	Pixels []Pixel
+}
+
+// Event is published to subscribers when the state changes or a bus
+// transaction fails.
+type Event struct {
+	State State
+	// Frame is the control frame that was sent, for events from Control.
+	Frame []byte
+	Err   error
+}
+
+// LampWithClient lampwith client
+type LampWithClient struct {
+	Client modbus.Client
+	// Bus paces the background effects, see Bus.
+	Bus Bus
+	// FadeCurve is the space crossfades mix colors in.
+	FadeCurve FadeCurve
+
+	mu       sync.Mutex
+	opts     Options
+	quantity int
+	pixels   []Pixel
+
+	cStopMarquee chan bool
+	marqueeDone  chan struct{}
+	fadeDone     chan struct{}
+	// background is the long-lived effect running, held the one waiting
+	// for Resume while holding is set. See Suspend.
+	background *effect
+	held       *effect
+	holding    bool
+
+	// busMu serializes transactions on the serial line.
+	busMu sync.Mutex
+
+	subMu sync.Mutex
+	subs  map[chan Event]struct{}
+}
+
+// New wraps client with the default options.
+func New(client modbus.Client) *LampWithClient {
+	return &LampWithClient{
+		Client:   client,
+		Bus:      DefaultBus(),
+		quantity: 30,
+		opts: Options{
+			ControlMode:       ModeNormal,
+			ControlPercentage: 100,
+			ControlPosition:   1,
+			ControlColor:      "3,4,5",
+		},
+		pixels: make([]Pixel, 30),
+		subs:   make(map[chan Event]struct{}),
+	}
+}
+
+// State returns the current options and strip quantity.
+func (lc *LampWithClient) State() State {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	return State{
+		Options:  lc.opts,
+		Quantity: lc.quantity,
+		Pixels:   append([]Pixel(nil), lc.pixels...),
+	}
+}
+
+// SetQuantity sets the number of LEDs on the strip. Options that no
+// longer fit are brought in, so the next Exec sends nothing past the end:
+// the single-mode position moves to the last LED it can address and
+// ranges past the end are cleared.
+func (lc *LampWithClient) SetQuantity(n int) error {
+	if n <= 0 || n > 255 {
+		return optionError("quantity", "err.option.quantity", n)
+	}
+
+	lc.mu.Lock()
+	lc.quantity = n
+	if lc.opts.ControlPosition >= n {
+		lc.opts.ControlPosition = max(n-1, 1)
+	}
+	if lc.opts.ControlRanges != "" {
+		if _, err := ParseRanges(lc.opts.ControlRanges, n); err != nil {
+			lc.opts.ControlRanges = ""
+		}
+	}
+	pixels := make([]Pixel, n)
+	copy(pixels, lc.pixels)
+	lc.pixels = pixels
+	lc.mu.Unlock()
+
+	lc.publish(Event{State: lc.State()})
+	return nil
+}
+
+// SetMode sets the mode used by the next Exec, at the mode's default
+// speed.
+func (lc *LampWithClient) SetMode(mode int) error {
+	return lc.update(func(o *Options) {
+		if o.ControlMode != mode {
+			o.ControlPeriod = 0
+		}
+		o.ControlMode = mode
+	})
+}
+
+// SetPeriod sets the period of breathe and strobe, 0 for the default.
+func (lc *LampWithClient) SetPeriod(d time.Duration) error {
+	return lc.update(func(o *Options) { o.ControlPeriod = d })
+}
+
+// SetPercentage sets the share of the strip lit by the fill modes.
+func (lc *LampWithClient) SetPercentage(n int) error {
+	return lc.update(func(o *Options) { o.ControlPercentage = n })
+}
+
+// SetPosition sets the LED addressed in single mode.
+func (lc *LampWithClient) SetPosition(n int) error {
+	return lc.update(func(o *Options) { o.ControlPosition = n })
+}
+
+// SetColor sets the color, each channel 0-255.
+func (lc *LampWithClient) SetColor(r, g, b int) error {
+	return lc.update(func(o *Options) { o.ControlColor = FormatColor(r, g, b) })
+}
+
+func (lc *LampWithClient) update(fn func(o *Options)) error {
+	lc.mu.Lock()
+	opts := lc.opts
+	fn(&opts)
+	if err := validate(opts, lc.quantity); err != nil {
+		lc.mu.Unlock()
+		return err
+	}
+	lc.opts = opts
+	lc.mu.Unlock()
+
+	lc.publish(Event{State: lc.State()})
+	return nil
+}
+
+// SetOptions replaces all options at once.
+func (lc *LampWithClient) SetOptions(opts Options) error {
+	return lc.update(func(o *Options) { *o = opts })
+}
+
+// Apply replaces all options at once and executes them.
+func (lc *LampWithClient) Apply(opts Options) error {
+	if err := lc.SetOptions(opts); err != nil {
+		return err
+	}
+
+	return lc.Exec()
+}
+
+// Validate checks the options against a strip of quantity LEDs, the way
+// SetOptions does.
+func (o Options) Validate(quantity int) error {
+	return validate(o, quantity)
+}
+
+func validate(o Options, quantity int) error {
+	switch o.ControlMode {
+	case ModeNormal, ModeBreathe, ModeStrobe, ModeSingle, ModeMarquee:
+	default:
+		return optionError("mode", "err.option.mode", o.ControlMode)
+	}
+
+	if o.ControlPercentage <= 0 || o.ControlPercentage > 100 {
+		return optionError("percentage", "err.option.percentage", o.ControlPercentage)
+	}
+
+	if o.ControlPosition <= 0 || o.ControlPosition >= quantity {
+		return optionError("position", "err.option.position", o.ControlPosition, quantity-1)
+	}
+
+	if _, _, _, err := ParseColor(o.ControlColor); err != nil {
+		return err
+	}
+
+	if o.ControlRanges != "" {
+		if _, err := ParseRanges(o.ControlRanges, quantity); err != nil {
+			return err
+		}
+	}
+
+	if o.ControlFade < 0 || o.ControlFade > MaxFade {
+		return optionError("fade", "err.option.fade", FormatPeriod(o.ControlFade), MaxFade)
+	}
+
+	return validatePeriod(o)
+}
+
+// FormatColor renders a color the way ControlColor stores it.
+func FormatColor(r, g, b int) string {
+	return strconv.Itoa(r) + "," + strconv.Itoa(g) + "," + strconv.Itoa(b)
+}
+
+// ParseColor parses an "r,g,b" string, each channel 0-255.
+func ParseColor(c string) (r, g, b byte, err error) {
+	s := strings.Split(c, ",")
+	if len(s) != 3 {
+		return 0, 0, 0, optionError("color", "err.option.color_rgb", c)
+	}
+
+	var v [3]byte
+	for i := range s {
+		n, err := strconv.Atoi(strings.TrimSpace(s[i]))
+		if err != nil || n < 0 || n > 255 {
+			return 0, 0, 0, optionError("color", "err.option.color_channel", s[i])
+		}
+		v[i] = byte(n)
+	}
+
+	return v[0], v[1], v[2], nil
+}
+
+// Subscribe returns a channel of events and a function that cancels it.
+// Events are dropped for a subscriber that falls behind.
+func (lc *LampWithClient) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 16)
+
+	lc.subMu.Lock()
+	lc.subs[ch] = struct{}{}
+	lc.subMu.Unlock()
+
+	return ch, func() {
+		lc.subMu.Lock()
+		defer lc.subMu.Unlock()
+		if _, ok := lc.subs[ch]; ok {
+			delete(lc.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (lc *LampWithClient) publish(ev Event) {
+	lc.subMu.Lock()
+	defer lc.subMu.Unlock()
+
+	for ch := range lc.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Control writes one raw control frame to the strip.
+func (lc *LampWithClient) Control(val []byte) error {
+	address := uint16(9)
+	quantity := uint16(3)
+
+	lc.busMu.Lock()
+	_, err := lc.Client.WriteMultipleRegisters(address, quantity, val)
+	lc.busMu.Unlock()
+	err = Classify(err)
+	frame := append([]byte(nil), val...)
+	if err != nil {
+		lc.publish(Event{State: lc.State(), Frame: frame, Err: err})
+		return err
+	}
+
+	lc.mu.Lock()
+	track(lc.pixels, val)
+	lc.mu.Unlock()
+
+	lc.publish(Event{State: lc.State(), Frame: frame})
+	return nil
+}
+
+// Ping checks that the controller answers without changing the strip. A
+// Modbus exception still proves a device is listening.
+func (lc *LampWithClient) Ping() error {
+	lc.busMu.Lock()
+	_, err := lc.Client.ReadHoldingRegisters(9, 3)
+	lc.busMu.Unlock()
+
+	var excErr *ExceptionError
+	if err = Classify(err); errors.As(err, &excErr) {
+		return nil
+	}
+	return err
+}
+
+// track applies a control frame to the pixel model.
+func track(pixels []Pixel, val []byte) {
+	if len(val) < 6 {
+		return
+	}
+
+	p := Pixel{G: val[2], R: val[3], B: val[4], Mode: int(val[0])}
+	switch p.Mode {
+	case ModeNormal, ModeBreathe, ModeStrobe:
+		for i := range pixels {
+			if i < int(val[1]) {
+				pixels[i] = p
+			} else {
+				pixels[i] = Pixel{Mode: ModeNormal}
+			}
+		}
+	case ModeSingle:
+		if i := int(val[1]) - 1; i >= 0 && i < len(pixels) {
+			pixels[i] = p
+		}
+	}
+}
+
+// Marquee starts a marquee in the background, stopping any running one.
+// color is "r", "g" or "b"; anything else uses the current color.
+func (lc *LampWithClient) Marquee(color string) {
+	lc.startEffect(func(stop <-chan bool) { lc.marquee(color, stop) })
+}
+
+// startEffect runs a background effect until StopMarquee, stopping any
+// running one.
+func (lc *LampWithClient) startEffect(run func(stop <-chan bool)) {
+	lc.launch(&effect{run: run})
+}
+
+// launch runs e until StopMarquee, stopping any running effect. e is the
+// background of the strip if it is long-lived, see startBackground.
+func (lc *LampWithClient) launch(e *effect) {
+	lc.StopMarquee()
+
+	stop := make(chan bool)
+	done := make(chan struct{})
+
+	lc.mu.Lock()
+	lc.cStopMarquee = stop
+	lc.marqueeDone = done
+	lc.background = nil
+	e.paused = false
+	if e.long {
+		lc.background = e
+	}
+	lc.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		e.run(stop)
+
+		lc.mu.Lock()
+		paused := e.paused
+		e.paused = false
+		if lc.background == e {
+			lc.background = nil
+		}
+		lc.mu.Unlock()
+		if !paused && e.end != nil {
+			e.end()
+		}
+	}()
+}
+
+// StopMarquee stops the running effect, if any, and waits for it to exit.
+func (lc *LampWithClient) StopMarquee() {
+	lc.mu.Lock()
+	stop, done := lc.cStopMarquee, lc.marqueeDone
+	lc.cStopMarquee, lc.marqueeDone = nil, nil
+	lc.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+func (lc *LampWithClient) marquee(color string, stop <-chan bool) {
+	value := []byte{}
+
+	switch color {
+	case "r":
+		value = []byte{0x06, 0x00, 0x00, 0x25, 0x00, 0x00}
+	case "g":
+		value = []byte{0x06, 0x00, 0x25, 0x00, 0x00, 0x00}
+	case "b":
+		value = []byte{0x06, 0x00, 0x00, 0x00, 0x25, 0x00}
+	default:
+		// parse current color
+		r, g, b := lc.parseColor(lc.State().ControlColor)
+		value = []byte{0x06, 0x00, g, r, b, 0x00}
+	}
+
+	// 设置了 ranges 时只在这些灯珠上跑
+	s := lc.State()
+	var positions []int
+	ranges, err := ParseRanges(s.ControlRanges, s.Quantity)
+	if s.ControlRanges == "" || err != nil {
+		ranges = []Range{{From: 1, To: s.Quantity - 1}}
+	}
+	for _, r := range ranges {
+		for i := r.From; i <= r.To; i++ {
+			positions = append(positions, i)
+		}
+	}
+	// 只有一颗灯珠时没有可跑的位置
+	if len(positions) == 0 {
+		return
+	}
+
+	// 每步点亮一颗再熄灭; 总线跟不上时跳过几颗, 速度不变
+	p := lc.newPacer("marquee", 2, time.Millisecond*500)
+	for frame, ok := 0, true; ok; {
+		i := positions[frame%len(positions)]
+		value[1] = byte(i)
+		lc.logControl("marquee", value)
+
+		frame, ok = p.next(stop)
+
+		// turn off light
+		tmp := []byte{0x06, byte(i), 0x00, 0x00, 0x00, 0x00}
+		lc.logControl("marquee", tmp)
+	}
+}
+
+// logControl sends a frame for a background effect, which has no caller
+// to return the error to.
+func (lc *LampWithClient) logControl(effect string, val []byte) {
+	if err := lc.Control(val); err != nil {
+		slog.Warn("effect frame failed", "effect", effect, "frame", fmt.Sprintf("% x", val), "err", err)
+	}
+}
+
+func (lc *LampWithClient) parseColor(c string) (byte, byte, byte) {
+	r, g, b, err := ParseColor(c)
+	if err != nil {
+		slog.Warn("invalid color, using 25,0,0", "color", c, "err", err)
+		return 25, 0, 0
+	}
+
+	return r, g, b
+}
+
+// Exec sends the current options to the strip. With ControlFade the strip
+// first crossfades to them in the background; the next Exec, Marquee or
+// StopMarquee cancels the fade. Marquee never fades.
+func (lc *LampWithClient) Exec() error {
+	lc.StopMarquee()
+
+	s := lc.State()
+	if s.ControlFade > 0 && s.ControlMode != ModeMarquee {
+		return lc.crossfade(s)
+	}
+
+	effect, err := lc.send(s)
+	if effect != nil {
+		lc.startEffect(effect)
+	}
+	return err
+}
+
+// send sends s to the strip. For what the controller cannot run on its
+// own it returns the background effect that runs it.
+func (lc *LampWithClient) send(s State) (effect func(stop <-chan bool), err error) {
+	r, g, b := lc.parseColor(s.ControlColor)
+
+	if s.ControlRanges != "" && s.ControlMode != ModeSingle && s.ControlMode != ModeMarquee {
+		ranges, err := ParseRanges(s.ControlRanges, s.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		return lc.execRanges(s.Options, ranges, r, g, b)
+	}
+
+	switch s.ControlMode {
+	case ModeNormal:
+		quantity := (s.ControlPercentage * s.Quantity) / 100
+		value := []byte{0x03, byte(quantity), g, r, b, 0x00}
+		err = lc.Control(value)
+	case ModeBreathe:
+		quantity := (s.ControlPercentage * s.Quantity) / 100
+		value := []byte{0x04, byte(quantity), g, r, b, modeParam(ModeBreathe, s.ControlPeriod)}
+		err = lc.Control(value)
+	case ModeStrobe:
+		quantity := (s.ControlPercentage * s.Quantity) / 100
+		value := []byte{0x05, byte(quantity), g, r, b, modeParam(ModeStrobe, s.ControlPeriod)}
+		err = lc.Control(value)
+	case ModeSingle:
+		value := []byte{0x06, byte(s.ControlPosition), g, r, b, 0x00}
+		err = lc.Control(value)
+	case ModeMarquee:
+		effect = func(stop <-chan bool) { lc.marquee("", stop) }
+	}
+	return effect, err
+}
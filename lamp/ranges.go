@@ -0,0 +1,215 @@
+package lamp
+
+import (
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Range is a span of LEDs. From and To count from 1 and are both lit.
+type Range struct {
+	From, To int
+}
+
+// ParseRanges parses a comma separated list of ranges for a strip of
+// quantity LEDs:
+//
+//	10-20     LEDs 10 to 20
+//	7         LED 7
+//	30-       LED 30 to the end
+//	-5        the last 5 LEDs
+//	20%       the first 20%
+//	-20%      the last 20%
+//	25%-75%   from a quarter to three quarters of the strip
+//
+// Overlapping and adjacent ranges are merged; the result is sorted.
+func ParseRanges(s string, quantity int) ([]Range, error) {
+	var ranges []Range
+	for _, part := range strings.Split(s, ",") {
+		r, err := parseRange(strings.TrimSpace(part), quantity)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return merge(ranges), nil
+}
+
+func parseRange(s string, quantity int) (Range, error) {
+	bad := func() (Range, error) {
+		return Range{}, optionError("ranges", "err.option.range_syntax", s)
+	}
+
+	// -N and -N%: from the end
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		n, err := ledCount(rest, quantity)
+		if err != nil || n <= 0 {
+			return bad()
+		}
+		return checkRange(s, Range{From: quantity - n + 1, To: quantity}, quantity)
+	}
+
+	from, to, isSpan := strings.Cut(s, "-")
+	a, err := ledCount(from, quantity)
+	if err != nil {
+		return bad()
+	}
+	switch {
+	case !isSpan && strings.HasSuffix(from, "%"):
+		return checkRange(s, Range{From: 1, To: a}, quantity)
+	case !isSpan:
+		return checkRange(s, Range{From: a, To: a}, quantity)
+	case to == "":
+		return checkRange(s, Range{From: a, To: quantity}, quantity)
+	}
+
+	b, err := ledCount(to, quantity)
+	if err != nil {
+		return bad()
+	}
+	// 25%-75% starts after the first quarter
+	if strings.HasSuffix(from, "%") {
+		a++
+	}
+	return checkRange(s, Range{From: a, To: b}, quantity)
+}
+
+// ledCount parses a number of LEDs, or a percentage of quantity.
+func ledCount(s string, quantity int) (int, error) {
+	if p, ok := strings.CutSuffix(s, "%"); ok {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || n > 100 {
+			return 0, optionError("ranges", "err.option.range_percent", s)
+		}
+		return n * quantity / 100, nil
+	}
+	return strconv.Atoi(s)
+}
+
+func checkRange(s string, r Range, quantity int) (Range, error) {
+	if r.From < 1 || r.To > quantity || r.From > r.To {
+		return Range{}, optionError("ranges", "err.option.range_outside", s, quantity)
+	}
+	return r, nil
+}
+
+func merge(ranges []Range) []Range {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].From < ranges[j].From })
+
+	var out []Range
+	for _, r := range ranges {
+		if n := len(out); n > 0 && r.From <= out[n-1].To+1 {
+			out[n-1].To = max(out[n-1].To, r.To)
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// FormatRanges renders ranges the way ControlRanges stores them.
+func FormatRanges(ranges []Range) string {
+	parts := make([]string, len(ranges))
+	for i, r := range ranges {
+		if r.From == r.To {
+			parts[i] = strconv.Itoa(r.From)
+		} else {
+			parts[i] = strconv.Itoa(r.From) + "-" + strconv.Itoa(r.To)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// execRanges sends the fill modes for the LEDs in ranges and turns the
+// others off. A single range from the first LED is one native frame. The
+// controller has no frame for other ranges, so they are written pixel by
+// pixel in single mode, and breathe and strobe are returned as an effect
+// that animates them.
+func (lc *LampWithClient) execRanges(o Options, ranges []Range, r, g, b byte) (func(stop <-chan bool), error) {
+	mode := o.ControlMode
+	if len(ranges) == 1 && ranges[0].From == 1 {
+		return nil, lc.Control([]byte{byte(mode), byte(ranges[0].To), g, r, b, modeParam(mode, o.ControlPeriod)})
+	}
+
+	if mode != ModeNormal {
+		period := o.Period()
+		return func(stop <-chan bool) { lc.animateRanges(mode, period, ranges, r, g, b, stop) }, nil
+	}
+	return nil, lc.paintRanges(ranges, r, g, b, true)
+}
+
+// paintRanges writes ranges pixel by pixel. With clear it first turns the
+// strip off, lighting a leading range in the same frame if there is one.
+func (lc *LampWithClient) paintRanges(ranges []Range, r, g, b byte, clear bool) error {
+	if clear {
+		first := []byte{ModeNormal, byte(lc.State().Quantity), 0, 0, 0, 0}
+		if ranges[0].From == 1 {
+			first = []byte{ModeNormal, byte(ranges[0].To), g, r, b, 0}
+			ranges = ranges[1:]
+		}
+		if err := lc.Control(first); err != nil {
+			return err
+		}
+	}
+
+	for _, rg := range ranges {
+		for i := rg.From; i <= rg.To; i++ {
+			if err := lc.Control([]byte{ModeSingle, byte(i), g, r, b, 0}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// animateRanges breathes or strobes ranges the controller cannot address,
+// by repainting them until stop is closed, one breath or flash cycle per
+// period. Each frame sends only the LEDs that changed, and frames the bus
+// cannot carry in time are dropped.
+func (lc *LampWithClient) animateRanges(mode int, period time.Duration, ranges []Range, r, g, b byte, stop <-chan bool) {
+	const breatheSteps = 20
+
+	effect := ModeName(mode) + " ranges"
+	if err := lc.paintRanges(ranges, 0, 0, 0, true); err != nil {
+		slog.Warn("effect frame failed", "effect", effect, "err", err)
+	}
+	leds := 0
+	for _, rg := range ranges {
+		leds += rg.To - rg.From + 1
+	}
+	tick := period / 2
+	if mode == ModeBreathe {
+		tick = period / (2 * breatheSteps)
+	}
+	p := lc.newPacer(effect, leds, tick)
+	fb := lc.NewFramebuffer()
+
+	scale := func(c byte, n, of int) byte { return byte(int(c) * n / of) }
+	for frame, ok := 0, true; ok; frame, ok = p.next(stop) {
+		var c Color
+		switch mode {
+		case ModeBreathe:
+			// up and down in breatheSteps steps each
+			n := frame % (2 * breatheSteps)
+			if n > breatheSteps {
+				n = 2*breatheSteps - n
+			}
+			c = Color{R: scale(r, n, breatheSteps), G: scale(g, n, breatheSteps), B: scale(b, n, breatheSteps)}
+		default:
+			if frame%2 == 0 {
+				c = Color{R: r, G: g, B: b}
+			}
+		}
+
+		for _, rg := range ranges {
+			for i := rg.From; i <= rg.To; i++ {
+				fb.Set(i-1, c)
+			}
+		}
+		if err := fb.Push(); err != nil {
+			slog.Warn("effect frame failed", "effect", effect, "err", err)
+		}
+	}
+}
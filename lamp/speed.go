@@ -0,0 +1,138 @@
+package lamp
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Byte 5 of a fill frame sets the speed of the modes the controller
+// animates:
+//
+//	breathe  one breath in seconds, 1s to 255s, default 5s
+//	strobe   one flash cycle in 10ms steps, 10ms to 2.55s, default 1s (1Hz)
+//
+// Normal, single and marquee have no speed and ignore ControlPeriod.
+var periodSteps = map[int]struct {
+	unit, def time.Duration
+}{
+	ModeBreathe: {unit: time.Second, def: 5 * time.Second},
+	ModeStrobe:  {unit: 10 * time.Millisecond, def: time.Second},
+}
+
+// DefaultPeriod is the period of mode when ControlPeriod is 0, what the
+// controller has always been sent. It is 0 for modes without a speed.
+func DefaultPeriod(mode int) time.Duration {
+	return periodSteps[mode].def
+}
+
+// PeriodRange returns the shortest and longest period of mode, both 0 for
+// modes without a speed.
+func PeriodRange(mode int) (shortest, longest time.Duration) {
+	s, ok := periodSteps[mode]
+	if !ok {
+		return 0, 0
+	}
+	return s.unit, 255 * s.unit
+}
+
+// Period is the period the mode of o runs at: ControlPeriod rounded to
+// what the controller can do, or the mode's default. It is 0 for modes
+// without a speed.
+func (o Options) Period() time.Duration {
+	s, ok := periodSteps[o.ControlMode]
+	if !ok {
+		return 0
+	}
+	return time.Duration(modeParam(o.ControlMode, o.ControlPeriod)) * s.unit
+}
+
+// modeParam is byte 5 of a fill frame, period in the unit of mode. A
+// period of 0 is the mode's default.
+func modeParam(mode int, period time.Duration) byte {
+	s, ok := periodSteps[mode]
+	if !ok {
+		return 0x00
+	}
+	if period == 0 {
+		period = s.def
+	}
+	n := (period + s.unit/2) / s.unit
+	return byte(min(max(n, 1), 255))
+}
+
+func validatePeriod(o Options) error {
+	if o.ControlPeriod < 0 {
+		return optionError("period", "err.option.period_negative", o.ControlPeriod)
+	}
+	shortest, longest := PeriodRange(o.ControlMode)
+	if o.ControlPeriod == 0 || longest == 0 {
+		return nil
+	}
+	if o.ControlPeriod < shortest || o.ControlPeriod > longest {
+		return optionError("period", "err.option.period_range",
+			ModeName(o.ControlMode), FormatPeriod(o.ControlPeriod), FormatPeriod(shortest), FormatPeriod(longest))
+	}
+	return nil
+}
+
+// ParsePeriod parses a period: a duration such as 500ms or 2s, a
+// frequency such as 2Hz, or a bare number of milliseconds. "default" is
+// 0, the mode's default.
+func ParsePeriod(s string) (time.Duration, error) {
+	return parseSpeed(s, "period", func(n float64) (time.Duration, error) {
+		return time.Duration(n * float64(time.Millisecond)), nil
+	})
+}
+
+// ParseSpeed parses a speed: a frequency such as 2Hz or a bare number of
+// Hz, or a period such as 500ms. It returns the period.
+func ParseSpeed(s string) (time.Duration, error) {
+	return parseSpeed(s, "speed", hertz)
+}
+
+func parseSpeed(s, name string, bare func(float64) (time.Duration, error)) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "default" {
+		return 0, nil
+	}
+
+	var (
+		d   time.Duration
+		err error
+	)
+	if f, ok := strings.CutSuffix(s, "hz"); ok {
+		n, perr := strconv.ParseFloat(strings.TrimSpace(f), 64)
+		if perr != nil {
+			return 0, optionError(name, "err.option.not_frequency", name, s)
+		}
+		d, err = hertz(n)
+	} else if n, perr := strconv.ParseFloat(s, 64); perr == nil {
+		d, err = bare(n)
+	} else {
+		d, err = time.ParseDuration(s)
+		if err != nil {
+			return 0, optionError(name, "err.option.not_period", name, s)
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, optionError(name, "err.option.not_positive", name, s)
+	}
+	return d, nil
+}
+
+func hertz(f float64) (time.Duration, error) {
+	if f <= 0 || math.IsInf(f, 0) || math.IsNaN(f) {
+		return 0, optionError("speed", "err.option.frequency_positive", f)
+	}
+	return time.Duration(math.Round(float64(time.Second) / f)), nil
+}
+
+// FormatPeriod renders a period to the millisecond: 500ms, 2s, 1.5s.
+func FormatPeriod(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
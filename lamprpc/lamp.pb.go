@@ -0,0 +1,1432 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: lamp.proto
+
+package lamprpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Mode values match the mode byte sent to the controller.
+type Mode int32
+
+const (
+	Mode_MODE_UNSPECIFIED Mode = 0
+	Mode_MODE_NORMAL      Mode = 3
+	Mode_MODE_BREATHE     Mode = 4
+	Mode_MODE_STROBE      Mode = 5
+	Mode_MODE_SINGLE      Mode = 6
+	Mode_MODE_MARQUEE     Mode = 7
+)
+
+// Enum value maps for Mode.
+var (
+	Mode_name = map[int32]string{
+		0: "MODE_UNSPECIFIED",
+		3: "MODE_NORMAL",
+		4: "MODE_BREATHE",
+		5: "MODE_STROBE",
+		6: "MODE_SINGLE",
+		7: "MODE_MARQUEE",
+	}
+	Mode_value = map[string]int32{
+		"MODE_UNSPECIFIED": 0,
+		"MODE_NORMAL":      3,
+		"MODE_BREATHE":     4,
+		"MODE_STROBE":      5,
+		"MODE_SINGLE":      6,
+		"MODE_MARQUEE":     7,
+	}
+)
+
+func (x Mode) Enum() *Mode {
+	p := new(Mode)
+	*p = x
+	return p
+}
+
+func (x Mode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Mode) Descriptor() protoreflect.EnumDescriptor {
+	return file_lamp_proto_enumTypes[0].Descriptor()
+}
+
+func (Mode) Type() protoreflect.EnumType {
+	return &file_lamp_proto_enumTypes[0]
+}
+
+func (x Mode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Mode.Descriptor instead.
+func (Mode) EnumDescriptor() ([]byte, []int) {
+	return file_lamp_proto_rawDescGZIP(), []int{0}
+}
+
+type AlertLevel int32
+
+const (
+	AlertLevel_ALERT_LEVEL_UNSPECIFIED AlertLevel = 0
+	AlertLevel_ALERT_LEVEL_INFO        AlertLevel = 1
+	AlertLevel_ALERT_LEVEL_WARNING     AlertLevel = 2
+	AlertLevel_ALERT_LEVEL_CRITICAL    AlertLevel = 3
+)
+
+// Enum value maps for AlertLevel.
+var (
+	AlertLevel_name = map[int32]string{
+		0: "ALERT_LEVEL_UNSPECIFIED",
+		1: "ALERT_LEVEL_INFO",
+		2: "ALERT_LEVEL_WARNING",
+		3: "ALERT_LEVEL_CRITICAL",
+	}
+	AlertLevel_value = map[string]int32{
+		"ALERT_LEVEL_UNSPECIFIED": 0,
+		"ALERT_LEVEL_INFO":        1,
+		"ALERT_LEVEL_WARNING":     2,
+		"ALERT_LEVEL_CRITICAL":    3,
+	}
+)
+
+func (x AlertLevel) Enum() *AlertLevel {
+	p := new(AlertLevel)
+	*p = x
+	return p
+}
+
+func (x AlertLevel) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AlertLevel) Descriptor() protoreflect.EnumDescriptor {
+	return file_lamp_proto_enumTypes[1].Descriptor()
+}
+
+func (AlertLevel) Type() protoreflect.EnumType {
+	return &file_lamp_proto_enumTypes[1]
+}
+
+func (x AlertLevel) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AlertLevel.Descriptor instead.
+func (AlertLevel) EnumDescriptor() ([]byte, []int) {
+	return file_lamp_proto_rawDescGZIP(), []int{1}
+}
+
+type Color struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	R             uint32                 `protobuf:"varint,1,opt,name=r,proto3" json:"r,omitempty"`
+	G             uint32                 `protobuf:"varint,2,opt,name=g,proto3" json:"g,omitempty"`
+	B             uint32                 `protobuf:"varint,3,opt,name=b,proto3" json:"b,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Color) Reset() {
+	*x = Color{}
+	mi := &file_lamp_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Color) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Color) ProtoMessage() {}
+
+func (x *Color) ProtoReflect() protoreflect.Message {
+	mi := &file_lamp_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Color.ProtoReflect.Descriptor instead.
+func (*Color) Descriptor() ([]byte, []int) {
+	return file_lamp_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Color) GetR() uint32 {
+	if x != nil {
+		return x.R
+	}
+	return 0
+}
+
+func (x *Color) GetG() uint32 {
+	if x != nil {
+		return x.G
+	}
+	return 0
+}
+
+func (x *Color) GetB() uint32 {
+	if x != nil {
+		return x.B
+	}
+	return 0
+}
+
+type FillRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// percent of the strip to light, 1-100.
+	Percent uint32 `protobuf:"varint,1,opt,name=percent,proto3" json:"percent,omitempty"`
+	Color   *Color `protobuf:"bytes,2,opt,name=color,proto3" json:"color,omitempty"`
+	// period_ms is one breath (1000-255000, in whole seconds) or one strobe
+	// flash cycle (10-2550, in steps of 10). 0 is the mode's default, a 5s
+	// breath or a 1Hz strobe. Normal ignores it.
+	PeriodMs uint32 `protobuf:"varint,3,opt,name=period_ms,json=periodMs,proto3" json:"period_ms,omitempty"`
+	// fade_ms crossfades from what the strip shows, up to 60000. 0 cuts at
+	// once.
+	FadeMs        uint32 `protobuf:"varint,4,opt,name=fade_ms,json=fadeMs,proto3" json:"fade_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FillRequest) Reset() {
+	*x = FillRequest{}
+	mi := &file_lamp_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FillRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FillRequest) ProtoMessage() {}
+
+func (x *FillRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lamp_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FillRequest.ProtoReflect.Descriptor instead.
+func (*FillRequest) Descriptor() ([]byte, []int) {
+	return file_lamp_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FillRequest) GetPercent() uint32 {
+	if x != nil {
+		return x.Percent
+	}
+	return 0
+}
+
+func (x *FillRequest) GetColor() *Color {
+	if x != nil {
+		return x.Color
+	}
+	return nil
+}
+
+func (x *FillRequest) GetPeriodMs() uint32 {
+	if x != nil {
+		return x.PeriodMs
+	}
+	return 0
+}
+
+func (x *FillRequest) GetFadeMs() uint32 {
+	if x != nil {
+		return x.FadeMs
+	}
+	return 0
+}
+
+type SingleRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// position of the LED, 1 to quantity-1.
+	Position uint32 `protobuf:"varint,1,opt,name=position,proto3" json:"position,omitempty"`
+	Color    *Color `protobuf:"bytes,2,opt,name=color,proto3" json:"color,omitempty"`
+	// fade_ms crossfades the LED from its color, up to 60000.
+	FadeMs        uint32 `protobuf:"varint,3,opt,name=fade_ms,json=fadeMs,proto3" json:"fade_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SingleRequest) Reset() {
+	*x = SingleRequest{}
+	mi := &file_lamp_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SingleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SingleRequest) ProtoMessage() {}
+
+func (x *SingleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lamp_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SingleRequest.ProtoReflect.Descriptor instead.
+func (*SingleRequest) Descriptor() ([]byte, []int) {
+	return file_lamp_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SingleRequest) GetPosition() uint32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *SingleRequest) GetColor() *Color {
+	if x != nil {
+		return x.Color
+	}
+	return nil
+}
+
+func (x *SingleRequest) GetFadeMs() uint32 {
+	if x != nil {
+		return x.FadeMs
+	}
+	return 0
+}
+
+type MarqueeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Color         *Color                 `protobuf:"bytes,1,opt,name=color,proto3" json:"color,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MarqueeRequest) Reset() {
+	*x = MarqueeRequest{}
+	mi := &file_lamp_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MarqueeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MarqueeRequest) ProtoMessage() {}
+
+func (x *MarqueeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lamp_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MarqueeRequest.ProtoReflect.Descriptor instead.
+func (*MarqueeRequest) Descriptor() ([]byte, []int) {
+	return file_lamp_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *MarqueeRequest) GetColor() *Color {
+	if x != nil {
+		return x.Color
+	}
+	return nil
+}
+
+type GetStateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStateRequest) Reset() {
+	*x = GetStateRequest{}
+	mi := &file_lamp_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStateRequest) ProtoMessage() {}
+
+func (x *GetStateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lamp_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStateRequest.ProtoReflect.Descriptor instead.
+func (*GetStateRequest) Descriptor() ([]byte, []int) {
+	return file_lamp_proto_rawDescGZIP(), []int{4}
+}
+
+type StreamStateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamStateRequest) Reset() {
+	*x = StreamStateRequest{}
+	mi := &file_lamp_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamStateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamStateRequest) ProtoMessage() {}
+
+func (x *StreamStateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lamp_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamStateRequest.ProtoReflect.Descriptor instead.
+func (*StreamStateRequest) Descriptor() ([]byte, []int) {
+	return file_lamp_proto_rawDescGZIP(), []int{5}
+}
+
+type LampState struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Mode     Mode                   `protobuf:"varint,1,opt,name=mode,proto3,enum=lampwith.v1.Mode" json:"mode,omitempty"`
+	Percent  uint32                 `protobuf:"varint,2,opt,name=percent,proto3" json:"percent,omitempty"`
+	Position uint32                 `protobuf:"varint,3,opt,name=position,proto3" json:"position,omitempty"`
+	Color    *Color                 `protobuf:"bytes,4,opt,name=color,proto3" json:"color,omitempty"`
+	Quantity uint32                 `protobuf:"varint,5,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	// period_ms is the period breathe or strobe runs at, 0 in the other modes.
+	PeriodMs uint32 `protobuf:"varint,6,opt,name=period_ms,json=periodMs,proto3" json:"period_ms,omitempty"`
+	// fade_ms is the crossfade of every change, 0 for none.
+	FadeMs uint32 `protobuf:"varint,7,opt,name=fade_ms,json=fadeMs,proto3" json:"fade_ms,omitempty"`
+	// ranges are the LEDs lit instead of percent, as "1-10,20-25", empty for
+	// percent.
+	Ranges        string `protobuf:"bytes,8,opt,name=ranges,proto3" json:"ranges,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LampState) Reset() {
+	*x = LampState{}
+	mi := &file_lamp_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LampState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LampState) ProtoMessage() {}
+
+func (x *LampState) ProtoReflect() protoreflect.Message {
+	mi := &file_lamp_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LampState.ProtoReflect.Descriptor instead.
+func (*LampState) Descriptor() ([]byte, []int) {
+	return file_lamp_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *LampState) GetMode() Mode {
+	if x != nil {
+		return x.Mode
+	}
+	return Mode_MODE_UNSPECIFIED
+}
+
+func (x *LampState) GetPercent() uint32 {
+	if x != nil {
+		return x.Percent
+	}
+	return 0
+}
+
+func (x *LampState) GetPosition() uint32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *LampState) GetColor() *Color {
+	if x != nil {
+		return x.Color
+	}
+	return nil
+}
+
+func (x *LampState) GetQuantity() uint32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *LampState) GetPeriodMs() uint32 {
+	if x != nil {
+		return x.PeriodMs
+	}
+	return 0
+}
+
+func (x *LampState) GetFadeMs() uint32 {
+	if x != nil {
+		return x.FadeMs
+	}
+	return 0
+}
+
+func (x *LampState) GetRanges() string {
+	if x != nil {
+		return x.Ranges
+	}
+	return ""
+}
+
+type RaiseAlertRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Level AlertLevel             `protobuf:"varint,1,opt,name=level,proto3,enum=lampwith.v1.AlertLevel" json:"level,omitempty"`
+	// source names what raised the alert, ex: "press-3".
+	Source string `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	// ttl_ms expires the alert, 0 keeps it until ClearAlert.
+	TtlMs         uint32 `protobuf:"varint,3,opt,name=ttl_ms,json=ttlMs,proto3" json:"ttl_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RaiseAlertRequest) Reset() {
+	*x = RaiseAlertRequest{}
+	mi := &file_lamp_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RaiseAlertRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RaiseAlertRequest) ProtoMessage() {}
+
+func (x *RaiseAlertRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lamp_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RaiseAlertRequest.ProtoReflect.Descriptor instead.
+func (*RaiseAlertRequest) Descriptor() ([]byte, []int) {
+	return file_lamp_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RaiseAlertRequest) GetLevel() AlertLevel {
+	if x != nil {
+		return x.Level
+	}
+	return AlertLevel_ALERT_LEVEL_UNSPECIFIED
+}
+
+func (x *RaiseAlertRequest) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *RaiseAlertRequest) GetTtlMs() uint32 {
+	if x != nil {
+		return x.TtlMs
+	}
+	return 0
+}
+
+type ClearAlertRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Source        string                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClearAlertRequest) Reset() {
+	*x = ClearAlertRequest{}
+	mi := &file_lamp_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClearAlertRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClearAlertRequest) ProtoMessage() {}
+
+func (x *ClearAlertRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lamp_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClearAlertRequest.ProtoReflect.Descriptor instead.
+func (*ClearAlertRequest) Descriptor() ([]byte, []int) {
+	return file_lamp_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ClearAlertRequest) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+type ListAlertsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAlertsRequest) Reset() {
+	*x = ListAlertsRequest{}
+	mi := &file_lamp_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAlertsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAlertsRequest) ProtoMessage() {}
+
+func (x *ListAlertsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lamp_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAlertsRequest.ProtoReflect.Descriptor instead.
+func (*ListAlertsRequest) Descriptor() ([]byte, []int) {
+	return file_lamp_proto_rawDescGZIP(), []int{9}
+}
+
+type Alert struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Level  AlertLevel             `protobuf:"varint,1,opt,name=level,proto3,enum=lampwith.v1.AlertLevel" json:"level,omitempty"`
+	Source string                 `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	// expires_ms is how long the alert has left, 0 if it stays until cleared.
+	ExpiresMs     uint32 `protobuf:"varint,3,opt,name=expires_ms,json=expiresMs,proto3" json:"expires_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Alert) Reset() {
+	*x = Alert{}
+	mi := &file_lamp_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Alert) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Alert) ProtoMessage() {}
+
+func (x *Alert) ProtoReflect() protoreflect.Message {
+	mi := &file_lamp_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Alert.ProtoReflect.Descriptor instead.
+func (*Alert) Descriptor() ([]byte, []int) {
+	return file_lamp_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Alert) GetLevel() AlertLevel {
+	if x != nil {
+		return x.Level
+	}
+	return AlertLevel_ALERT_LEVEL_UNSPECIFIED
+}
+
+func (x *Alert) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Alert) GetExpiresMs() uint32 {
+	if x != nil {
+		return x.ExpiresMs
+	}
+	return 0
+}
+
+type Alerts struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// alerts holds the active alerts, the one the strip shows first.
+	Alerts        []*Alert `protobuf:"bytes,1,rep,name=alerts,proto3" json:"alerts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Alerts) Reset() {
+	*x = Alerts{}
+	mi := &file_lamp_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Alerts) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Alerts) ProtoMessage() {}
+
+func (x *Alerts) ProtoReflect() protoreflect.Message {
+	mi := &file_lamp_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Alerts.ProtoReflect.Descriptor instead.
+func (*Alerts) Descriptor() ([]byte, []int) {
+	return file_lamp_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Alerts) GetAlerts() []*Alert {
+	if x != nil {
+		return x.Alerts
+	}
+	return nil
+}
+
+type GaugeThreshold struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// at is the value the color shows from.
+	At            float64 `protobuf:"fixed64,1,opt,name=at,proto3" json:"at,omitempty"`
+	Color         *Color  `protobuf:"bytes,2,opt,name=color,proto3" json:"color,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GaugeThreshold) Reset() {
+	*x = GaugeThreshold{}
+	mi := &file_lamp_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GaugeThreshold) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GaugeThreshold) ProtoMessage() {}
+
+func (x *GaugeThreshold) ProtoReflect() protoreflect.Message {
+	mi := &file_lamp_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GaugeThreshold.ProtoReflect.Descriptor instead.
+func (*GaugeThreshold) Descriptor() ([]byte, []int) {
+	return file_lamp_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GaugeThreshold) GetAt() float64 {
+	if x != nil {
+		return x.At
+	}
+	return 0
+}
+
+func (x *GaugeThreshold) GetColor() *Color {
+	if x != nil {
+		return x.Color
+	}
+	return nil
+}
+
+type GaugeSettings struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// min shows as an empty bar and max as a full one, min below max.
+	Min float64 `protobuf:"fixed64,1,opt,name=min,proto3" json:"min,omitempty"`
+	Max float64 `protobuf:"fixed64,2,opt,name=max,proto3" json:"max,omitempty"`
+	// thresholds color the bar by its value, in order of at. Empty is green,
+	// amber from 70% and red from 90% of the range.
+	Thresholds []*GaugeThreshold `protobuf:"bytes,3,rep,name=thresholds,proto3" json:"thresholds,omitempty"`
+	// reverse fills the bar from the last LED down.
+	Reverse bool `protobuf:"varint,4,opt,name=reverse,proto3" json:"reverse,omitempty"`
+	// ease_ms is how long the bar takes to move to a new value, up to 60000.
+	// 0 jumps.
+	EaseMs        uint32 `protobuf:"varint,5,opt,name=ease_ms,json=easeMs,proto3" json:"ease_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GaugeSettings) Reset() {
+	*x = GaugeSettings{}
+	mi := &file_lamp_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GaugeSettings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GaugeSettings) ProtoMessage() {}
+
+func (x *GaugeSettings) ProtoReflect() protoreflect.Message {
+	mi := &file_lamp_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GaugeSettings.ProtoReflect.Descriptor instead.
+func (*GaugeSettings) Descriptor() ([]byte, []int) {
+	return file_lamp_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GaugeSettings) GetMin() float64 {
+	if x != nil {
+		return x.Min
+	}
+	return 0
+}
+
+func (x *GaugeSettings) GetMax() float64 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+func (x *GaugeSettings) GetThresholds() []*GaugeThreshold {
+	if x != nil {
+		return x.Thresholds
+	}
+	return nil
+}
+
+func (x *GaugeSettings) GetReverse() bool {
+	if x != nil {
+		return x.Reverse
+	}
+	return false
+}
+
+func (x *GaugeSettings) GetEaseMs() uint32 {
+	if x != nil {
+		return x.EaseMs
+	}
+	return 0
+}
+
+type SetGaugeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Value float64                `protobuf:"fixed64,1,opt,name=value,proto3" json:"value,omitempty"`
+	// settings, if set, replaces the settings of the gauge.
+	Settings      *GaugeSettings `protobuf:"bytes,2,opt,name=settings,proto3" json:"settings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetGaugeRequest) Reset() {
+	*x = SetGaugeRequest{}
+	mi := &file_lamp_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetGaugeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetGaugeRequest) ProtoMessage() {}
+
+func (x *SetGaugeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lamp_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetGaugeRequest.ProtoReflect.Descriptor instead.
+func (*SetGaugeRequest) Descriptor() ([]byte, []int) {
+	return file_lamp_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *SetGaugeRequest) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *SetGaugeRequest) GetSettings() *GaugeSettings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+type GaugeValue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         float64                `protobuf:"fixed64,1,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GaugeValue) Reset() {
+	*x = GaugeValue{}
+	mi := &file_lamp_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GaugeValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GaugeValue) ProtoMessage() {}
+
+func (x *GaugeValue) ProtoReflect() protoreflect.Message {
+	mi := &file_lamp_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GaugeValue.ProtoReflect.Descriptor instead.
+func (*GaugeValue) Descriptor() ([]byte, []int) {
+	return file_lamp_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GaugeValue) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+type Gauge struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// value is the last value set.
+	Value    float64        `protobuf:"fixed64,1,opt,name=value,proto3" json:"value,omitempty"`
+	Settings *GaugeSettings `protobuf:"bytes,2,opt,name=settings,proto3" json:"settings,omitempty"`
+	// showing is false once a direct command took the strip.
+	Showing       bool `protobuf:"varint,3,opt,name=showing,proto3" json:"showing,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Gauge) Reset() {
+	*x = Gauge{}
+	mi := &file_lamp_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Gauge) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Gauge) ProtoMessage() {}
+
+func (x *Gauge) ProtoReflect() protoreflect.Message {
+	mi := &file_lamp_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Gauge.ProtoReflect.Descriptor instead.
+func (*Gauge) Descriptor() ([]byte, []int) {
+	return file_lamp_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Gauge) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *Gauge) GetSettings() *GaugeSettings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+func (x *Gauge) GetShowing() bool {
+	if x != nil {
+		return x.Showing
+	}
+	return false
+}
+
+type BusError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BusError) Reset() {
+	*x = BusError{}
+	mi := &file_lamp_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BusError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BusError) ProtoMessage() {}
+
+func (x *BusError) ProtoReflect() protoreflect.Message {
+	mi := &file_lamp_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BusError.ProtoReflect.Descriptor instead.
+func (*BusError) Descriptor() ([]byte, []int) {
+	return file_lamp_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *BusError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type StateUpdate struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Update:
+	//
+	//	*StateUpdate_State
+	//	*StateUpdate_Error
+	Update        isStateUpdate_Update `protobuf_oneof:"update"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StateUpdate) Reset() {
+	*x = StateUpdate{}
+	mi := &file_lamp_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StateUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StateUpdate) ProtoMessage() {}
+
+func (x *StateUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_lamp_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StateUpdate.ProtoReflect.Descriptor instead.
+func (*StateUpdate) Descriptor() ([]byte, []int) {
+	return file_lamp_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *StateUpdate) GetUpdate() isStateUpdate_Update {
+	if x != nil {
+		return x.Update
+	}
+	return nil
+}
+
+func (x *StateUpdate) GetState() *LampState {
+	if x != nil {
+		if x, ok := x.Update.(*StateUpdate_State); ok {
+			return x.State
+		}
+	}
+	return nil
+}
+
+func (x *StateUpdate) GetError() *BusError {
+	if x != nil {
+		if x, ok := x.Update.(*StateUpdate_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+type isStateUpdate_Update interface {
+	isStateUpdate_Update()
+}
+
+type StateUpdate_State struct {
+	State *LampState `protobuf:"bytes,1,opt,name=state,proto3,oneof"`
+}
+
+type StateUpdate_Error struct {
+	Error *BusError `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*StateUpdate_State) isStateUpdate_Update() {}
+
+func (*StateUpdate_Error) isStateUpdate_Update() {}
+
+var File_lamp_proto protoreflect.FileDescriptor
+
+const file_lamp_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"lamp.proto\x12\vlampwith.v1\"1\n" +
+	"\x05Color\x12\f\n" +
+	"\x01r\x18\x01 \x01(\rR\x01r\x12\f\n" +
+	"\x01g\x18\x02 \x01(\rR\x01g\x12\f\n" +
+	"\x01b\x18\x03 \x01(\rR\x01b\"\x87\x01\n" +
+	"\vFillRequest\x12\x18\n" +
+	"\apercent\x18\x01 \x01(\rR\apercent\x12(\n" +
+	"\x05color\x18\x02 \x01(\v2\x12.lampwith.v1.ColorR\x05color\x12\x1b\n" +
+	"\tperiod_ms\x18\x03 \x01(\rR\bperiodMs\x12\x17\n" +
+	"\afade_ms\x18\x04 \x01(\rR\x06fadeMs\"n\n" +
+	"\rSingleRequest\x12\x1a\n" +
+	"\bposition\x18\x01 \x01(\rR\bposition\x12(\n" +
+	"\x05color\x18\x02 \x01(\v2\x12.lampwith.v1.ColorR\x05color\x12\x17\n" +
+	"\afade_ms\x18\x03 \x01(\rR\x06fadeMs\":\n" +
+	"\x0eMarqueeRequest\x12(\n" +
+	"\x05color\x18\x01 \x01(\v2\x12.lampwith.v1.ColorR\x05color\"\x11\n" +
+	"\x0fGetStateRequest\"\x14\n" +
+	"\x12StreamStateRequest\"\xfc\x01\n" +
+	"\tLampState\x12%\n" +
+	"\x04mode\x18\x01 \x01(\x0e2\x11.lampwith.v1.ModeR\x04mode\x12\x18\n" +
+	"\apercent\x18\x02 \x01(\rR\apercent\x12\x1a\n" +
+	"\bposition\x18\x03 \x01(\rR\bposition\x12(\n" +
+	"\x05color\x18\x04 \x01(\v2\x12.lampwith.v1.ColorR\x05color\x12\x1a\n" +
+	"\bquantity\x18\x05 \x01(\rR\bquantity\x12\x1b\n" +
+	"\tperiod_ms\x18\x06 \x01(\rR\bperiodMs\x12\x17\n" +
+	"\afade_ms\x18\a \x01(\rR\x06fadeMs\x12\x16\n" +
+	"\x06ranges\x18\b \x01(\tR\x06ranges\"q\n" +
+	"\x11RaiseAlertRequest\x12-\n" +
+	"\x05level\x18\x01 \x01(\x0e2\x17.lampwith.v1.AlertLevelR\x05level\x12\x16\n" +
+	"\x06source\x18\x02 \x01(\tR\x06source\x12\x15\n" +
+	"\x06ttl_ms\x18\x03 \x01(\rR\x05ttlMs\"+\n" +
+	"\x11ClearAlertRequest\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\"\x13\n" +
+	"\x11ListAlertsRequest\"m\n" +
+	"\x05Alert\x12-\n" +
+	"\x05level\x18\x01 \x01(\x0e2\x17.lampwith.v1.AlertLevelR\x05level\x12\x16\n" +
+	"\x06source\x18\x02 \x01(\tR\x06source\x12\x1d\n" +
+	"\n" +
+	"expires_ms\x18\x03 \x01(\rR\texpiresMs\"4\n" +
+	"\x06Alerts\x12*\n" +
+	"\x06alerts\x18\x01 \x03(\v2\x12.lampwith.v1.AlertR\x06alerts\"J\n" +
+	"\x0eGaugeThreshold\x12\x0e\n" +
+	"\x02at\x18\x01 \x01(\x01R\x02at\x12(\n" +
+	"\x05color\x18\x02 \x01(\v2\x12.lampwith.v1.ColorR\x05color\"\xa3\x01\n" +
+	"\rGaugeSettings\x12\x10\n" +
+	"\x03min\x18\x01 \x01(\x01R\x03min\x12\x10\n" +
+	"\x03max\x18\x02 \x01(\x01R\x03max\x12;\n" +
+	"\n" +
+	"thresholds\x18\x03 \x03(\v2\x1b.lampwith.v1.GaugeThresholdR\n" +
+	"thresholds\x12\x18\n" +
+	"\areverse\x18\x04 \x01(\bR\areverse\x12\x17\n" +
+	"\aease_ms\x18\x05 \x01(\rR\x06easeMs\"_\n" +
+	"\x0fSetGaugeRequest\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\x01R\x05value\x126\n" +
+	"\bsettings\x18\x02 \x01(\v2\x1a.lampwith.v1.GaugeSettingsR\bsettings\"\"\n" +
+	"\n" +
+	"GaugeValue\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\x01R\x05value\"o\n" +
+	"\x05Gauge\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\x01R\x05value\x126\n" +
+	"\bsettings\x18\x02 \x01(\v2\x1a.lampwith.v1.GaugeSettingsR\bsettings\x12\x18\n" +
+	"\ashowing\x18\x03 \x01(\bR\ashowing\"$\n" +
+	"\bBusError\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\"v\n" +
+	"\vStateUpdate\x12.\n" +
+	"\x05state\x18\x01 \x01(\v2\x16.lampwith.v1.LampStateH\x00R\x05state\x12-\n" +
+	"\x05error\x18\x02 \x01(\v2\x15.lampwith.v1.BusErrorH\x00R\x05errorB\b\n" +
+	"\x06update*s\n" +
+	"\x04Mode\x12\x14\n" +
+	"\x10MODE_UNSPECIFIED\x10\x00\x12\x0f\n" +
+	"\vMODE_NORMAL\x10\x03\x12\x10\n" +
+	"\fMODE_BREATHE\x10\x04\x12\x0f\n" +
+	"\vMODE_STROBE\x10\x05\x12\x0f\n" +
+	"\vMODE_SINGLE\x10\x06\x12\x10\n" +
+	"\fMODE_MARQUEE\x10\a*r\n" +
+	"\n" +
+	"AlertLevel\x12\x1b\n" +
+	"\x17ALERT_LEVEL_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10ALERT_LEVEL_INFO\x10\x01\x12\x17\n" +
+	"\x13ALERT_LEVEL_WARNING\x10\x02\x12\x18\n" +
+	"\x14ALERT_LEVEL_CRITICAL\x10\x032\x91\x06\n" +
+	"\vLampService\x12:\n" +
+	"\x06Normal\x12\x18.lampwith.v1.FillRequest\x1a\x16.lampwith.v1.LampState\x12;\n" +
+	"\aBreathe\x12\x18.lampwith.v1.FillRequest\x1a\x16.lampwith.v1.LampState\x12:\n" +
+	"\x06Strobe\x12\x18.lampwith.v1.FillRequest\x1a\x16.lampwith.v1.LampState\x12<\n" +
+	"\x06Single\x12\x1a.lampwith.v1.SingleRequest\x1a\x16.lampwith.v1.LampState\x12>\n" +
+	"\aMarquee\x12\x1b.lampwith.v1.MarqueeRequest\x1a\x16.lampwith.v1.LampState\x12@\n" +
+	"\bGetState\x12\x1c.lampwith.v1.GetStateRequest\x1a\x16.lampwith.v1.LampState\x12J\n" +
+	"\vStreamState\x12\x1f.lampwith.v1.StreamStateRequest\x1a\x18.lampwith.v1.StateUpdate0\x01\x12A\n" +
+	"\n" +
+	"RaiseAlert\x12\x1e.lampwith.v1.RaiseAlertRequest\x1a\x13.lampwith.v1.Alerts\x12A\n" +
+	"\n" +
+	"ClearAlert\x12\x1e.lampwith.v1.ClearAlertRequest\x1a\x13.lampwith.v1.Alerts\x12A\n" +
+	"\n" +
+	"ListAlerts\x12\x1e.lampwith.v1.ListAlertsRequest\x1a\x13.lampwith.v1.Alerts\x12<\n" +
+	"\bSetGauge\x12\x1c.lampwith.v1.SetGaugeRequest\x1a\x12.lampwith.v1.Gauge\x12:\n" +
+	"\tFeedGauge\x12\x17.lampwith.v1.GaugeValue\x1a\x12.lampwith.v1.Gauge(\x01B\x16Z\x14lampwith-tag/lamprpcb\x06proto3"
+
+var (
+	file_lamp_proto_rawDescOnce sync.Once
+	file_lamp_proto_rawDescData []byte
+)
+
+func file_lamp_proto_rawDescGZIP() []byte {
+	file_lamp_proto_rawDescOnce.Do(func() {
+		file_lamp_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_lamp_proto_rawDesc), len(file_lamp_proto_rawDesc)))
+	})
+	return file_lamp_proto_rawDescData
+}
+
+var file_lamp_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_lamp_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_lamp_proto_goTypes = []any{
+	(Mode)(0),                  // 0: lampwith.v1.Mode
+	(AlertLevel)(0),            // 1: lampwith.v1.AlertLevel
+	(*Color)(nil),              // 2: lampwith.v1.Color
+	(*FillRequest)(nil),        // 3: lampwith.v1.FillRequest
+	(*SingleRequest)(nil),      // 4: lampwith.v1.SingleRequest
+	(*MarqueeRequest)(nil),     // 5: lampwith.v1.MarqueeRequest
+	(*GetStateRequest)(nil),    // 6: lampwith.v1.GetStateRequest
+	(*StreamStateRequest)(nil), // 7: lampwith.v1.StreamStateRequest
+	(*LampState)(nil),          // 8: lampwith.v1.LampState
+	(*RaiseAlertRequest)(nil),  // 9: lampwith.v1.RaiseAlertRequest
+	(*ClearAlertRequest)(nil),  // 10: lampwith.v1.ClearAlertRequest
+	(*ListAlertsRequest)(nil),  // 11: lampwith.v1.ListAlertsRequest
+	(*Alert)(nil),              // 12: lampwith.v1.Alert
+	(*Alerts)(nil),             // 13: lampwith.v1.Alerts
+	(*GaugeThreshold)(nil),     // 14: lampwith.v1.GaugeThreshold
+	(*GaugeSettings)(nil),      // 15: lampwith.v1.GaugeSettings
+	(*SetGaugeRequest)(nil),    // 16: lampwith.v1.SetGaugeRequest
+	(*GaugeValue)(nil),         // 17: lampwith.v1.GaugeValue
+	(*Gauge)(nil),              // 18: lampwith.v1.Gauge
+	(*BusError)(nil),           // 19: lampwith.v1.BusError
+	(*StateUpdate)(nil),        // 20: lampwith.v1.StateUpdate
+}
+var file_lamp_proto_depIdxs = []int32{
+	2,  // 0: lampwith.v1.FillRequest.color:type_name -> lampwith.v1.Color
+	2,  // 1: lampwith.v1.SingleRequest.color:type_name -> lampwith.v1.Color
+	2,  // 2: lampwith.v1.MarqueeRequest.color:type_name -> lampwith.v1.Color
+	0,  // 3: lampwith.v1.LampState.mode:type_name -> lampwith.v1.Mode
+	2,  // 4: lampwith.v1.LampState.color:type_name -> lampwith.v1.Color
+	1,  // 5: lampwith.v1.RaiseAlertRequest.level:type_name -> lampwith.v1.AlertLevel
+	1,  // 6: lampwith.v1.Alert.level:type_name -> lampwith.v1.AlertLevel
+	12, // 7: lampwith.v1.Alerts.alerts:type_name -> lampwith.v1.Alert
+	2,  // 8: lampwith.v1.GaugeThreshold.color:type_name -> lampwith.v1.Color
+	14, // 9: lampwith.v1.GaugeSettings.thresholds:type_name -> lampwith.v1.GaugeThreshold
+	15, // 10: lampwith.v1.SetGaugeRequest.settings:type_name -> lampwith.v1.GaugeSettings
+	15, // 11: lampwith.v1.Gauge.settings:type_name -> lampwith.v1.GaugeSettings
+	8,  // 12: lampwith.v1.StateUpdate.state:type_name -> lampwith.v1.LampState
+	19, // 13: lampwith.v1.StateUpdate.error:type_name -> lampwith.v1.BusError
+	3,  // 14: lampwith.v1.LampService.Normal:input_type -> lampwith.v1.FillRequest
+	3,  // 15: lampwith.v1.LampService.Breathe:input_type -> lampwith.v1.FillRequest
+	3,  // 16: lampwith.v1.LampService.Strobe:input_type -> lampwith.v1.FillRequest
+	4,  // 17: lampwith.v1.LampService.Single:input_type -> lampwith.v1.SingleRequest
+	5,  // 18: lampwith.v1.LampService.Marquee:input_type -> lampwith.v1.MarqueeRequest
+	6,  // 19: lampwith.v1.LampService.GetState:input_type -> lampwith.v1.GetStateRequest
+	7,  // 20: lampwith.v1.LampService.StreamState:input_type -> lampwith.v1.StreamStateRequest
+	9,  // 21: lampwith.v1.LampService.RaiseAlert:input_type -> lampwith.v1.RaiseAlertRequest
+	10, // 22: lampwith.v1.LampService.ClearAlert:input_type -> lampwith.v1.ClearAlertRequest
+	11, // 23: lampwith.v1.LampService.ListAlerts:input_type -> lampwith.v1.ListAlertsRequest
+	16, // 24: lampwith.v1.LampService.SetGauge:input_type -> lampwith.v1.SetGaugeRequest
+	17, // 25: lampwith.v1.LampService.FeedGauge:input_type -> lampwith.v1.GaugeValue
+	8,  // 26: lampwith.v1.LampService.Normal:output_type -> lampwith.v1.LampState
+	8,  // 27: lampwith.v1.LampService.Breathe:output_type -> lampwith.v1.LampState
+	8,  // 28: lampwith.v1.LampService.Strobe:output_type -> lampwith.v1.LampState
+	8,  // 29: lampwith.v1.LampService.Single:output_type -> lampwith.v1.LampState
+	8,  // 30: lampwith.v1.LampService.Marquee:output_type -> lampwith.v1.LampState
+	8,  // 31: lampwith.v1.LampService.GetState:output_type -> lampwith.v1.LampState
+	20, // 32: lampwith.v1.LampService.StreamState:output_type -> lampwith.v1.StateUpdate
+	13, // 33: lampwith.v1.LampService.RaiseAlert:output_type -> lampwith.v1.Alerts
+	13, // 34: lampwith.v1.LampService.ClearAlert:output_type -> lampwith.v1.Alerts
+	13, // 35: lampwith.v1.LampService.ListAlerts:output_type -> lampwith.v1.Alerts
+	18, // 36: lampwith.v1.LampService.SetGauge:output_type -> lampwith.v1.Gauge
+	18, // 37: lampwith.v1.LampService.FeedGauge:output_type -> lampwith.v1.Gauge
+	26, // [26:38] is the sub-list for method output_type
+	14, // [14:26] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_lamp_proto_init() }
+func file_lamp_proto_init() {
+	if File_lamp_proto != nil {
+		return
+	}
+	file_lamp_proto_msgTypes[18].OneofWrappers = []any{
+		(*StateUpdate_State)(nil),
+		(*StateUpdate_Error)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_lamp_proto_rawDesc), len(file_lamp_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   19,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_lamp_proto_goTypes,
+		DependencyIndexes: file_lamp_proto_depIdxs,
+		EnumInfos:         file_lamp_proto_enumTypes,
+		MessageInfos:      file_lamp_proto_msgTypes,
+	}.Build()
+	File_lamp_proto = out.File
+	file_lamp_proto_goTypes = nil
+	file_lamp_proto_depIdxs = nil
+}
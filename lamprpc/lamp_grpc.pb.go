@@ -0,0 +1,580 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: lamp.proto
+
+package lamprpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	LampService_Normal_FullMethodName      = "/lampwith.v1.LampService/Normal"
+	LampService_Breathe_FullMethodName     = "/lampwith.v1.LampService/Breathe"
+	LampService_Strobe_FullMethodName      = "/lampwith.v1.LampService/Strobe"
+	LampService_Single_FullMethodName      = "/lampwith.v1.LampService/Single"
+	LampService_Marquee_FullMethodName     = "/lampwith.v1.LampService/Marquee"
+	LampService_GetState_FullMethodName    = "/lampwith.v1.LampService/GetState"
+	LampService_StreamState_FullMethodName = "/lampwith.v1.LampService/StreamState"
+	LampService_RaiseAlert_FullMethodName  = "/lampwith.v1.LampService/RaiseAlert"
+	LampService_ClearAlert_FullMethodName  = "/lampwith.v1.LampService/ClearAlert"
+	LampService_ListAlerts_FullMethodName  = "/lampwith.v1.LampService/ListAlerts"
+	LampService_SetGauge_FullMethodName    = "/lampwith.v1.LampService/SetGauge"
+	LampService_FeedGauge_FullMethodName   = "/lampwith.v1.LampService/FeedGauge"
+)
+
+// LampServiceClient is the client API for LampService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// LampService controls one tag-lamp strip. Each unary RPC applies one of the
+// modes LampWithClient.Exec supports and returns the resulting state.
+type LampServiceClient interface {
+	// Normal lights the first percent of the strip.
+	Normal(ctx context.Context, in *FillRequest, opts ...grpc.CallOption) (*LampState, error)
+	// Breathe fades the first percent of the strip in and out.
+	Breathe(ctx context.Context, in *FillRequest, opts ...grpc.CallOption) (*LampState, error)
+	// Strobe flashes the first percent of the strip.
+	Strobe(ctx context.Context, in *FillRequest, opts ...grpc.CallOption) (*LampState, error)
+	// Single sets the color of one LED.
+	Single(ctx context.Context, in *SingleRequest, opts ...grpc.CallOption) (*LampState, error)
+	// Marquee runs a single lit LED along the strip until the next command.
+	Marquee(ctx context.Context, in *MarqueeRequest, opts ...grpc.CallOption) (*LampState, error)
+	// GetState returns the current state without touching the bus.
+	GetState(ctx context.Context, in *GetStateRequest, opts ...grpc.CallOption) (*LampState, error)
+	// StreamState sends the current state, then every state change and bus
+	// error until the client cancels.
+	StreamState(ctx context.Context, in *StreamStateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StateUpdate], error)
+	// RaiseAlert raises the alert of a source, replacing the one it raised
+	// before. The strip shows the most severe active alert and goes back to
+	// what it showed before once all have cleared or expired.
+	RaiseAlert(ctx context.Context, in *RaiseAlertRequest, opts ...grpc.CallOption) (*Alerts, error)
+	// ClearAlert clears the alert of a source, or every alert when source is
+	// empty.
+	ClearAlert(ctx context.Context, in *ClearAlertRequest, opts ...grpc.CallOption) (*Alerts, error)
+	// ListAlerts returns the active alerts.
+	ListAlerts(ctx context.Context, in *ListAlertsRequest, opts ...grpc.CallOption) (*Alerts, error)
+	// SetGauge shows value as a bar on the strip, moving the bar to it. With
+	// settings it first changes how the gauge shows its values.
+	SetGauge(ctx context.Context, in *SetGaugeRequest, opts ...grpc.CallOption) (*Gauge, error)
+	// FeedGauge shows each value the client sends as SetGauge does, for a
+	// client that streams readings. It returns the gauge once the client
+	// closes the stream.
+	FeedGauge(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[GaugeValue, Gauge], error)
+}
+
+type lampServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLampServiceClient(cc grpc.ClientConnInterface) LampServiceClient {
+	return &lampServiceClient{cc}
+}
+
+func (c *lampServiceClient) Normal(ctx context.Context, in *FillRequest, opts ...grpc.CallOption) (*LampState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LampState)
+	err := c.cc.Invoke(ctx, LampService_Normal_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lampServiceClient) Breathe(ctx context.Context, in *FillRequest, opts ...grpc.CallOption) (*LampState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LampState)
+	err := c.cc.Invoke(ctx, LampService_Breathe_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lampServiceClient) Strobe(ctx context.Context, in *FillRequest, opts ...grpc.CallOption) (*LampState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LampState)
+	err := c.cc.Invoke(ctx, LampService_Strobe_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lampServiceClient) Single(ctx context.Context, in *SingleRequest, opts ...grpc.CallOption) (*LampState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LampState)
+	err := c.cc.Invoke(ctx, LampService_Single_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lampServiceClient) Marquee(ctx context.Context, in *MarqueeRequest, opts ...grpc.CallOption) (*LampState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LampState)
+	err := c.cc.Invoke(ctx, LampService_Marquee_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lampServiceClient) GetState(ctx context.Context, in *GetStateRequest, opts ...grpc.CallOption) (*LampState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LampState)
+	err := c.cc.Invoke(ctx, LampService_GetState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lampServiceClient) StreamState(ctx context.Context, in *StreamStateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StateUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LampService_ServiceDesc.Streams[0], LampService_StreamState_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamStateRequest, StateUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LampService_StreamStateClient = grpc.ServerStreamingClient[StateUpdate]
+
+func (c *lampServiceClient) RaiseAlert(ctx context.Context, in *RaiseAlertRequest, opts ...grpc.CallOption) (*Alerts, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Alerts)
+	err := c.cc.Invoke(ctx, LampService_RaiseAlert_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lampServiceClient) ClearAlert(ctx context.Context, in *ClearAlertRequest, opts ...grpc.CallOption) (*Alerts, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Alerts)
+	err := c.cc.Invoke(ctx, LampService_ClearAlert_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lampServiceClient) ListAlerts(ctx context.Context, in *ListAlertsRequest, opts ...grpc.CallOption) (*Alerts, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Alerts)
+	err := c.cc.Invoke(ctx, LampService_ListAlerts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lampServiceClient) SetGauge(ctx context.Context, in *SetGaugeRequest, opts ...grpc.CallOption) (*Gauge, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Gauge)
+	err := c.cc.Invoke(ctx, LampService_SetGauge_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lampServiceClient) FeedGauge(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[GaugeValue, Gauge], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LampService_ServiceDesc.Streams[1], LampService_FeedGauge_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GaugeValue, Gauge]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LampService_FeedGaugeClient = grpc.ClientStreamingClient[GaugeValue, Gauge]
+
+// LampServiceServer is the server API for LampService service.
+// All implementations must embed UnimplementedLampServiceServer
+// for forward compatibility.
+//
+// LampService controls one tag-lamp strip. Each unary RPC applies one of the
+// modes LampWithClient.Exec supports and returns the resulting state.
+type LampServiceServer interface {
+	// Normal lights the first percent of the strip.
+	Normal(context.Context, *FillRequest) (*LampState, error)
+	// Breathe fades the first percent of the strip in and out.
+	Breathe(context.Context, *FillRequest) (*LampState, error)
+	// Strobe flashes the first percent of the strip.
+	Strobe(context.Context, *FillRequest) (*LampState, error)
+	// Single sets the color of one LED.
+	Single(context.Context, *SingleRequest) (*LampState, error)
+	// Marquee runs a single lit LED along the strip until the next command.
+	Marquee(context.Context, *MarqueeRequest) (*LampState, error)
+	// GetState returns the current state without touching the bus.
+	GetState(context.Context, *GetStateRequest) (*LampState, error)
+	// StreamState sends the current state, then every state change and bus
+	// error until the client cancels.
+	StreamState(*StreamStateRequest, grpc.ServerStreamingServer[StateUpdate]) error
+	// RaiseAlert raises the alert of a source, replacing the one it raised
+	// before. The strip shows the most severe active alert and goes back to
+	// what it showed before once all have cleared or expired.
+	RaiseAlert(context.Context, *RaiseAlertRequest) (*Alerts, error)
+	// ClearAlert clears the alert of a source, or every alert when source is
+	// empty.
+	ClearAlert(context.Context, *ClearAlertRequest) (*Alerts, error)
+	// ListAlerts returns the active alerts.
+	ListAlerts(context.Context, *ListAlertsRequest) (*Alerts, error)
+	// SetGauge shows value as a bar on the strip, moving the bar to it. With
+	// settings it first changes how the gauge shows its values.
+	SetGauge(context.Context, *SetGaugeRequest) (*Gauge, error)
+	// FeedGauge shows each value the client sends as SetGauge does, for a
+	// client that streams readings. It returns the gauge once the client
+	// closes the stream.
+	FeedGauge(grpc.ClientStreamingServer[GaugeValue, Gauge]) error
+	mustEmbedUnimplementedLampServiceServer()
+}
+
+// UnimplementedLampServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLampServiceServer struct{}
+
+func (UnimplementedLampServiceServer) Normal(context.Context, *FillRequest) (*LampState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Normal not implemented")
+}
+func (UnimplementedLampServiceServer) Breathe(context.Context, *FillRequest) (*LampState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Breathe not implemented")
+}
+func (UnimplementedLampServiceServer) Strobe(context.Context, *FillRequest) (*LampState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Strobe not implemented")
+}
+func (UnimplementedLampServiceServer) Single(context.Context, *SingleRequest) (*LampState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Single not implemented")
+}
+func (UnimplementedLampServiceServer) Marquee(context.Context, *MarqueeRequest) (*LampState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Marquee not implemented")
+}
+func (UnimplementedLampServiceServer) GetState(context.Context, *GetStateRequest) (*LampState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetState not implemented")
+}
+func (UnimplementedLampServiceServer) StreamState(*StreamStateRequest, grpc.ServerStreamingServer[StateUpdate]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamState not implemented")
+}
+func (UnimplementedLampServiceServer) RaiseAlert(context.Context, *RaiseAlertRequest) (*Alerts, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RaiseAlert not implemented")
+}
+func (UnimplementedLampServiceServer) ClearAlert(context.Context, *ClearAlertRequest) (*Alerts, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClearAlert not implemented")
+}
+func (UnimplementedLampServiceServer) ListAlerts(context.Context, *ListAlertsRequest) (*Alerts, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAlerts not implemented")
+}
+func (UnimplementedLampServiceServer) SetGauge(context.Context, *SetGaugeRequest) (*Gauge, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetGauge not implemented")
+}
+func (UnimplementedLampServiceServer) FeedGauge(grpc.ClientStreamingServer[GaugeValue, Gauge]) error {
+	return status.Errorf(codes.Unimplemented, "method FeedGauge not implemented")
+}
+func (UnimplementedLampServiceServer) mustEmbedUnimplementedLampServiceServer() {}
+func (UnimplementedLampServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeLampServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LampServiceServer will
+// result in compilation errors.
+type UnsafeLampServiceServer interface {
+	mustEmbedUnimplementedLampServiceServer()
+}
+
+func RegisterLampServiceServer(s grpc.ServiceRegistrar, srv LampServiceServer) {
+	// If the following call pancis, it indicates UnimplementedLampServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&LampService_ServiceDesc, srv)
+}
+
+func _LampService_Normal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FillRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LampServiceServer).Normal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LampService_Normal_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LampServiceServer).Normal(ctx, req.(*FillRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LampService_Breathe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FillRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LampServiceServer).Breathe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LampService_Breathe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LampServiceServer).Breathe(ctx, req.(*FillRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LampService_Strobe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FillRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LampServiceServer).Strobe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LampService_Strobe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LampServiceServer).Strobe(ctx, req.(*FillRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LampService_Single_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SingleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LampServiceServer).Single(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LampService_Single_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LampServiceServer).Single(ctx, req.(*SingleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LampService_Marquee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MarqueeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LampServiceServer).Marquee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LampService_Marquee_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LampServiceServer).Marquee(ctx, req.(*MarqueeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LampService_GetState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LampServiceServer).GetState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LampService_GetState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LampServiceServer).GetState(ctx, req.(*GetStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LampService_StreamState_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamStateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LampServiceServer).StreamState(m, &grpc.GenericServerStream[StreamStateRequest, StateUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LampService_StreamStateServer = grpc.ServerStreamingServer[StateUpdate]
+
+func _LampService_RaiseAlert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RaiseAlertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LampServiceServer).RaiseAlert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LampService_RaiseAlert_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LampServiceServer).RaiseAlert(ctx, req.(*RaiseAlertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LampService_ClearAlert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClearAlertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LampServiceServer).ClearAlert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LampService_ClearAlert_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LampServiceServer).ClearAlert(ctx, req.(*ClearAlertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LampService_ListAlerts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAlertsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LampServiceServer).ListAlerts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LampService_ListAlerts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LampServiceServer).ListAlerts(ctx, req.(*ListAlertsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LampService_SetGauge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetGaugeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LampServiceServer).SetGauge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LampService_SetGauge_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LampServiceServer).SetGauge(ctx, req.(*SetGaugeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LampService_FeedGauge_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LampServiceServer).FeedGauge(&grpc.GenericServerStream[GaugeValue, Gauge]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LampService_FeedGaugeServer = grpc.ClientStreamingServer[GaugeValue, Gauge]
+
+// LampService_ServiceDesc is the grpc.ServiceDesc for LampService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LampService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "lampwith.v1.LampService",
+	HandlerType: (*LampServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Normal",
+			Handler:    _LampService_Normal_Handler,
+		},
+		{
+			MethodName: "Breathe",
+			Handler:    _LampService_Breathe_Handler,
+		},
+		{
+			MethodName: "Strobe",
+			Handler:    _LampService_Strobe_Handler,
+		},
+		{
+			MethodName: "Single",
+			Handler:    _LampService_Single_Handler,
+		},
+		{
+			MethodName: "Marquee",
+			Handler:    _LampService_Marquee_Handler,
+		},
+		{
+			MethodName: "GetState",
+			Handler:    _LampService_GetState_Handler,
+		},
+		{
+			MethodName: "RaiseAlert",
+			Handler:    _LampService_RaiseAlert_Handler,
+		},
+		{
+			MethodName: "ClearAlert",
+			Handler:    _LampService_ClearAlert_Handler,
+		},
+		{
+			MethodName: "ListAlerts",
+			Handler:    _LampService_ListAlerts_Handler,
+		},
+		{
+			MethodName: "SetGauge",
+			Handler:    _LampService_SetGauge_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamState",
+			Handler:       _LampService_StreamState_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "FeedGauge",
+			Handler:       _LampService_FeedGauge_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "lamp.proto",
+}
@@ -0,0 +1,285 @@
+// Package lamprpc exposes a lamp controller as the gRPC LampService.
+package lamprpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative lamp.proto
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"lampwith-tag/alert"
+	"lampwith-tag/lamp"
+)
+
+// Server implements LampServiceServer on top of the controller the REPL uses.
+type Server struct {
+	UnimplementedLampServiceServer
+
+	lc     *lamp.LampWithClient
+	alerts *alert.Manager
+	gauge  *lamp.Gauge
+}
+
+// NewServer returns a LampService backed by lc.
+func NewServer(lc *lamp.LampWithClient) *Server {
+	return &Server{lc: lc}
+}
+
+// SetAlerts serves the alert RPCs from m. Without it they are
+// unimplemented. With it the other RPCs send through m.
+func (s *Server) SetAlerts(m *alert.Manager) {
+	s.alerts = m
+}
+
+// ServeGauge serves the gauge RPCs from g. Without it they are
+// unimplemented.
+func (s *Server) ServeGauge(g *lamp.Gauge) {
+	s.gauge = g
+}
+
+func (s *Server) Normal(ctx context.Context, req *FillRequest) (*LampState, error) {
+	return s.fill(lamp.ModeNormal, req)
+}
+
+func (s *Server) Breathe(ctx context.Context, req *FillRequest) (*LampState, error) {
+	return s.fill(lamp.ModeBreathe, req)
+}
+
+func (s *Server) Strobe(ctx context.Context, req *FillRequest) (*LampState, error) {
+	return s.fill(lamp.ModeStrobe, req)
+}
+
+func (s *Server) Single(ctx context.Context, req *SingleRequest) (*LampState, error) {
+	opts := s.state().Options
+	opts.ControlMode = lamp.ModeSingle
+	opts.ControlPosition = int(req.GetPosition())
+	opts.ControlColor = formatColor(req.GetColor())
+	opts.ControlFade = time.Duration(req.GetFadeMs()) * time.Millisecond
+
+	return s.apply(opts)
+}
+
+func (s *Server) Marquee(ctx context.Context, req *MarqueeRequest) (*LampState, error) {
+	opts := s.state().Options
+	opts.ControlMode = lamp.ModeMarquee
+	opts.ControlColor = formatColor(req.GetColor())
+
+	return s.apply(opts)
+}
+
+func (s *Server) GetState(ctx context.Context, req *GetStateRequest) (*LampState, error) {
+	return toProto(s.lc.State()), nil
+}
+
+func (s *Server) StreamState(req *StreamStateRequest, stream LampService_StreamStateServer) error {
+	events, cancel := s.lc.Subscribe()
+	defer cancel()
+
+	if err := stream.Send(&StateUpdate{Update: &StateUpdate_State{State: toProto(s.lc.State())}}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case ev, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			var u StateUpdate
+			if ev.Err != nil {
+				u.Update = &StateUpdate_Error{Error: &BusError{Message: ev.Err.Error()}}
+			} else {
+				u.Update = &StateUpdate_State{State: toProto(ev.State)}
+			}
+			if err := stream.Send(&u); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Server) RaiseAlert(ctx context.Context, req *RaiseAlertRequest) (*Alerts, error) {
+	if s.alerts == nil {
+		return nil, status.Error(codes.Unimplemented, "alerts are not enabled")
+	}
+	// AlertLevel 的取值与 alert.Level 相同
+	switch req.GetLevel() {
+	case AlertLevel_ALERT_LEVEL_INFO, AlertLevel_ALERT_LEVEL_WARNING, AlertLevel_ALERT_LEVEL_CRITICAL:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown alert level %v", req.GetLevel())
+	}
+	if req.GetSource() == "" {
+		return nil, status.Error(codes.InvalidArgument, "alert needs a source")
+	}
+
+	ttl := time.Duration(req.GetTtlMs()) * time.Millisecond
+	if err := s.alerts.Raise(alert.Level(req.GetLevel()), req.GetSource(), ttl); err != nil {
+		return nil, controlStatus(err)
+	}
+	return s.listAlerts(), nil
+}
+
+func (s *Server) ClearAlert(ctx context.Context, req *ClearAlertRequest) (*Alerts, error) {
+	if s.alerts == nil {
+		return nil, status.Error(codes.Unimplemented, "alerts are not enabled")
+	}
+	if src := req.GetSource(); src != "" && !slices.ContainsFunc(s.alerts.Active(), func(a alert.Alert) bool { return a.Source == src }) {
+		return nil, status.Errorf(codes.NotFound, "no alert from %q", src)
+	}
+
+	if err := s.alerts.Clear(req.GetSource()); err != nil {
+		return nil, controlStatus(err)
+	}
+	return s.listAlerts(), nil
+}
+
+func (s *Server) ListAlerts(ctx context.Context, req *ListAlertsRequest) (*Alerts, error) {
+	if s.alerts == nil {
+		return nil, status.Error(codes.Unimplemented, "alerts are not enabled")
+	}
+	return s.listAlerts(), nil
+}
+
+func (s *Server) listAlerts() *Alerts {
+	now := time.Now()
+	res := &Alerts{}
+	for _, a := range s.alerts.Active() {
+		pa := &Alert{Level: AlertLevel(a.Level), Source: a.Source}
+		if !a.Expires.IsZero() {
+			pa.ExpiresMs = uint32(max(a.Expires.Sub(now), time.Millisecond) / time.Millisecond)
+		}
+		res.Alerts = append(res.Alerts, pa)
+	}
+	return res
+}
+
+func (s *Server) SetGauge(ctx context.Context, req *SetGaugeRequest) (*Gauge, error) {
+	if s.gauge == nil {
+		return nil, status.Error(codes.Unimplemented, "the gauge is not enabled")
+	}
+	if req.Settings != nil {
+		if err := s.gauge.Configure(fromProtoGauge(req.Settings)); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	if err := s.gauge.Set(req.GetValue()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return s.gaugeProto(), nil
+}
+
+func (s *Server) FeedGauge(stream LampService_FeedGaugeServer) error {
+	if s.gauge == nil {
+		return status.Error(codes.Unimplemented, "the gauge is not enabled")
+	}
+	for {
+		v, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(s.gaugeProto())
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.gauge.Set(v.GetValue()); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+}
+
+func (s *Server) gaugeProto() *Gauge {
+	gs := s.gauge.Settings()
+	ps := &GaugeSettings{Min: gs.Min, Max: gs.Max, Reverse: gs.Reverse, EaseMs: uint32(gs.Ease / time.Millisecond)}
+	for _, t := range gs.Thresholds {
+		ps.Thresholds = append(ps.Thresholds, &GaugeThreshold{At: t.At, Color: &Color{R: uint32(t.Color.R), G: uint32(t.Color.G), B: uint32(t.Color.B)}})
+	}
+	return &Gauge{Value: s.gauge.Value(), Settings: ps, Showing: s.gauge.Showing()}
+}
+
+func fromProtoGauge(ps *GaugeSettings) lamp.GaugeSettings {
+	gs := lamp.GaugeSettings{Min: ps.GetMin(), Max: ps.GetMax(), Reverse: ps.GetReverse(), Ease: time.Duration(ps.GetEaseMs()) * time.Millisecond}
+	for _, t := range ps.GetThresholds() {
+		c := t.GetColor()
+		gs.Thresholds = append(gs.Thresholds, lamp.Threshold{At: t.GetAt(), Color: lamp.Color{R: byte(min(c.GetR(), 255)), G: byte(min(c.GetG(), 255)), B: byte(min(c.GetB(), 255))}})
+	}
+	return gs
+}
+
+func (s *Server) fill(mode int, req *FillRequest) (*LampState, error) {
+	opts := s.state().Options
+	opts.ControlMode = mode
+	opts.ControlPercentage = int(req.GetPercent())
+	// 按比例点亮, 与命令行的 set percent 一样清除 ranges
+	opts.ControlRanges = ""
+	opts.ControlColor = formatColor(req.GetColor())
+	opts.ControlPeriod = time.Duration(req.GetPeriodMs()) * time.Millisecond
+	opts.ControlFade = time.Duration(req.GetFadeMs()) * time.Millisecond
+
+	return s.apply(opts)
+}
+
+// apply sends opts, through the alert manager when there is one so that
+// an alert stays on the strip and the strip goes back to opts after it.
+func (s *Server) apply(opts lamp.Options) (*LampState, error) {
+	if s.alerts != nil {
+		if err := s.alerts.Apply(opts); err != nil {
+			return nil, controlStatus(err)
+		}
+		return toProto(s.alerts.State()), nil
+	}
+	if err := s.lc.Apply(opts); err != nil {
+		return nil, controlStatus(err)
+	}
+
+	return toProto(s.lc.State()), nil
+}
+
+// state is the state the fill RPCs start from: while an alert shows, the
+// one the strip goes back to.
+func (s *Server) state() lamp.State {
+	if s.alerts != nil {
+		return s.alerts.State()
+	}
+	return s.lc.State()
+}
+
+// controlStatus maps an error of the strip to a gRPC status.
+func controlStatus(err error) error {
+	var excErr *lamp.ExceptionError
+	switch {
+	case errors.Is(err, lamp.ErrInvalidOption):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.As(err, &excErr):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Error(codes.Unavailable, err.Error())
+}
+
+func formatColor(c *Color) string {
+	return lamp.FormatColor(int(c.GetR()), int(c.GetG()), int(c.GetB()))
+}
+
+func toProto(s lamp.State) *LampState {
+	st := &LampState{
+		Mode:     Mode(s.ControlMode),
+		Percent:  uint32(s.ControlPercentage),
+		Position: uint32(s.ControlPosition),
+		Quantity: uint32(s.Quantity),
+		PeriodMs: uint32(s.Period() / time.Millisecond),
+		FadeMs:   uint32(s.ControlFade / time.Millisecond),
+		Ranges:   s.ControlRanges,
+	}
+	if r, g, b, err := lamp.ParseColor(s.ControlColor); err == nil {
+		st.Color = &Color{R: uint32(r), G: uint32(g), B: uint32(b)}
+	}
+
+	return st
+}
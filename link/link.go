@@ -0,0 +1,191 @@
+// Package link keeps the Modbus connection to a strip alive. A request that
+// fails with an I/O error (timeout, bad CRC, unplugged adapter) closes the
+// port, reopens it through a Dialer and is sent again, with exponential
+// backoff between attempts.
+package link
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/goburrow/modbus"
+
+	"lampwith-tag/i18n"
+)
+
+// Policy is how hard a request is retried.
+type Policy struct {
+	// Retries is the number of attempts after the first one.
+	Retries int
+	// Backoff is the wait before the first retry. It doubles with every
+	// retry up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultPolicy retries three times, waiting 200ms, 400ms and 800ms.
+func DefaultPolicy() Policy {
+	return Policy{Retries: 3, Backoff: 200 * time.Millisecond, MaxBackoff: 5 * time.Second}
+}
+
+// Dialer opens the port. It is called again after every I/O error, so it
+// should find the adapter again if it came back under another name.
+type Dialer func() (modbus.Client, io.Closer, error)
+
+// Client is a modbus.Client that reconnects and retries.
+type Client struct {
+	dial   Dialer
+	policy Policy
+
+	// OnReconnect, when set, runs in its own goroutine after a request
+	// succeeded on a port reopened after an I/O error, e.g. to send the
+	// last state again. A port opened again after Close does not count:
+	// whoever closed it sends what it needs.
+	OnReconnect func()
+
+	mu     sync.Mutex
+	client modbus.Client
+	closer io.Closer
+	// closed is set by Close until the port is open again.
+	closed bool
+}
+
+// New dials once and returns the client.
+func New(dial Dialer, policy Policy) (*Client, error) {
+	c := &Client{dial: dial, policy: policy}
+	if err := c.connect(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) connect() error {
+	client, closer, err := c.dial()
+	if err != nil {
+		return err
+	}
+	c.client, c.closer = client, closer
+	return nil
+}
+
+// Close closes the port. The next request opens it again.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	return c.drop()
+}
+
+func (c *Client) drop() error {
+	if c.client == nil {
+		return nil
+	}
+	err := c.closer.Close()
+	c.client, c.closer = nil, nil
+	return err
+}
+
+// do sends a request. An exception from the device is an answer, so it is
+// returned as is; any other error drops the port and retries.
+func (c *Client) do(req func(modbus.Client) ([]byte, error)) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	backoff := c.policy.Backoff
+	reconnected := false
+	for attempt := 0; ; attempt++ {
+		var err error
+		if c.client == nil {
+			err = c.connect()
+			reconnected = err == nil && !c.closed
+			if err == nil {
+				c.closed = false
+			}
+		}
+		if c.client != nil {
+			var results []byte
+			results, err = req(c.client)
+
+			var mbErr *modbus.ModbusError
+			if err == nil || errors.As(err, &mbErr) {
+				if reconnected && c.OnReconnect != nil {
+					go c.OnReconnect()
+				}
+				return results, err
+			}
+			c.drop()
+		}
+
+		if attempt >= c.policy.Retries {
+			return nil, &giveUpError{attempts: attempt + 1, err: err}
+		}
+		slog.Warn("modbus request failed, retrying", "attempt", attempt+1, "backoff", backoff, "err", err)
+		time.Sleep(backoff)
+		backoff *= 2
+		if c.policy.MaxBackoff > 0 && backoff > c.policy.MaxBackoff {
+			backoff = c.policy.MaxBackoff
+		}
+	}
+}
+
+func (c *Client) ReadCoils(address, quantity uint16) ([]byte, error) {
+	return c.do(func(m modbus.Client) ([]byte, error) { return m.ReadCoils(address, quantity) })
+}
+
+func (c *Client) ReadDiscreteInputs(address, quantity uint16) ([]byte, error) {
+	return c.do(func(m modbus.Client) ([]byte, error) { return m.ReadDiscreteInputs(address, quantity) })
+}
+
+func (c *Client) WriteSingleCoil(address, value uint16) ([]byte, error) {
+	return c.do(func(m modbus.Client) ([]byte, error) { return m.WriteSingleCoil(address, value) })
+}
+
+func (c *Client) WriteMultipleCoils(address, quantity uint16, value []byte) ([]byte, error) {
+	return c.do(func(m modbus.Client) ([]byte, error) { return m.WriteMultipleCoils(address, quantity, value) })
+}
+
+func (c *Client) ReadInputRegisters(address, quantity uint16) ([]byte, error) {
+	return c.do(func(m modbus.Client) ([]byte, error) { return m.ReadInputRegisters(address, quantity) })
+}
+
+func (c *Client) ReadHoldingRegisters(address, quantity uint16) ([]byte, error) {
+	return c.do(func(m modbus.Client) ([]byte, error) { return m.ReadHoldingRegisters(address, quantity) })
+}
+
+func (c *Client) WriteSingleRegister(address, value uint16) ([]byte, error) {
+	return c.do(func(m modbus.Client) ([]byte, error) { return m.WriteSingleRegister(address, value) })
+}
+
+func (c *Client) WriteMultipleRegisters(address, quantity uint16, value []byte) ([]byte, error) {
+	return c.do(func(m modbus.Client) ([]byte, error) { return m.WriteMultipleRegisters(address, quantity, value) })
+}
+
+func (c *Client) ReadWriteMultipleRegisters(readAddress, readQuantity, writeAddress, writeQuantity uint16, value []byte) ([]byte, error) {
+	return c.do(func(m modbus.Client) ([]byte, error) {
+		return m.ReadWriteMultipleRegisters(readAddress, readQuantity, writeAddress, writeQuantity, value)
+	})
+}
+
+func (c *Client) MaskWriteRegister(address, andMask, orMask uint16) ([]byte, error) {
+	return c.do(func(m modbus.Client) ([]byte, error) { return m.MaskWriteRegister(address, andMask, orMask) })
+}
+
+func (c *Client) ReadFIFOQueue(address uint16) ([]byte, error) {
+	return c.do(func(m modbus.Client) ([]byte, error) { return m.ReadFIFOQueue(address) })
+}
+
+// giveUpError is a request that failed on every attempt.
+type giveUpError struct {
+	attempts int
+	err      error
+}
+
+func (e *giveUpError) Error() string { return i18n.English(e) }
+
+func (e *giveUpError) Message() (string, []any) { return "err.link_gave_up", []any{e.attempts, e.err} }
+
+func (e *giveUpError) Unwrap() error { return e.err }
@@ -0,0 +1,77 @@
+package link
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/goburrow/modbus"
+)
+
+// Trace wraps an RTU transporter and logs every request and response ADU
+// in hex, with the round-trip time, the slave ID and whether the response
+// CRC is right.
+func Trace(t modbus.Transporter, slaveID byte, log *slog.Logger) modbus.Transporter {
+	return &tracer{t: t, slaveID: slaveID, log: log}
+}
+
+type tracer struct {
+	t       modbus.Transporter
+	slaveID byte
+	log     *slog.Logger
+}
+
+func (tr *tracer) Send(request []byte) ([]byte, error) {
+	start := time.Now()
+	response, err := tr.t.Send(request)
+	took := time.Since(start)
+
+	attrs := []any{
+		"slave", tr.slaveID,
+		"request", fmt.Sprintf("% x", request),
+		"response", fmt.Sprintf("% x", response),
+		"took", took,
+		"crc", crcResult(response),
+	}
+	if err != nil {
+		tr.log.Warn("rtu", append(attrs, "err", err)...)
+	} else {
+		tr.log.Info("rtu", attrs...)
+	}
+	return response, err
+}
+
+func crcResult(adu []byte) string {
+	if len(adu) < 4 {
+		return "none"
+	}
+	if !ValidCRC(adu) {
+		return "bad"
+	}
+	return "ok"
+}
+
+// ValidCRC checks the CRC at the end of an RTU ADU.
+func ValidCRC(adu []byte) bool {
+	if len(adu) < 4 {
+		return false
+	}
+	n := len(adu) - 2
+	return crc16(adu[:n]) == binary.LittleEndian.Uint16(adu[n:])
+}
+
+func crc16(b []byte) uint16 {
+	crc := uint16(0xffff)
+	for _, v := range b {
+		crc ^= uint16(v)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xa001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
@@ -1,41 +1,223 @@
 package main
 
 import (
-	"bufio"
-		"fmt"
-		"os"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/goburrow/modbus"
+	"github.com/peterh/liner"
+	"google.golang.org/grpc"
+	"lampwith-tag/alert"
+	"lampwith-tag/config"
+	"lampwith-tag/daemon"
+	"lampwith-tag/i18n"
+	"lampwith-tag/lamp"
+	"lampwith-tag/lamprpc"
+	"lampwith-tag/link"
+	"lampwith-tag/meter"
+	"lampwith-tag/metrics"
 	"lampwith-tag/port"
+	"lampwith-tag/preset"
+	"lampwith-tag/repl"
+	"lampwith-tag/scene"
+	"lampwith-tag/schedule"
+	"lampwith-tag/session"
+	"lampwith-tag/web"
 )
 
-const (
-	modeNormal  = 3
-	modeBreathe = 4
-	modeStrobe  = 5
-	modeSingle  = 6
-	modeMarquee = 7
-)
+func main() {
+	grpcAddr := flag.String("grpc", "", "gRPC listen address, ex: :50051")
+	webAddr := flag.String("web", "", "web control panel listen address, ex: :8080")
+	presetPath := flag.String("presets", preset.DefaultPath(), "preset file")
+	statePath := flag.String("state", session.DefaultPath(), "state file holding the last port, quantity and options")
+	onStartFlag := flag.String("on-start", "off", "strip at startup: restore (last state), keep (unchanged) or off")
+	onQuitFlag := flag.String("on-quit", "off", "strip on q: restore (last state), keep (unchanged) or off")
+	configPath := flag.String("config", config.DefaultPath(), "config file with per-port settings")
+	rs485Flag := flag.Bool("rs485", false, "put every port in kernel RS-485 mode (Linux), overrides the config file")
+	rtsOnSend := flag.Bool("rs485-rts-on-send", true, "RTS level while sending in RS-485 mode")
+	rtsAfterSend := flag.Bool("rs485-rts-after-send", false, "RTS level after sending in RS-485 mode")
+	rxDuringTX := flag.Bool("rs485-rx-during-tx", false, "keep the receiver on while sending in RS-485 mode")
+	delayBefore := flag.Int("rs485-delay-before", 0, "RTS delay before sending in RS-485 mode, ms")
+	delayAfter := flag.Int("rs485-delay-after", 0, "RTS delay after sending in RS-485 mode, ms")
+	rs485Check := flag.Bool("rs485-check", false, "report whether each port accepts RS-485 mode, then exit")
+	retries := flag.Int("retries", link.DefaultPolicy().Retries, "retries of a failed request, the port is reopened before each")
+	backoff := flag.Duration("backoff", link.DefaultPolicy().Backoff, "wait before the first retry, doubled for each further retry")
+	maxBackoff := flag.Duration("max-backoff", link.DefaultPolicy().MaxBackoff, "longest wait between retries")
+	daemonFlag := flag.Bool("daemon", false, "run without the console: attach every port with a strip, following adapters as they are plugged in and out")
+	watchInterval := flag.Duration("watch-interval", 2*time.Second, "how often the daemon looks for new or removed ports")
+	logLevel := flag.String("log-level", "info", "log level: debug, info, warn or error")
+	logFormat := flag.String("log-format", "text", "log format: text or json")
+	flag.BoolVar(&traceFrames, "trace", false, "log every RTU request and response with timing, slave ID and CRC check")
+	metricsAddr := flag.String("metrics", "", "serve Prometheus metrics at /metrics on this address, ex: :9100")
+	lang := flag.String("lang", "", "console language: en or zh (default from LANG)")
+	busBudget := flag.Bool("bus-budget", false, "print how many control frames per second each baud rate carries, then exit")
+	fadeCurveFlag := flag.String("fade-curve", "linear", "color space of crossfades (set fade): linear or perceptual")
+	scheduleList := flag.Bool("schedule-list", false, "print the schedule rules of the config file and when they fire next, then exit")
+	scheduleSkip := flag.String("schedule-skip", "", "comma separated schedule rules to leave out this run, all for none")
+	followSpec := flag.String("follow", "", "drive the strip from a signal instead of the console: - (a number per line on stdin), pcm[:rate[:channels]] (raw 16-bit audio on stdin), a .wav file or an http URL (#name picks a JSON field or Prometheus metric)")
+	followColors := flag.String("follow-colors", "white", "one color dimmed with the signal, or two, ex: \"green red\", mixed from the first to the second")
+	followMin := flag.Float64("follow-min", 0, "signal value shown as off, or the first color")
+	followMax := flag.Float64("follow-max", 1, "signal value shown at full, or the second color")
+	followCurve := flag.String("follow-curve", "linear", "curve from the signal to the strip: linear, log or an exponent such as 2")
+	followRanges := flag.String("follow-ranges", "", "LEDs that follow the signal, ex: 1-30, all by default")
+	followEvery := flag.Duration("follow-every", time.Second, "how often an http signal is polled")
+	gaugeFlag := flag.Bool("gauge", false, "show the -follow values on the gauge, from -follow-min to -follow-max, instead of as brightness")
+	flag.Parse()
 
-// LampWithClient lampwith client
-type LampWithClient struct {
-	Client   modbus.Client
-	Quantity int
+	if err := i18n.SetLocale(i18n.Detect(*lang)); err != nil {
+		fmt.Printf("-lang: %v\n", err)
+		os.Exit(1)
+	}
 
-	cStopMarquee chan bool
+	if err := setupLogging(*logLevel, *logFormat); err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
 
-	ControlMode       int
-	ControlPercentage int
-	ControlPosition   int
-	ControlColor      string
-}
+	onStart, err := session.ParsePolicy(*onStartFlag)
+	if err != nil {
+		fmt.Printf("-on-start: %v\n", err)
+		os.Exit(1)
+	}
+	onQuit, err := session.ParsePolicy(*onQuitFlag)
+	if err != nil {
+		fmt.Printf("-on-quit: %v\n", err)
+		os.Exit(1)
+	}
+	if fadeCurve, err = lamp.ParseFadeCurve(*fadeCurveFlag); err != nil {
+		fmt.Printf("-fade-curve: %v\n", i18n.Error(err))
+		os.Exit(1)
+	}
 
-func main() {
-	fmt.Printf("本地串口列表:\n")
+	curve := meter.Curve{Min: *followMin, Max: *followMax}
+	if err := curve.ParseShape(*followCurve); err != nil {
+		fmt.Printf("-follow-curve: %v\n", i18n.Error(err))
+		os.Exit(1)
+	}
+
+	if *busBudget {
+		showBusBudget()
+		return
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		i18n.Printf("config.read_failed", err)
+		os.Exit(1)
+	}
+	sched, err := newSchedule(cfg, *scheduleSkip)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	if *scheduleList {
+		repl.ShowSchedule(os.Stdout, sched, time.Now())
+		return
+	}
+
+	// 命令行的 RS-485 参数覆盖配置文件
+	portConfig := func(name string) config.Port {
+		pc := cfg.Port(name)
+		flag.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "rs485":
+				pc.RS485.Enabled = *rs485Flag
+			case "rs485-rts-on-send":
+				pc.RS485.RTSOnSend = *rtsOnSend
+			case "rs485-rts-after-send":
+				pc.RS485.RTSAfterSend = *rtsAfterSend
+			case "rs485-rx-during-tx":
+				pc.RS485.RXDuringTX = *rxDuringTX
+			case "rs485-delay-before":
+				pc.RS485.DelayBeforeSend = *delayBefore
+			case "rs485-delay-after":
+				pc.RS485.DelayAfterSend = *delayAfter
+			}
+		})
+		return pc
+	}
+
+	last, err := session.Load(*statePath)
+	if err != nil {
+		slog.Warn("cannot read state, not restoring the last session", "path", *statePath, "err", err)
+	}
+
+	i18n.Printf("port.list")
 	port.ShowPort()
+
+	if *rs485Check {
+		for i := 1; i <= len(port.Port); i++ {
+			name := port.Port[i]
+			rs := portConfig(name).RS485
+			rs.Enabled = true
+			if err := port.CheckRS485(name, baudRate, rs); err != nil {
+				i18n.Printf("rs485.unavailable", name, err)
+			} else {
+				i18n.Printf("rs485.available", name)
+			}
+		}
+		return
+	}
+	policy := link.Policy{Retries: *retries, Backoff: *backoff, MaxBackoff: *maxBackoff}
+
+	if *metricsAddr != "" {
+		lis, err := net.Listen("tcp", *metricsAddr)
+		if err != nil {
+			slog.Error("metrics listen failed", "addr", *metricsAddr, "err", err)
+			os.Exit(1)
+		}
+
+		mtr = metrics.New()
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", mtr.Handler())
+		go http.Serve(lis, mux)
+		slog.Info("metrics listening", "url", "http://"+lis.Addr().String()+"/metrics")
+	}
+
+	if *daemonFlag {
+		attach := func(name string) (*lamp.LampWithClient, error) {
+			if !cfg.Pinned(port.Describe(name)) {
+				return nil, errors.New(i18n.T("port.not_pinned"))
+			}
+			lc, err := findTruePort(name, portConfig(name), policy, onStart == session.Off)
+			if err != nil {
+				return nil, err
+			}
+			if last.Quantity > 0 {
+				lc.SetQuantity(last.Quantity)
+			}
+			if opts, err := last.Options(); err == nil && lc.SetOptions(opts) == nil && onStart == session.Restore {
+				lc.Exec()
+			}
+			if mtr != nil {
+				mtr.Watch(name, lc)
+			}
+			return lc, nil
+		}
+		presets, err := preset.Load(*presetPath)
+		if err != nil {
+			i18n.Printf("preset.load_failed", err)
+			os.Exit(1)
+		}
+		runDaemon(attach, *watchInterval, onQuit, sched, cfg.Schedule, presets)
+		return
+	}
+
 	//var portIndex int
 	var portNameMap =  port.Port
 /*	portPtr := flag.String("p", "", "port name")
@@ -51,501 +233,682 @@ func main() {
 	//port := "COM3"
 
 	// new handler
-	var lc LampWithClient
-	var err error
-	for _,portName := range  portNameMap{
-	   lc,err = findTruePort(portName)
+	// 配置了 pins 时按 pins 的顺序只试绑定的适配器, 否则先试上次使用的串口
+	portNames := []string{}
+	if len(cfg.Pins) > 0 {
+		ds, _ := port.List()
+		for _, m := range cfg.Pins {
+			for _, d := range ds {
+				if d.Matches(m) && !slices.Contains(portNames, d.Name) {
+					portNames = append(portNames, d.Name)
+				}
+			}
+		}
+	} else {
+		for _, portName := range portNameMap {
+			if portName == last.Port {
+				portNames = append([]string{portName}, portNames...)
+			} else {
+				portNames = append(portNames, portName)
+			}
+		}
+	}
+
+	var lc *lamp.LampWithClient
+	var usedPort string
+	for _,portName := range  portNames{
+	   lc,err = findTruePort(portName, portConfig(portName), policy, onStart == session.Off)
 		if err != nil{
 			continue
 		}else{
-			fmt.Printf("使用串口%v \n",portName)
+			slog.Info("using port", "port", portName)
+			usedPort = portName
 			break
 		}
 	}
+	if lc == nil && len(cfg.Pins) > 0 {
+		i18n.Printf("port.none_pinned")
+		os.Exit(1)
+	}
+	if lc == nil {
+		i18n.Printf("port.none")
+		os.Exit(1)
+	}
 
-	q := 30
-	inputReader := bufio.NewReader(os.Stdin)
+	if mtr != nil {
+		mtr.SetOnline(usedPort, true)
+		defer mtr.Watch(usedPort, lc)()
+	}
 
-	//fmt.Printf("连接串口 [%s] 成功\n", port)
+	// 报警和命令行共用场景播放器: 报警期间停止场景, 清除后重新播放
+	player := scene.NewPlayer(lc)
+	alerts := alert.NewManager(lc, player)
+	// 图层显示时报警作为最上层
+	layers := lc.NewCompositor()
+	alerts.UseLayers(layers)
+	gauge := lc.NewGauge()
 
-	//彩虹灯
-   // rainbow(lc)
+	if *grpcAddr != "" {
+		lis, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			slog.Error("gRPC listen failed", "addr", *grpcAddr, "err", err)
+			os.Exit(1)
+		}
 
-	/*var q int*/
-	fmt.Printf("请输入灯带的数量(默认 30): ")
-	_, err = fmt.Scanln(&q)
-	if err != nil || q <= 0 {
-		q = 30
-		fmt.Printf("不合法的输入, 使用默认灯带数量 [30], 控制开始\n\n")
-	} else {
-		lc.Quantity = q
-		fmt.Printf("输入数量 [%d], 控制开始\n\n", q)
+		s := grpc.NewServer()
+		srv := lamprpc.NewServer(lc)
+		srv.SetAlerts(alerts)
+		srv.ServeGauge(gauge)
+		lamprpc.RegisterLampServiceServer(s, srv)
+		go s.Serve(lis)
+		defer s.Stop()
+		slog.Info("gRPC listening", "addr", lis.Addr().String())
 	}
 
-	lc.showHelp()
-
-	lastcommand := ""
-
-LOOP:
-	for {
-		fmt.Printf("> ")
-		input, _, err := inputReader.ReadLine()
+	if *webAddr != "" {
+		lis, err := net.Listen("tcp", *webAddr)
 		if err != nil {
-			fmt.Printf("错误输入: %v\n", err)
+			slog.Error("web panel listen failed", "addr", *webAddr, "err", err)
+			os.Exit(1)
 		}
 
-		si := string(input)
-
-		// trim space
-		si = strings.Replace(si, " ", "", -1)
+		go http.Serve(lis, web.Handler(lc, alerts))
+		slog.Info("web panel listening", "url", "http://"+lis.Addr().String()+"/")
+	}
 
-		if lastcommand == "10" || lastcommand == "11" || lastcommand == "12" || (lastcommand == "exec" && lc.ControlMode == modeMarquee) {
-			lc.cStopMarquee <- true
-			time.Sleep(time.Millisecond * 50)
+	q := 30
+	if last.Quantity > 0 {
+		q = last.Quantity
+	}
+	defaultQuantity := q
+
+	// 跟随标准输入时不进入命令行, 灯带数量用上次的
+	if *followSpec != "" {
+		lc.SetQuantity(q)
+		var g *lamp.Gauge
+		if *gaugeFlag {
+			g = gauge
 		}
+		if err := runFollow(lc, g, *followSpec, *followColors, *followRanges, curve, *followEvery); err != nil {
+			fmt.Printf("-follow: %v\n", i18n.Error(err))
+			os.Exit(1)
+		}
+		quit(lc, onQuit)
+		return
+	}
 
-		// percent=[?]			控制的灯珠比例。ex: percent=20 代表控制前20%的灯
-		// position=[?]			控制灯珠的位置（单颗灯控制使用）。ex: position=5 代表控制第5颗灯的颜色
-		// rgb=[r,g,b]			控制灯的颜色和亮度。ex: rgb=255,0,0 代表设置灯的颜色为红色
+	// 命令行支持行编辑、Tab 补全和历史
+	line := liner.NewLiner()
+	defer line.Close()
+	line.SetCtrlCAborts(true)
+	historyPath := filepath.Join(config.Dir(), "history")
+	if f, err := os.Open(historyPath); err == nil {
+		line.ReadHistory(f)
+		f.Close()
+	}
+	defer saveHistory(line, historyPath)
 
-		if strings.HasPrefix(si, "percent=") {
-			sn := strings.Trim(si, "percent=")
-			n, err := strconv.Atoi(sn)
-			if err != nil {
-				fmt.Printf("不合法的输入: %s\n", si)
-				continue
-			}
+	//fmt.Printf("连接串口 [%s] 成功\n", port)
 
-			if n <= 0 || n > 100 {
-				fmt.Printf("百分比应该在 1 and 100\n")
-				continue
-			}
+	//彩虹灯
+   // rainbow(lc)
 
-			lc.ControlPercentage = n
-			fmt.Printf("使用百分比: %d\n类型 'option' 用于显示当前设置 或者 'exec' 用于实现.\n", n)
-			lastcommand = si
-			continue
-		} else if strings.HasPrefix(si, "position=") {
-			sn := strings.Trim(si, "position=")
-			n, err := strconv.Atoi(sn)
-			if err != nil {
-				fmt.Printf("不合法的输入: %s\n", si)
-				continue
-			}
+	/*var q int*/
+	answer, err := line.Prompt(i18n.T("quantity.prompt", defaultQuantity))
+	if err == nil {
+		q, err = strconv.Atoi(strings.TrimSpace(answer))
+	}
+	if err != nil || q <= 0 || q > 255 {
+		q = defaultQuantity
+		lc.SetQuantity(q)
+		i18n.Printf("quantity.default", q)
+	} else {
+		lc.SetQuantity(q)
+		i18n.Printf("quantity.set", q)
+	}
 
-			if n <= 0 || n >= lc.Quantity {
-				fmt.Printf("百分比应该在 1 和 %d之间\n", lc.Quantity)
-				continue
+	// 恢复上次的设置; on-start 决定是否把它发到灯带
+	if opts, err := last.Options(); err == nil {
+		if err := lc.SetOptions(opts); err != nil {
+			slog.Warn("last options are invalid, using defaults", "err", err)
+		} else if onStart == session.Restore {
+			if err := lc.Exec(); err != nil {
+				slog.Error("restoring the last state failed", "port", usedPort, "err", err)
 			}
+		}
+	}
 
-			lc.ControlPosition = n
-			fmt.Printf("设置位置: %d\n类型 'option' 用于显示当前设置 或者 'exec' 用于实现.\n", n)
-			lastcommand = si
-			continue
-		} else if strings.HasPrefix(si, "rgb=") {
-			sc := strings.Trim(si, "rgb=")
-			scs := strings.Split(sc, ",")
-			if len(scs) != 3 {
-				fmt.Printf("不合法的输入: %s\n", si)
-				continue
-			}
+	if err := session.Save(*statePath, session.FromState(usedPort, lc.State())); err != nil {
+		slog.Warn("saving state failed", "path", *statePath, "err", err)
+	}
+	stopTracking := session.Track(lc, *statePath, usedPort, func(err error) {
+		slog.Warn("saving state failed", "path", *statePath, "err", err)
+	})
+	defer stopTracking()
 
-			r, _ := strconv.Atoi(scs[0])
-			g, _ := strconv.Atoi(scs[1])
-			b, _ := strconv.Atoi(scs[2])
+	presets, err := preset.Load(*presetPath)
+	if err != nil {
+		i18n.Printf("preset.load_failed", err)
+		os.Exit(1)
+	}
 
-			if r < 0 || g < 0 || b < 0 || r > 255 || g > 255 || b > 255 {
-				fmt.Printf("rgb值应该在 0 之间 255\n")
-				continue
-			}
+	if err := setAlertLooks(alerts, presets, cfg.Alerts); err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
 
-			if r == 0 && g == 0 && b == 0 {
-				fmt.Printf("颜色值设为(0) !!!!!!\n")
-			}
+	sh := repl.New(lc, presets, player, os.Stdout)
+	line.SetCompleter(sh.Complete)
+	sh.SetAlerts(alerts)
+	sh.SetLayers(layers)
+	sh.SetGauge(gauge)
+
+	runner := &schedule.Runner{Presets: presets, Strips: func() []schedule.Strip {
+		return []schedule.Strip{{Lamp: lc, Player: player, Alerts: alerts, Layers: layers, Gauge: gauge}}
+	}}
+	sh.SetSchedule(sched, runner)
+	defer runSchedule(sched, runner, cfg.Schedule)()
+
+	sh.ShowHelp()
 
-			lc.ControlColor = strconv.Itoa(r) + "," + strconv.Itoa(g) + "," + strconv.Itoa(b)
-			fmt.Printf("设置颜色: r,g,b=%s\n类型 'option' 用于显示当前设置 或者 'exec' 用于实现.\n", lc.ControlColor)
-			lastcommand = si
+	for {
+		input, err := line.Prompt("> ")
+		// Ctrl+D 和 Ctrl+C 与 q 一样退出
+		if err == io.EOF || err == liner.ErrPromptAborted {
+			fmt.Println()
+			input = "quit"
+		} else if err != nil {
+			i18n.Printf("input.error", err)
 			continue
 		}
+		if strings.TrimSpace(input) != "" {
+			line.AppendHistory(input)
+		}
 
-		switch si {
-		case "":
-		case "0":
-			bval := []byte{0x03, 0x64, 0x00, 0x00, 0x00, 0x00}
-			if err := lc.control(bval); err != nil {
-				fmt.Printf("控制错误: %v\n", err)
-				continue
-			}
-		case "1":
-			bval := []byte{0x03, 0x64, 0x00, 0x25, 0x00, 0x00}
-			if err := lc.control(bval); err != nil {
-				fmt.Printf("控制错误: %v\n", err)
-				continue
-			}
-		case "2":
-			bval := []byte{0x03, 0x64, 0x00, 0x00, 0x25, 0x00}
-			if err := lc.control(bval); err != nil {
-				fmt.Printf("控制错误: %v\n", err)
-				continue
-			}
-		case "3":
-			bval := []byte{0x03, 0x64, 0x25, 0x00, 0x00, 0x00}
-			if err := lc.control(bval); err != nil {
-				fmt.Printf("控制错误: %v\n", err)
-				continue
-			}
-		case "4":
-			bval := []byte{0x04, 0x64, 0x00, 0x25, 0x00, 0x05}
-			if err := lc.control(bval); err != nil {
-				fmt.Printf("控制错误: %v\n", err)
-				continue
-			}
-		case "5":
-			bval := []byte{0x04, 0x64, 0x00, 0x00, 0x25, 0x05}
-			if err := lc.control(bval); err != nil {
-				fmt.Printf("控制错误: %v\n", err)
-				continue
-			}
-		case "6":
-			bval := []byte{0x04, 0x64, 0x25, 0x00, 0x00, 0x05}
-			if err := lc.control(bval); err != nil {
-				fmt.Printf("控制错误: %v\n", err)
-				continue
-			}
-		case "7":
-			bval := []byte{0x05, 0x64, 0x00, 0x25, 0x00, 0x64}
-			if err := lc.control(bval); err != nil {
-				fmt.Printf("控制错误: %v\n", err)
+		err = sh.Exec(input)
+		if errors.Is(err, repl.ErrQuit) {
+			quit(lc, onQuit)
+			break
+		}
+		if err != nil {
+			fmt.Println(err)
+		}
+	}
+}
+// runDaemon 不进入命令行: 为每个接上灯带的串口挂载控制器, 拔出的灯带标记为离线,
+// 收到退出信号后按 on-quit 处理所有在线的灯带; rules 与命令行模式一样在启动时检查
+func runDaemon(attach daemon.AttachFunc, interval time.Duration, onQuit session.Policy, sched *schedule.Scheduler, rules []config.Rule, presets *preset.Store) {
+	d := daemon.New(attach)
+	// 挂载失败的串口 (例如灯带还没上电) 稍后重试, 间隔逐次加倍
+	d.Retry = 10 * time.Second
+
+	// 定时规则作用于所有在线的灯带, 每条灯带一个场景播放器
+	players := map[*lamp.LampWithClient]*scene.Player{}
+	var playersMu sync.Mutex
+	runner := &schedule.Runner{Presets: presets, Strips: func() []schedule.Strip {
+		playersMu.Lock()
+		defer playersMu.Unlock()
+		var strips []schedule.Strip
+		for _, s := range d.Strips() {
+			if !s.Online {
 				continue
 			}
-		case "8":
-			bval := []byte{0x05, 0x64, 0x00, 0x00, 0x25, 0x64}
-			if err := lc.control(bval); err != nil {
-				fmt.Printf("控制错误: %v\n", err)
-				continue
+			if players[s.Lamp] == nil {
+				players[s.Lamp] = scene.NewPlayer(s.Lamp)
 			}
-		case "9":
-			bval := []byte{0x05, 0x64, 0x25, 0x00, 0x00, 0x64}
-			if err := lc.control(bval); err != nil {
-				fmt.Printf("控制错误: %v\n", err)
-				continue
+			strips = append(strips, schedule.Strip{Lamp: s.Lamp, Player: players[s.Lamp]})
+		}
+		return strips
+	}}
+	defer runSchedule(sched, runner, rules)()
+
+	events, cancel := d.Subscribe()
+	defer cancel()
+	go func() {
+		for ev := range events {
+			if mtr != nil && ev.Err == nil {
+				mtr.SetOnline(ev.Port, ev.Online)
 			}
-		case "10":
-			go lc.marquee("r")
-		case "11":
-			go lc.marquee("b")
-		case "12":
-			go lc.marquee("g")
-		case "sma":
-			lc.ControlMode = modeNormal
-		case "smb":
-			lc.ControlMode = modeBreathe
-		case "smc":
-			lc.ControlMode = modeStrobe
-		case "smd":
-			lc.ControlMode = modeSingle
-		case "sme":
-			lc.ControlMode = modeMarquee
-		case "option":
-			lc.showCurrentOptions()
-		case "exec":
-			lc.exec()
-		case "h":
-			lc.showHelp()
-		case "q":
-			if lastcommand == "10" || lastcommand == "11" || lastcommand == "12" || (lastcommand == "exec" && lc.ControlMode == modeMarquee) {
-				lc.cStopMarquee <- true
+			switch {
+			case ev.Err != nil:
+				slog.Info("no strip on port", "port", ev.Port, "err", ev.Err)
+			case ev.Online:
+				slog.Info("strip online", "port", ev.Port)
+			default:
+				slog.Warn("strip offline", "port", ev.Port)
 			}
+		}
+	}()
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		d.Run(port.Watch(interval, stop))
+		close(done)
+	}()
+	slog.Info("daemon running, Ctrl+C to quit")
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	<-sig
+	close(stop)
+	<-done
+
+	for _, s := range d.Strips() {
+		if s.Online {
+			quit(s.Lamp, onQuit)
+		}
+	}
+}
 
-			bval := []byte{0x03, 0x64, 0x00, 0x00, 0x00, 0x00}
-			lc.control(bval)
+// newSchedule 读取配置文件中的定时规则, skip 中的规则不参与本次运行
+func newSchedule(cfg config.Config, skip string) (*schedule.Scheduler, error) {
+	rules := cfg.Schedule
+	if skip == "all" {
+		rules = nil
+	} else if skip != "" {
+		names := strings.Split(skip, ",")
+		for _, name := range names {
+			if !slices.ContainsFunc(rules, func(r config.Rule) bool { return r.Name == name }) {
+				return nil, fmt.Errorf("-schedule-skip: no rule %q", name)
+			}
+		}
+		rules = slices.DeleteFunc(slices.Clone(rules), func(r config.Rule) bool { return slices.Contains(names, r.Name) })
+	}
+	return schedule.New(rules, cfg.Location)
+}
 
-			break LOOP
-		default:
-			fmt.Printf("输入 h 帮助,输入 q 退出\n")
+// setAlertLooks 让报警级别显示配置文件中指定的预设
+func setAlertLooks(m *alert.Manager, presets *preset.Store, looks map[string]string) error {
+	for name, presetName := range looks {
+		level, err := alert.ParseLevel(name)
+		if err != nil {
+			return err
+		}
+		p, ok := presets.Get(presetName)
+		if !ok {
+			return fmt.Errorf("config: alert %s: no preset %q", name, presetName)
+		}
+		opts, err := p.Options()
+		if err != nil {
+			return fmt.Errorf("config: alert %s: %v", name, err)
+		}
+		if err := m.SetLook(level, opts); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		lastcommand = si
+// runSchedule 在后台执行定时规则, 返回的函数停止它
+func runSchedule(sched *schedule.Scheduler, runner *schedule.Runner, rules []config.Rule) (stop func()) {
+	if err := runner.Check(rules); err != nil {
+		slog.Warn("schedule rules will fail", "err", err)
 	}
+
+	done := make(chan struct{})
+	go sched.Run(done, func(a schedule.Action) {
+		slog.Info("schedule rule fired", "rule", a.Rule.Name, "end", a.End)
+		if err := runner.Fire(a); err != nil {
+			slog.Warn("schedule rule failed", "rule", a.Rule.Name, "err", err)
+		}
+	})
+	return func() { close(done) }
 }
 
-func (lc *LampWithClient) control(val []byte) error {
-	address := uint16(9)
-	quantity := uint16(3)
+// runFollow 由 spec 指定的信号驱动灯带, 直到信号结束或收到退出信号;
+// gauge 不为 nil 时把信号的数值显示在仪表上
+func runFollow(lc *lamp.LampWithClient, gauge *lamp.Gauge, spec, colors, ranges string, curve meter.Curve, every time.Duration) error {
+	f := lamp.Follow{Ranges: ranges}
+	var cs []lamp.Color
+	for _, c := range strings.Fields(colors) {
+		r, g, b, err := lamp.ParseColorSpec(c)
+		if err != nil {
+			return err
+		}
+		cs = append(cs, lamp.Color{R: r, G: g, B: b})
+	}
+	switch len(cs) {
+	case 1:
+		f.Color = cs[0]
+	case 2:
+		f.Style, f.Color, f.To = lamp.FollowColor, cs[0], cs[1]
+	default:
+		return fmt.Errorf("-follow-colors %q: want one or two colors", colors)
+	}
 
-	_, err := lc.Client.WriteMultipleRegisters(address, quantity, val)
+	src, err := meter.Open(spec, os.Stdin, every)
 	if err != nil {
 		return err
 	}
+	defer src.Close()
+	var tick <-chan time.Time
+	if gauge != nil {
+		s := gauge.Settings()
+		s.Min, s.Max = curve.Min, curve.Max
+		if err := gauge.Configure(s); err != nil {
+			return err
+		}
+		t := time.NewTicker(100 * time.Millisecond)
+		defer t.Stop()
+		tick = t.C
+	} else {
+		f.Level = func() float64 { return curve.Map(src.Value()) }
+		f.Done = src.Done()
+		if err := lc.Follow(f); err != nil {
+			return err
+		}
+	}
+	slog.Info("following signal, Ctrl+C to quit", "signal", spec)
 
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	shown := math.NaN()
+loop:
+	for {
+		select {
+		case <-sig:
+			break loop
+		case <-src.Done():
+			slog.Info("signal ended", "signal", spec)
+			// 显示最后一个数值再退出
+			if gauge != nil {
+				gauge.Set(src.Value())
+				time.Sleep(gauge.Settings().Ease)
+			}
+			break loop
+		case <-tick:
+			// 数值变化时才移动仪表
+			if v := src.Value(); v != shown {
+				gauge.Set(v)
+				shown = v
+			}
+		}
+	}
+	if err := src.Err(); err != nil {
+		slog.Warn("signal failed", "signal", spec, "err", err)
+	}
 	return nil
 }
 
-func (lc *LampWithClient) marquee(color string) {
-	value := []byte{}
+// quit 退出前按 on-quit 处理灯带
+func quit(lc *lamp.LampWithClient, onQuit session.Policy) {
+	lc.StopMarquee()
+	switch onQuit {
+	case session.Off:
+		bval := []byte{0x03, 0x64, 0x00, 0x00, 0x00, 0x00}
+		lc.Control(bval)
+	case session.Restore:
+		if lc.State().ControlMode != lamp.ModeMarquee {
+			// 等渐变结束再退出
+			lc.Exec()
+			<-lc.FadeDone()
+		}
+	}
+}
 
-	switch color {
-	case "r":
-		value = []byte{0x06, 0x00, 0x00, 0x25, 0x00, 0x00}
-	case "g":
-		value = []byte{0x06, 0x00, 0x25, 0x00, 0x00, 0x00}
-	case "b":
-		value = []byte{0x06, 0x00, 0x00, 0x00, 0x25, 0x00}
-	default:
-		// parse current color
-		r, g, b := lc.parseColor()
-		value = []byte{0x06, 0x00, g, r, b, 0x00}
+// saveHistory 保存命令历史, 下次启动时可以用上下键翻看
+func saveHistory(line *liner.State, path string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		slog.Warn("saving history failed", "path", path, "err", err)
+		return
 	}
+	f, err := os.Create(path)
+	if err != nil {
+		slog.Warn("saving history failed", "path", path, "err", err)
+		return
+	}
+	defer f.Close()
 
-OUTLOOP:
-	for {
-		for i := 1; i < lc.Quantity; i++ {
-			value[1] = byte(i)
-			lc.control(value)
-
-			select {
-			case <-time.After(time.Millisecond * 500):
-				// turn off light only
-				tmp := []byte{0x06, byte(i), 0x00, 0x00, 0x00, 0x00}
-				lc.control(tmp)
-			case <-lc.cStopMarquee:
-				// turn off light and break loop
-				tmp := []byte{0x06, byte(i), 0x00, 0x00, 0x00, 0x00}
-				lc.control(tmp)
-
-				break OUTLOOP
-			}
-		}
+	if _, err := line.WriteHistory(f); err != nil {
+		slog.Warn("saving history failed", "path", path, "err", err)
 	}
 }
 
-func (lc *LampWithClient) parseColor() (byte, byte, byte) {
-	c := lc.ControlColor
-	s := strings.Split(c, ",")
-	if len(s) != 3 {
-		fmt.Println("不合法的rgb颜色格式, 使用 25,0,0")
-		return 25, 0, 0
+// showBusBudget 打印各波特率下每帧的耗时和每秒能发送的帧数
+func showBusBudget() {
+	i18n.Printf("bus.header")
+	for _, baud := range []int{9600, 19200, 38400, 57600, 115200} {
+		bus := lamp.DefaultBus()
+		bus.Baud = baud
+		i18n.Printf("bus.budget", baud, bus.WriteTime(3).Round(10*time.Microsecond), bus.WritesPerSecond())
 	}
+}
 
-	r, _ := strconv.Atoi(s[0])
-	g, _ := strconv.Atoi(s[1])
-	b, _ := strconv.Atoi(s[2])
+// 灯带控制器的波特率
+const baudRate = 19200
 
-	return byte(r), byte(g), byte(b)
-}
+//找到合适的端口
+// turnOff 为 false 时只探测, 不改变灯带
+func findTruePort(portName string, pc config.Port, policy link.Policy, turnOff bool)(*lamp.LampWithClient, error){
+	handler := newHandler(portName, pc)
+	err := handler.Connect()
+	if err != nil {
+		if port.IsRS485Error(err) {
+			slog.Error("port rejected RS-485 mode", "port", portName, "err", err)
+		} else {
+			slog.Debug("cannot open port", "port", portName, "err", err)
+		}
+		return nil, &lamp.PortError{Port: portName, Err: err}
+	}
+	if pc.RS485.Enabled {
+		if port.RS485Supported {
+			slog.Info("port in RS-485 mode", "port", portName)
+		} else {
+			slog.Warn("RS-485 mode is only supported on Linux, ignored", "port", portName)
+		}
+	}
 
-func (lc *LampWithClient) exec() error {
-	r, g, b := lc.parseColor()
-
-	switch lc.ControlMode {
-	case modeNormal:
-		quantity := (lc.ControlPercentage * lc.Quantity) / 100
-		value := []byte{0x03, byte(quantity), g, r, b, 0x00}
-		lc.control(value)
-	case modeBreathe:
-		quantity := (lc.ControlPercentage * lc.Quantity) / 100
-		value := []byte{0x04, byte(quantity), g, r, b, 0x05}
-		lc.control(value)
-	case modeStrobe:
-		quantity := (lc.ControlPercentage * lc.Quantity) / 100
-		value := []byte{0x05, byte(quantity), g, r, b, 0x64}
-		lc.control(value)
-	case modeSingle:
-		value := []byte{0x06, byte(lc.ControlPosition), g, r, b, 0x00}
-		lc.control(value)
-	case modeMarquee:
-		go lc.marquee("")
+	// 探测时不重试, 不响应的串口很快跳过
+	probe := lamp.New(newClient(handler, portName))
+	if turnOff {
+		bval := []byte{0x03, 0x64, 0x00, 0x00, 0x00, 0x00}
+		err = probe.Control(bval)
+	} else {
+		err = probe.Ping()
+	}
+	if err != nil {
+		slog.Debug("no strip answers on port", "port", portName, "err", err)
+		handler.Close()
+		return nil,err
 	}
 
-	return nil
-}
+	// 断线后按 by-id 链接或 USB 序列号重新找到适配器
+	stable := port.StableName(portName)
+	desc := port.Describe(portName)
+	opened := handler
+	dial := func() (modbus.Client, io.Closer, error) {
+		if opened != nil {
+			h := opened
+			opened = nil
+			return newClient(h, portName), h, nil
+		}
 
-func (lc *LampWithClient) showCurrentOptions() {
-	mode := ""
-	switch lc.ControlMode {
-	case modeNormal:
-		mode = "常亮"
-	case modeBreathe:
-		mode = "呼吸"
-	case modeStrobe:
-		mode = "频闪"
-	case modeSingle:
-		mode = "单颗灯控制"
-	case modeMarquee:
-		mode = "跑马灯"
-	default:
-		mode = "未知"
+		candidates := []string{stable}
+		if desc.Serial != "" {
+			if d, ok := port.Find(port.Match{VID: desc.VID, PID: desc.PID, Serial: desc.Serial}); ok {
+				candidates = append(candidates, d.Name)
+			}
+		}
+		candidates = append(candidates, portName)
+
+		var err error
+		for _, name := range candidates {
+			h := newHandler(name, pc)
+			if err = h.Connect(); err == nil {
+				return newClient(h, portName), h, nil
+			}
+		}
+		return nil, nil, &lamp.PortError{Port: portName, Err: err}
 	}
 
-	fmt.Printf(`当前操作:
-	模式: %s
-	百分比: %d
-	位置: %d
-	颜色: r,g,b=%s
+	client, err := link.New(dial, policy)
+	if err != nil {
+		handler.Close()
+		return nil, err
+	}
+	lc := lamp.New(client)
+	lc.Bus = lamp.Bus{
+		Baud:       handler.BaudRate,
+		DataBits:   handler.DataBits,
+		StopBits:   handler.StopBits,
+		Parity:     handler.Parity,
+		Turnaround: lamp.DefaultBus().Turnaround,
+	}
+	lc.FadeCurve = fadeCurve
+	client.OnReconnect = func() {
+		slog.Info("port reconnected, sending the last state again", "port", portName)
+		if mtr != nil {
+			mtr.Reconnected(portName)
+		}
+		// 图层、仪表或跟随信号也重新开始
+		if err := lc.Resend(); err != nil {
+			slog.Error("restoring the state after reconnect failed", "port", portName, "err", err)
+		}
+	}
 
-`, mode, lc.ControlPercentage, lc.ControlPosition, lc.ControlColor)
-}
+	return lc,nil
 
-func (lc *LampWithClient) showHelp() {
-	fmt.Print(`用法:
-	以下数字为预先设置的模式(输入 h 帮助,输入 q 退出):
-
-	数字				    描述
-	  0 					所有灯灭
-	  1 					所有灯常亮：红
-	  2 					所有灯常亮：蓝
-	  3 					所有灯常亮：绿
-	  4 					所有灯呼吸：红
-	  5 					所有灯呼吸：蓝
-	  6 					所有灯呼吸：绿
-	  7 					所有灯频闪：红
-	  8 					所有灯频闪：蓝
-	  9 					所有灯频闪：绿
-	  10 					跑马灯：红
-	  11 					跑马灯：蓝
-	  12 					跑马灯：绿
-
-	以下命令为特殊的设置
-	
-	命令					 描述
-	  sma					设置为常亮模式
-	  smb					设置为呼吸模式
-	  smc					设置为频闪模式					
-	  smd					设置为单颗灯控制模式
-	  sme 					设置为跑马灯模式
-
-	percent=[?]				控制的灯珠比例。ex: percent=20 代表控制前20%的灯
-	position=[?]			控制灯珠的位置（单颗灯控制使用）。例如: position=5 代表控制第5颗灯的颜色
-	rgb=[r,g,b]				控制灯的颜色和亮度。例如: rgb=255,0,0 代表设置灯的颜色为红色
-
-	 option					显示当前配置
-	  exec					使用当前配置执行控制
-
-`)
 }
-//找到合适的端口
-func findTruePort(portName string)(lc LampWithClient,err error){
+
+// newHandler 按灯带控制器的串口参数创建 RTU 连接
+func newHandler(portName string, pc config.Port) *modbus.RTUClientHandler {
 	handler := modbus.NewRTUClientHandler(portName)
-	handler.BaudRate = 19200
+	handler.BaudRate = baudRate
 	handler.Timeout = time.Second
 	handler.DataBits = 8
 	handler.Parity = "N"
 	handler.StopBits = 1
 	handler.SlaveId = 1
-	// handler.Logger = log.New(os.Stdout, "rtu: ", log.LstdFlags)
+	handler.RS485 = pc.RS485.Serial()
 
-	err = handler.Connect()
-	if err != nil {
-	//	fmt.Printf("连接串口 [%s] 失败, 错误信息[%v]\n", portName, err)
-		return lc,err
-	}
-
-	client := modbus.NewClient(handler)
-
-	lc.Client = client
-	lc.Quantity = 30
-	lc.cStopMarquee = make(chan bool)
+	return handler
+}
 
-	lc.ControlMode = modeNormal
-	lc.ControlPercentage = 100
-	lc.ControlPosition = 1
-	lc.ControlColor = "3,4,5"
+var (
+	// traceFrames 为 true 时记录每一帧 RTU 请求和响应
+	traceFrames bool
+	// mtr 在 -metrics 打开时统计总线和灯带状态
+	mtr *metrics.Metrics
+	// fadeCurve 是渐变混合颜色的方式
+	fadeCurve lamp.FadeCurve
+)
 
-	bval := []byte{0x03, 0x64, 0x00, 0x00, 0x00, 0x00}
-	if err := lc.control(bval); err != nil {
-	//	fmt.Printf("连接串口错误: %v\n", err)
-		return lc,err
+// newClient 创建 Modbus 客户端; portName 是统计用的灯带名称, 重新连接后不变
+func newClient(handler *modbus.RTUClientHandler, portName string) modbus.Client {
+	var t modbus.Transporter = handler
+	if mtr != nil {
+		t = mtr.Transport(t, portName)
+	}
+	if traceFrames {
+		t = link.Trace(t, handler.SlaveId, slog.Default())
 	}
+	return modbus.NewClient2(handler, t)
+}
 
-	return lc,err
+// setupLogging 按 -log-level 和 -log-format 设置默认日志
+func setupLogging(level, format string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("-log-level: %v", err)
+	}
 
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case "text":
+		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, opts)))
+	case "json":
+		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, opts)))
+	default:
+		return fmt.Errorf("-log-format: unknown format %q, want text or json", format)
+	}
+	return nil
 }
 
 //彩虹跑马灯
-func rainbow(lc LampWithClient){
+func rainbow(lc *lamp.LampWithClient){
 	var i=0
 	for {
 		for ; i+6 < 16; i++ {
-			lc.ControlMode = modeSingle
-			lc.ControlColor = strconv.Itoa(255) + "," + strconv.Itoa(0) + "," + strconv.Itoa(0)
-			lc.ControlPosition = i
-			lc.exec()
-
-			lc.ControlMode = modeSingle
-			lc.ControlColor = strconv.Itoa(255) + "," + strconv.Itoa(165) + "," + strconv.Itoa(0)
-			lc.ControlPosition = i + 1
-			lc.exec()
-
-			lc.ControlMode = modeSingle
-			lc.ControlColor = strconv.Itoa(255) + "," + strconv.Itoa(255) + "," + strconv.Itoa(0)
-			lc.ControlPosition = i + 2
-			lc.exec()
-
-			lc.ControlMode = modeSingle
-			lc.ControlColor = strconv.Itoa(0) + "," + strconv.Itoa(255) + "," + strconv.Itoa(0)
-			lc.ControlPosition = i + 3
-			lc.exec()
-
-			lc.ControlMode = modeSingle
-			lc.ControlColor = strconv.Itoa(0) + "," + strconv.Itoa(127) + "," + strconv.Itoa(255)
-			lc.ControlPosition = i + 4
-			lc.exec()
-
-			lc.ControlMode = modeSingle
-			lc.ControlColor = strconv.Itoa(0) + "," + strconv.Itoa(0) + "," + strconv.Itoa(255)
-			lc.ControlPosition = i + 5
-			lc.exec()
-
-			lc.ControlMode = modeSingle
-			lc.ControlColor = strconv.Itoa(139) + "," + strconv.Itoa(0) + "," + strconv.Itoa(255)
-			lc.ControlPosition = i + 6
-			lc.exec()
+			lc.SetMode(lamp.ModeSingle)
+			lc.SetColor(255, 0, 0)
+			lc.SetPosition(i)
+			lc.Exec()
+
+			lc.SetMode(lamp.ModeSingle)
+			lc.SetColor(255, 165, 0)
+			lc.SetPosition(i + 1)
+			lc.Exec()
+
+			lc.SetMode(lamp.ModeSingle)
+			lc.SetColor(255, 255, 0)
+			lc.SetPosition(i + 2)
+			lc.Exec()
+
+			lc.SetMode(lamp.ModeSingle)
+			lc.SetColor(0, 255, 0)
+			lc.SetPosition(i + 3)
+			lc.Exec()
+
+			lc.SetMode(lamp.ModeSingle)
+			lc.SetColor(0, 127, 255)
+			lc.SetPosition(i + 4)
+			lc.Exec()
+
+			lc.SetMode(lamp.ModeSingle)
+			lc.SetColor(0, 0, 255)
+			lc.SetPosition(i + 5)
+			lc.Exec()
+
+			lc.SetMode(lamp.ModeSingle)
+			lc.SetColor(139, 0, 255)
+			lc.SetPosition(i + 6)
+			lc.Exec()
 
 		}
 
 		for ; i > 0; i-- {
-			lc.ControlMode = modeSingle
-			lc.ControlColor = strconv.Itoa(255) + "," + strconv.Itoa(0) + "," + strconv.Itoa(0)
-			lc.ControlPosition = i
-			lc.exec()
+			lc.SetMode(lamp.ModeSingle)
+			lc.SetColor(255, 0, 0)
+			lc.SetPosition(i)
+			lc.Exec()
 
-			lc.ControlMode = modeSingle
-			lc.ControlColor = strconv.Itoa(255) + "," + strconv.Itoa(165) + "," + strconv.Itoa(0)
-			lc.ControlPosition = i + 1
-			lc.exec()
+			lc.SetMode(lamp.ModeSingle)
+			lc.SetColor(255, 165, 0)
+			lc.SetPosition(i + 1)
+			lc.Exec()
 
-			lc.ControlMode = modeSingle
-			lc.ControlColor = strconv.Itoa(255) + "," + strconv.Itoa(255) + "," + strconv.Itoa(0)
-			lc.ControlPosition = i + 2
-			lc.exec()
+			lc.SetMode(lamp.ModeSingle)
+			lc.SetColor(255, 255, 0)
+			lc.SetPosition(i + 2)
+			lc.Exec()
 
 
-			lc.ControlMode = modeSingle
-			lc.ControlColor = strconv.Itoa(0) + "," + strconv.Itoa(255) + "," + strconv.Itoa(0)
+			lc.SetMode(lamp.ModeSingle)
+			lc.SetColor(0, 255, 0)
 
 
 
 
-			lc.ControlPosition = i + 3
-			lc.exec()
+			lc.SetPosition(i + 3)
+			lc.Exec()
 
-			lc.ControlMode = modeSingle
-			lc.ControlColor = strconv.Itoa(0) + "," + strconv.Itoa(127) + "," + strconv.Itoa(255)
-			lc.ControlPosition = i + 4
-			lc.exec()
+			lc.SetMode(lamp.ModeSingle)
+			lc.SetColor(0, 127, 255)
+			lc.SetPosition(i + 4)
+			lc.Exec()
 
-			lc.ControlMode = modeSingle
-			lc.ControlColor = strconv.Itoa(0) + "," + strconv.Itoa(0) + "," + strconv.Itoa(255)
+			lc.SetMode(lamp.ModeSingle)
+			lc.SetColor(0, 0, 255)
 
 
-			lc.ControlPosition = i + 5
-			lc.exec()
+			lc.SetPosition(i + 5)
+			lc.Exec()
 
-			lc.ControlMode = modeSingle
-			lc.ControlColor = strconv.Itoa(139) + "," + strconv.Itoa(0) + "," + strconv.Itoa(255)
-			lc.ControlPosition = i + 6
-			lc.exec()
+			lc.SetMode(lamp.ModeSingle)
+			lc.SetColor(139, 0, 255)
+			lc.SetPosition(i + 6)
+			lc.Exec()
 		}
 	}
 
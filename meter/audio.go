@@ -0,0 +1,172 @@
+package meter
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// window is the stretch of audio one level is measured over.
+const window = 50 * time.Millisecond
+
+// Limits on the audio format, so a bad header or spec cannot size a huge
+// buffer.
+const (
+	maxRate     = 384000
+	maxChannels = 32
+	// maxFmt is the largest fmt chunk read, the extensible one is 40 bytes.
+	maxFmt = 64
+)
+
+// format is how PCM samples are laid out.
+type format struct {
+	rate     int
+	channels int
+	// bits is 8 (unsigned) or 16 (signed little-endian).
+	bits int
+}
+
+// PCM reads raw signed 16-bit little-endian audio from r as it comes, a
+// live stream such as arecord -t raw -f S16_LE, and tracks its RMS level:
+// 0 for silence, about 0.7 for a sine at full scale.
+func PCM(r io.Reader, rate, channels int) *Source {
+	s := newSource()
+	go s.readAudio(r, format{rate: rate, channels: channels, bits: 16}, false)
+	return s
+}
+
+// WAV plays a PCM WAV file in real time, so the strip follows it as it
+// would sound, and tracks its level as PCM does. The signal ends with the
+// file.
+func WAV(path string) (*Source, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(f)
+	fm, err := readWAVHeader(r)
+	if err != nil {
+		f.Close()
+		return nil, errorf("err.meter.file", path, err)
+	}
+
+	s := newSource()
+	go func() {
+		defer f.Close()
+		s.readAudio(r, fm, true)
+	}()
+	return s, nil
+}
+
+// readWAVHeader reads up to the samples of a RIFF WAVE file.
+func readWAVHeader(r io.Reader) (format, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return format{}, err
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return format{}, errorf("err.meter.wav")
+	}
+
+	var fm format
+	for {
+		var head [8]byte
+		if _, err := io.ReadFull(r, head[:]); err != nil {
+			return format{}, errorf("err.meter.wav_data", err)
+		}
+		id, size := string(head[0:4]), binary.LittleEndian.Uint32(head[4:8])
+
+		switch id {
+		case "fmt ":
+			if size < 16 || size > maxFmt {
+				return format{}, errorf("err.meter.wav_fmt_size", size, maxFmt)
+			}
+			body := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return format{}, errorf("err.meter.wav_fmt_short")
+			}
+			// 1 是 PCM, 0xfffe 是扩展格式
+			if tag := binary.LittleEndian.Uint16(body[0:2]); tag != 1 && tag != 0xfffe {
+				return format{}, errorf("err.meter.wav_pcm", tag)
+			}
+			fm.channels = int(binary.LittleEndian.Uint16(body[2:4]))
+			fm.rate = int(binary.LittleEndian.Uint32(body[4:8]))
+			fm.bits = int(binary.LittleEndian.Uint16(body[14:16]))
+			if fm.bits != 8 && fm.bits != 16 {
+				return format{}, errorf("err.meter.wav_bits", fm.bits)
+			}
+			if fm.channels == 0 || fm.rate == 0 {
+				return format{}, errorf("err.meter.wav_rate")
+			}
+			if fm.channels > maxChannels || fm.rate > maxRate {
+				return format{}, errorf("err.meter.wav_too_big", fm.channels, fm.rate, maxChannels, maxRate)
+			}
+		case "data":
+			if fm.rate == 0 {
+				return format{}, errorf("err.meter.wav_data_first")
+			}
+			return fm, nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, int64(size+size%2)); err != nil {
+				return format{}, err
+			}
+		}
+	}
+}
+
+// readAudio measures r window by window until it ends or the source is
+// closed. With pace it keeps to the sample rate.
+func (s *Source) readAudio(r io.Reader, fm format, pace bool) {
+	defer close(s.done)
+
+	if fm.rate <= 0 || fm.rate > maxRate || fm.channels <= 0 || fm.channels > maxChannels {
+		s.fail(errorf("err.meter.audio_format", fm.channels, fm.rate, maxChannels, maxRate))
+		return
+	}
+	frames := max(fm.rate*int(window)/int(time.Second), 1)
+	block := make([]byte, frames*fm.channels*fm.bits/8)
+	start := time.Now()
+	for n := 1; !s.stopped(); n++ {
+		got, err := io.ReadFull(r, block)
+		if got > 0 {
+			s.set(rms(block[:got], fm.bits))
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+				s.fail(err)
+			}
+			s.set(0)
+			return
+		}
+		if pace {
+			time.Sleep(time.Until(start.Add(time.Duration(n) * window)))
+		}
+	}
+}
+
+// rms is the root mean square of the samples, 1 at full scale.
+func rms(b []byte, bits int) float64 {
+	var sum float64
+	n := 0
+	if bits == 8 {
+		for _, v := range b {
+			x := (float64(v) - 128) / 128
+			sum += x * x
+			n++
+		}
+	} else {
+		for i := 0; i+1 < len(b); i += 2 {
+			x := float64(int16(binary.LittleEndian.Uint16(b[i:]))) / 32768
+			sum += x * x
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return math.Sqrt(sum / float64(n))
+}
@@ -0,0 +1,27 @@
+package meter
+
+import "lampwith-tag/i18n"
+
+// meterError is an error of a signal, told through the i18n catalog.
+type meterError struct {
+	key  string
+	args []any
+}
+
+func errorf(key string, args ...any) error {
+	return &meterError{key: key, args: args}
+}
+
+func (e *meterError) Error() string { return i18n.English(e) }
+
+func (e *meterError) Message() (string, []any) { return e.key, e.args }
+
+// Unwrap returns the error among the args, if any.
+func (e *meterError) Unwrap() error {
+	for _, a := range e.args {
+		if err, ok := a.(error); ok {
+			return err
+		}
+	}
+	return nil
+}
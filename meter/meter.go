@@ -0,0 +1,184 @@
+// Package meter reads signals for lamp.Follow: the loudness of audio from a
+// WAV file or raw PCM, numbers written to a stream, or a metric polled over
+// HTTP. A Curve maps the value of a signal to the 0..1 the strip shows.
+package meter
+
+import (
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Source is a signal read in the background. Value is the latest reading.
+type Source struct {
+	mu    sync.Mutex
+	value float64
+	err   error
+
+	done chan struct{}
+	stop chan struct{}
+	once sync.Once
+}
+
+func newSource() *Source {
+	return &Source{done: make(chan struct{}), stop: make(chan struct{})}
+}
+
+// Value returns the latest reading, 0 before the first.
+func (s *Source) Value() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.value
+}
+
+// Err returns the error that ended the signal or the last failed poll.
+func (s *Source) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
+
+// Done is closed when the signal has ended: the audio or the stream ran
+// out, or Close was called.
+func (s *Source) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close stops reading. A stream blocked in a read ends with its next line
+// or block.
+func (s *Source) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	return nil
+}
+
+func (s *Source) set(v float64) {
+	s.mu.Lock()
+	s.value = v
+	s.mu.Unlock()
+}
+
+func (s *Source) fail(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+}
+
+// stopped reports whether Close was called.
+func (s *Source) stopped() bool {
+	select {
+	case <-s.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// Open opens the signal spec names:
+//
+//	song.wav               a PCM WAV file, played in real time
+//	pcm[:rate[:channels]]  raw signed 16-bit little-endian audio on stdin
+//	-                      one number per line on stdin
+//	http://host/path#name  a metric polled every interval
+//
+// The name after # of a URL picks the number from the body, see Poll.
+func Open(spec string, stdin io.Reader, interval time.Duration) (*Source, error) {
+	switch {
+	case spec == "-" || spec == "stdin":
+		return Numbers(stdin), nil
+	case spec == "pcm" || strings.HasPrefix(spec, "pcm:"):
+		rate, channels := 44100, 1
+		parts := strings.Split(spec, ":")
+		var err error
+		if len(parts) > 1 {
+			if rate, err = strconv.Atoi(parts[1]); err != nil || rate <= 0 || rate > maxRate {
+				return nil, errorf("err.meter.pcm_rate", spec)
+			}
+		}
+		if len(parts) > 2 {
+			if channels, err = strconv.Atoi(parts[2]); err != nil || channels <= 0 || channels > maxChannels {
+				return nil, errorf("err.meter.pcm_channels", spec)
+			}
+		}
+		return PCM(stdin, rate, channels), nil
+	case strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://"):
+		url, field, _ := strings.Cut(spec, "#")
+		return Poll(url, field, interval), nil
+	case strings.HasSuffix(strings.ToLower(spec), ".wav"):
+		return WAV(spec)
+	}
+	return nil, errorf("err.meter.spec", spec)
+}
+
+// Shape is the shape of a Curve.
+type Shape int
+
+const (
+	// Linear maps the range evenly.
+	Linear Shape = iota
+	// Log lifts low values and compresses high ones, as the ear hears
+	// loudness.
+	Log
+	// Power raises the linear value to Curve.Exponent: above 1 it holds
+	// the strip dark until the signal is high, below 1 the reverse.
+	Power
+)
+
+// Curve maps a signal from Min..Max to 0..1.
+type Curve struct {
+	Min, Max float64
+	Shape    Shape
+	Exponent float64
+}
+
+// DefaultCurve maps 0..1 straight through.
+var DefaultCurve = Curve{Min: 0, Max: 1}
+
+// Map returns where v falls on the curve, clamped to 0..1.
+func (c Curve) Map(v float64) float64 {
+	if c.Max == c.Min || math.IsNaN(v) {
+		return 0
+	}
+	t := min(max((v-c.Min)/(c.Max-c.Min), 0), 1)
+	switch c.Shape {
+	case Log:
+		return math.Log10(1 + 9*t)
+	case Power:
+		return math.Pow(t, c.Exponent)
+	}
+	return t
+}
+
+// ParseShape parses "linear", "log" or an exponent for Power, ex: "2" or
+// "0.5". It sets Shape and Exponent of c.
+func (c *Curve) ParseShape(s string) error {
+	switch s {
+	case "linear":
+		c.Shape = Linear
+		return nil
+	case "log":
+		c.Shape = Log
+		return nil
+	}
+	e, err := strconv.ParseFloat(s, 64)
+	if err != nil || e <= 0 || math.IsInf(e, 0) {
+		return errorf("err.meter.curve", s)
+	}
+	c.Shape, c.Exponent = Power, e
+	return nil
+}
+
+// String returns the shape as ParseShape takes it.
+func (c Curve) String() string {
+	switch c.Shape {
+	case Log:
+		return "log"
+	case Power:
+		return strconv.FormatFloat(c.Exponent, 'g', -1, 64)
+	}
+	return "linear"
+}
@@ -0,0 +1,141 @@
+package meter
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Numbers reads one number per line from r, ex: a production counter piped
+// in. Lines that are not a number are skipped with a warning. The signal
+// ends with r.
+func Numbers(r io.Reader) *Source {
+	s := newSource()
+	go func() {
+		defer close(s.done)
+
+		sc := bufio.NewScanner(r)
+		for !s.stopped() && sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" {
+				continue
+			}
+			v, err := strconv.ParseFloat(line, 64)
+			if err != nil {
+				slog.Warn("not a number", "line", line)
+				continue
+			}
+			s.set(v)
+		}
+		if err := sc.Err(); err != nil {
+			s.fail(err)
+		}
+	}()
+	return s
+}
+
+// Poll gets url every interval (1s if 0) until closed. The body is a plain
+// number; or JSON, with the number at field, a dotted path such as
+// "line.rate"; or Prometheus text, with the number of the metric named
+// field. A failed poll is logged and keeps the last value.
+func Poll(url, field string, interval time.Duration) *Source {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	client := &http.Client{Timeout: max(interval, time.Second)}
+
+	s := newSource()
+	go func() {
+		defer close(s.done)
+
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			v, err := poll(client, url, field)
+			if err != nil {
+				slog.Warn("poll failed", "url", url, "err", err)
+				s.fail(err)
+			} else {
+				s.set(v)
+			}
+
+			select {
+			case <-s.stop:
+				return
+			case <-t.C:
+			}
+		}
+	}()
+	return s
+}
+
+func poll(client *http.Client, url, field string) (float64, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, errorf("err.meter.http_status", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, err
+	}
+	return parseValue(body, field)
+}
+
+// parseValue finds the number in a poll body, see Poll.
+func parseValue(body []byte, field string) (float64, error) {
+	text := strings.TrimSpace(string(body))
+	if field == "" {
+		return strconv.ParseFloat(text, 64)
+	}
+
+	if strings.HasPrefix(text, "{") {
+		var v any
+		if err := json.Unmarshal(body, &v); err != nil {
+			return 0, err
+		}
+		for _, k := range strings.Split(field, ".") {
+			m, ok := v.(map[string]any)
+			if !ok {
+				return 0, errorf("err.meter.no_field", field)
+			}
+			if v, ok = m[k]; !ok {
+				return 0, errorf("err.meter.no_field", field)
+			}
+		}
+		switch n := v.(type) {
+		case float64:
+			return n, nil
+		case string:
+			return strconv.ParseFloat(n, 64)
+		}
+		return 0, errorf("err.meter.not_number", field)
+	}
+
+	// Prometheus 文本格式: 名称{标签} 值 [时间戳]
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, rest, _ := strings.Cut(line, " ")
+		if n, _, ok := strings.Cut(name, "{"); ok {
+			name = n
+			_, rest, _ = strings.Cut(line, "} ")
+		}
+		if name != field {
+			continue
+		}
+		value, _, _ := strings.Cut(strings.TrimSpace(rest), " ")
+		return strconv.ParseFloat(value, 64)
+	}
+	return 0, errorf("err.meter.no_metric", field)
+}
@@ -0,0 +1,187 @@
+// Package metrics exports bus health and strip state for Prometheus.
+//
+// Bus metrics come from the RTU transport of each strip (see Transport), so
+// every attempt of a retried request is counted. Strip metrics come from
+// the controller's events (see Watch).
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/goburrow/modbus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"lampwith-tag/lamp"
+	"lampwith-tag/link"
+)
+
+// Metrics holds the collectors of one process.
+type Metrics struct {
+	reg *prometheus.Registry
+
+	transactions *prometheus.CounterVec
+	errors       *prometheus.CounterVec
+	latency      *prometheus.HistogramVec
+	reconnects   *prometheus.CounterVec
+	online       *prometheus.GaugeVec
+	mode         *prometheus.GaugeVec
+	color        *prometheus.GaugeVec
+	frames       *prometheus.CounterVec
+}
+
+// New registers the collectors.
+func New() *Metrics {
+	m := &Metrics{
+		reg: prometheus.NewRegistry(),
+		transactions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "lampwith_modbus_transactions_total",
+			Help: "Modbus transactions sent, by strip and function code.",
+		}, []string{"port", "function"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "lampwith_modbus_errors_total",
+			Help: "Failed Modbus transactions by type: timeout, crc, framing, io or exception. exception holds the exception code.",
+		}, []string{"port", "type", "exception"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "lampwith_modbus_request_duration_seconds",
+			Help:    "Round-trip time of Modbus transactions.",
+			Buckets: []float64{.005, .01, .02, .03, .05, .075, .1, .2, .5, 1, 2},
+		}, []string{"port"}),
+		reconnects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "lampwith_reconnects_total",
+			Help: "Times the port of a strip was reopened after an I/O error.",
+		}, []string{"port"}),
+		online: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "lampwith_strip_online",
+			Help: "1 while the strip is attached.",
+		}, []string{"port"}),
+		mode: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "lampwith_strip_mode",
+			Help: "Current mode of the strip: 3 normal, 4 breathe, 5 strobe, 6 single, 7 marquee.",
+		}, []string{"port"}),
+		color: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "lampwith_strip_color",
+			Help: "Current color of the strip, per channel (r, g, b).",
+		}, []string{"port", "channel"}),
+		frames: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "lampwith_frames_total",
+			Help: "Control frames sent, by the strip's mode. The rate for mode=\"marquee\" is the effect frame rate.",
+		}, []string{"port", "mode"}),
+	}
+
+	m.reg.MustRegister(
+		m.transactions, m.errors, m.latency, m.reconnects,
+		m.online, m.mode, m.color, m.frames,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.reg, promhttp.HandlerOpts{})
+}
+
+// Transport wraps the RTU transporter of the strip on port.
+func (m *Metrics) Transport(t modbus.Transporter, port string) modbus.Transporter {
+	return &transport{t: t, m: m, port: port}
+}
+
+type transport struct {
+	t    modbus.Transporter
+	m    *Metrics
+	port string
+}
+
+func (t *transport) Send(request []byte) ([]byte, error) {
+	start := time.Now()
+	response, err := t.t.Send(request)
+	took := time.Since(start)
+
+	function := "unknown"
+	if len(request) > 1 {
+		function = strconv.Itoa(int(request[1]))
+	}
+	t.m.transactions.WithLabelValues(t.port, function).Inc()
+	t.m.latency.WithLabelValues(t.port).Observe(took.Seconds())
+
+	switch {
+	case err != nil:
+		t.m.errors.WithLabelValues(t.port, errorType(err), "").Inc()
+	case !link.ValidCRC(response):
+		t.m.errors.WithLabelValues(t.port, "crc", "").Inc()
+	case response[1]&0x80 != 0:
+		t.m.errors.WithLabelValues(t.port, "exception", strconv.Itoa(int(response[2]))).Inc()
+	}
+	return response, err
+}
+
+// errorType sorts transport errors, see lamp.Classify.
+func errorType(err error) string {
+	var (
+		timeout  *lamp.TimeoutError
+		frameErr *lamp.FrameError
+	)
+	switch err = lamp.Classify(err); {
+	case errors.As(err, &timeout):
+		return "timeout"
+	case errors.As(err, &frameErr):
+		return "framing"
+	}
+	return "io"
+}
+
+// Reconnected counts a reopened port.
+func (m *Metrics) Reconnected(port string) {
+	m.reconnects.WithLabelValues(port).Inc()
+}
+
+// SetOnline marks the strip on port attached or not.
+func (m *Metrics) SetOnline(port string, online bool) {
+	v := 0.0
+	if online {
+		v = 1
+	}
+	m.online.WithLabelValues(port).Set(v)
+}
+
+// Watch follows the mode, color and frames of lc until the returned
+// function is called.
+func (m *Metrics) Watch(port string, lc *lamp.LampWithClient) (stop func()) {
+	events, cancel := lc.Subscribe()
+	m.state(port, lc.State())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for ev := range events {
+			m.state(port, ev.State)
+			if ev.Frame != nil && ev.Err == nil {
+				m.frames.WithLabelValues(port, lamp.ModeName(ev.State.ControlMode)).Inc()
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func (m *Metrics) state(port string, st lamp.State) {
+	m.mode.WithLabelValues(port).Set(float64(st.ControlMode))
+
+	r, g, b, err := lamp.ParseColor(st.ControlColor)
+	if err != nil {
+		return
+	}
+	m.color.WithLabelValues(port, "r").Set(float64(r))
+	m.color.WithLabelValues(port, "g").Set(float64(g))
+	m.color.WithLabelValues(port, "b").Set(float64(b))
+}
@@ -0,0 +1,90 @@
+package port
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Descriptor is a serial port and the USB adapter behind it. The USB fields
+// are empty for on-board UARTs and on systems where they cannot be read.
+type Descriptor struct {
+	Name string
+	// VID and PID are the USB vendor and product IDs in hex, e.g. 0403 and
+	// 6001 for an FTDI FT232R.
+	VID, PID    string
+	Serial      string
+	Description string
+}
+
+func (d Descriptor) String() string {
+	if d.VID == "" {
+		return d.Name
+	}
+
+	s := fmt.Sprintf("%s [%s:%s", d.Name, d.VID, d.PID)
+	if d.Serial != "" {
+		s += " " + d.Serial
+	}
+	if d.Description != "" {
+		s += " " + d.Description
+	}
+	return s + "]"
+}
+
+// Match selects adapters. Empty fields match anything; VID and PID compare
+// without case.
+type Match struct {
+	// Name is a port name or, on Linux, a /dev/serial/by-id link.
+	Name   string `json:"name,omitempty"`
+	VID    string `json:"vid,omitempty"`
+	PID    string `json:"pid,omitempty"`
+	Serial string `json:"serial,omitempty"`
+}
+
+func (m Match) String() string {
+	parts := []string{}
+	for _, f := range []struct{ k, v string }{{"name", m.Name}, {"vid", m.VID}, {"pid", m.PID}, {"serial", m.Serial}} {
+		if f.v != "" {
+			parts = append(parts, f.k+"="+f.v)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Matches reports whether d is selected by m.
+func (d Descriptor) Matches(m Match) bool {
+	if m.Name != "" && m.Name != d.Name && StableName(m.Name) != StableName(d.Name) {
+		return false
+	}
+	if m.VID != "" && !strings.EqualFold(m.VID, d.VID) {
+		return false
+	}
+	if m.PID != "" && !strings.EqualFold(m.PID, d.PID) {
+		return false
+	}
+	if m.Serial != "" && m.Serial != d.Serial {
+		return false
+	}
+	return true
+}
+
+// List describes the serial ports in the order ShowPort numbers them.
+func List() ([]Descriptor, error) {
+	names, err := list()
+	ds := make([]Descriptor, len(names))
+	for i, name := range names {
+		ds[i] = Describe(name)
+	}
+	return ds, err
+}
+
+// Find returns the first port selected by m.
+func Find(m Match) (Descriptor, bool) {
+	ds, _ := List()
+	for _, d := range ds {
+		if d.Matches(m) {
+			return d, true
+		}
+	}
+	return Descriptor{}, false
+}
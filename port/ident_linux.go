@@ -0,0 +1,71 @@
+package port
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const serialByID = "/dev/serial/by-id"
+
+// StableName returns the /dev/serial/by-id link of name. The link follows
+// the adapter when it comes back under another ttyUSB number. It returns
+// name when there is no link.
+func StableName(name string) string {
+	real, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		return name
+	}
+
+	links, _ := filepath.Glob(filepath.Join(serialByID, "*"))
+	for _, link := range links {
+		if target, err := filepath.EvalSymlinks(link); err == nil && target == real {
+			return link
+		}
+	}
+	return name
+}
+
+// Describe reads the USB identity of name from sysfs. Ports that are not
+// USB adapters only get a Name.
+func Describe(name string) Descriptor {
+	d := Descriptor{Name: name}
+
+	dir := usbDevice(name)
+	if dir == "" {
+		return d
+	}
+	attr := func(file string) string {
+		b, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(b))
+	}
+
+	d.VID = attr("idVendor")
+	d.PID = attr("idProduct")
+	d.Serial = attr("serial")
+	d.Description = strings.TrimSpace(attr("manufacturer") + " " + attr("product"))
+	return d
+}
+
+// usbDevice returns the sysfs directory of the USB device behind the tty
+// name: the first parent of its device link that has an idVendor file.
+func usbDevice(name string) string {
+	real, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		return ""
+	}
+
+	dir, err := filepath.EvalSymlinks(filepath.Join(sysTTY, filepath.Base(real), "device"))
+	if err != nil {
+		return ""
+	}
+	for ; dir != "/" && dir != "."; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, "idVendor")); err == nil {
+			return dir
+		}
+	}
+	return ""
+}
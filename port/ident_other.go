@@ -0,0 +1,14 @@
+//go:build !linux
+
+package port
+
+// StableName returns name. Only Linux has stable port links.
+func StableName(name string) string {
+	return name
+}
+
+// Describe returns a descriptor with only the Name. Only Linux reads USB
+// identities.
+func Describe(name string) Descriptor {
+	return Descriptor{Name: name}
+}
@@ -0,0 +1,72 @@
+package port
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/goburrow/serial"
+)
+
+// RS485Supported is true where the serial driver can be switched to RS-485
+// mode. Elsewhere RS485 settings are ignored.
+const RS485Supported = runtime.GOOS == "linux"
+
+// RS485 is the kernel RS-485 mode of a port: the driver raises RTS around
+// each transmission so a half-duplex transceiver switches direction.
+type RS485 struct {
+	Enabled bool `json:"enabled"`
+	// RTSOnSend is the RTS level while sending, RTSAfterSend after it.
+	RTSOnSend    bool `json:"rts_on_send"`
+	RTSAfterSend bool `json:"rts_after_send"`
+	// RXDuringTX keeps the receiver on while sending.
+	RXDuringTX bool `json:"rx_during_tx"`
+	// Delays around sending, in milliseconds.
+	DelayBeforeSend int `json:"delay_before_send_ms"`
+	DelayAfterSend  int `json:"delay_after_send_ms"`
+}
+
+// Serial converts r to the serial package's config.
+func (r RS485) Serial() serial.RS485Config {
+	return serial.RS485Config{
+		Enabled:            r.Enabled,
+		DelayRtsBeforeSend: time.Duration(r.DelayBeforeSend) * time.Millisecond,
+		DelayRtsAfterSend:  time.Duration(r.DelayAfterSend) * time.Millisecond,
+		RtsHighDuringSend:  r.RTSOnSend,
+		RtsHighAfterSend:   r.RTSAfterSend,
+		RxDuringTx:         r.RXDuringTX,
+	}
+}
+
+// IsRS485Error reports whether opening a port failed because the driver
+// rejected RS-485 mode.
+func IsRS485Error(err error) bool {
+	var se *os.SyscallError
+	return errors.As(err, &se) && se.Syscall == "SYS_IOCTL (RS485)"
+}
+
+// CheckRS485 opens name with r applied and closes it again. It returns nil
+// when the driver accepted RS-485 mode.
+func CheckRS485(name string, baudRate int, r RS485) error {
+	if !r.Enabled {
+		return errors.New("RS-485 mode is not enabled")
+	}
+	if !RS485Supported {
+		return errors.New("RS-485 mode is only set by the driver on Linux")
+	}
+
+	p, err := serial.Open(&serial.Config{
+		Address:  name,
+		BaudRate: baudRate,
+		DataBits: 8,
+		StopBits: 1,
+		Parity:   "N",
+		Timeout:  time.Second,
+		RS485:    r.Serial(),
+	})
+	if err != nil {
+		return err
+	}
+	return p.Close()
+}
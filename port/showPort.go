@@ -1,3 +1,5 @@
+//go:build windows
+
 package port
 
 import (
@@ -47,6 +49,15 @@ func toStr(str *uint16) string {
 }
 
 func ShowPort(){
+	names, _ := list()
+	for i, name := range names {
+		Port[i+1] = name
+		fmt.Printf("%v:%v \n",i+1,Port[i+1])
+	}
+}
+
+// list returns the COM ports known to the spooler.
+func list() ([]string, error) {
 	var cbNeeded, cReturned uint32
 	nEnumPortsW.Call(
 		0,                 // null
@@ -60,6 +71,9 @@ func ShowPort(){
 	// fmt.Printf("cb: %v\n", cbNeeded)
 	// fmt.Printf("cReturned: %v\n", cReturned)
 
+	if cbNeeded == 0 {
+		return nil, nil
+	}
 	buffer := make([]byte, cbNeeded)
 	nEnumPortsW.Call(
 		0,
@@ -77,7 +91,7 @@ func ShowPort(){
 	}
 	pInfos := *(*[]PortInfo2)(unsafe.Pointer(&hdr))
 	pInfos = pInfos[:] // you can even copy all.
-	i := 1
+	names := []string{}
 	for _, t := range pInfos {
 		// And the length of array is 1<<30. Pretty sure unsafe.
 		// First make the pointer an array (with length of inifnite)
@@ -86,13 +100,12 @@ func ShowPort(){
 			index := strings.Index(toStr(t.pPortName),":")
 			s:= toStr(t.pPortName)
 			port := (string)([]rune(s)[:index])
-			Port[i] = port
-			fmt.Printf("%v:%v \n",i,Port[i])
-			i++
+			names = append(names, port)
 		/*	fmt.Printf("%v - %v - %v\n", toStr(t.pPortName),
 				toStr(t.pMonitorName),
 				toStr(t.pDescription),
 			)*/
 		}
 	}
-}
\ No newline at end of file
+	return names, nil
+}
@@ -0,0 +1,55 @@
+package port
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"lampwith-tag/i18n"
+)
+
+var Port = make(map[int]string)
+
+const sysTTY = "/sys/class/tty"
+
+// ShowPort lists the serial ports that have a device behind them.
+func ShowPort() {
+	names, err := list()
+	if err != nil {
+		i18n.Printf("port.list_failed", sysTTY, err)
+	}
+	for i, name := range names {
+		Port[i+1] = name
+		fmt.Printf("%v:%v \n", i+1, Describe(name))
+	}
+}
+
+// list returns the serial ports in name order. Legacy 8250 entries (ttyS*)
+// without a detected UART are skipped, they exist on most machines whether
+// or not there is a port.
+func list() ([]string, error) {
+	entries, err := os.ReadDir(sysTTY)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, e := range entries {
+		name := e.Name()
+		if _, err := os.Stat(filepath.Join(sysTTY, name, "device")); err != nil {
+			continue
+		}
+		if strings.HasPrefix(name, "ttyS") {
+			b, err := os.ReadFile(filepath.Join(sysTTY, name, "type"))
+			if err != nil || strings.TrimSpace(string(b)) == "0" {
+				continue
+			}
+		}
+		names = append(names, "/dev/"+name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
@@ -0,0 +1,27 @@
+//go:build !windows && !linux
+
+package port
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+var Port = make(map[int]string)
+
+// ShowPort lists the callout devices under /dev.
+func ShowPort() {
+	names, _ := list()
+	for i, name := range names {
+		Port[i+1] = name
+		fmt.Printf("%v:%v \n", i+1, Describe(name))
+	}
+}
+
+// list returns the callout devices in name order.
+func list() ([]string, error) {
+	names, err := filepath.Glob("/dev/cu.*")
+	sort.Strings(names)
+	return names, err
+}
@@ -0,0 +1,66 @@
+package port
+
+import "time"
+
+// Event is a serial port appearing or going away.
+type Event struct {
+	Name  string
+	Added bool
+}
+
+// Watch lists the serial ports every interval and sends an Event for each
+// port that appeared or went away, until stop is closed. The ports present
+// at the start are sent as added. The channel is closed when Watch stops.
+func Watch(interval time.Duration, stop <-chan struct{}) <-chan Event {
+	return WatchList(list, interval, stop)
+}
+
+// WatchList is Watch over the port names list returns, for another source
+// of ports.
+func WatchList(list func() ([]string, error), interval time.Duration, stop <-chan struct{}) <-chan Event {
+	events := make(chan Event, 16)
+
+	go func() {
+		defer close(events)
+
+		known := map[string]bool{}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			// 列表读取失败时保持原状, 不把所有串口当作拔出
+			if names, err := list(); err == nil {
+				current := map[string]bool{}
+				for _, name := range names {
+					current[name] = true
+					if !known[name] && !send(events, Event{Name: name, Added: true}, stop) {
+						return
+					}
+				}
+				for name := range known {
+					if !current[name] && !send(events, Event{Name: name}, stop) {
+						return
+					}
+				}
+				known = current
+			}
+
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return events
+}
+
+func send(events chan<- Event, ev Event, stop <-chan struct{}) bool {
+	select {
+	case events <- ev:
+		return true
+	case <-stop:
+		return false
+	}
+}
@@ -0,0 +1,265 @@
+// Package preset keeps named option sets in a JSON file.
+package preset
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"lampwith-tag/config"
+	"lampwith-tag/lamp"
+)
+
+// Preset is a named set of options.
+type Preset struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Mode        string `json:"mode"`
+	Percent     int    `json:"percent"`
+	Position    int    `json:"position"`
+	Color       string `json:"color"`
+	Ranges      string `json:"ranges,omitempty"`
+	// Period is the speed of breathe and strobe, ex: "2s", "250ms" or
+	// "4Hz". Empty is the mode's default.
+	Period string `json:"period,omitempty"`
+	// Fade crossfades to the preset, ex: "500ms". Empty keeps the fade
+	// that is set.
+	Fade string `json:"fade,omitempty"`
+}
+
+// Options returns the options the preset applies.
+func (p Preset) Options() (lamp.Options, error) {
+	mode, err := lamp.ParseMode(p.Mode)
+	if err != nil {
+		return lamp.Options{}, err
+	}
+	var period, fade time.Duration
+	if p.Period != "" {
+		if period, err = lamp.ParsePeriod(p.Period); err != nil {
+			return lamp.Options{}, err
+		}
+	}
+	if p.Fade != "" {
+		if fade, err = lamp.ParseFade(p.Fade); err != nil {
+			return lamp.Options{}, err
+		}
+	}
+
+	return lamp.Options{
+		ControlMode:       mode,
+		ControlPercentage: p.Percent,
+		ControlPosition:   p.Position,
+		ControlColor:      p.Color,
+		ControlRanges:     p.Ranges,
+		ControlPeriod:     period,
+		ControlFade:       fade,
+	}, nil
+}
+
+// FromOptions captures opts as a preset called name.
+func FromOptions(name string, opts lamp.Options) Preset {
+	return Preset{
+		Name:     name,
+		Mode:     lamp.ModeName(opts.ControlMode),
+		Percent:  opts.ControlPercentage,
+		Position: opts.ControlPosition,
+		Color:    opts.ControlColor,
+		Ranges:   opts.ControlRanges,
+		Period:   formatPeriod(opts.ControlPeriod),
+		Fade:     formatPeriod(opts.ControlFade),
+	}
+}
+
+func formatPeriod(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return lamp.FormatPeriod(d)
+}
+
+// Defaults are the numbered presets the tool has always shipped with. They
+// have no description, so the console describes them in its own language.
+func Defaults() []Preset {
+	fill := func(name, mode, color string) Preset {
+		return Preset{Name: name, Mode: mode, Percent: 100, Position: 1, Color: color}
+	}
+
+	return []Preset{
+		fill("0", "normal", "0,0,0"),
+		fill("1", "normal", "37,0,0"),
+		fill("2", "normal", "0,0,37"),
+		fill("3", "normal", "0,37,0"),
+		fill("4", "breathe", "37,0,0"),
+		fill("5", "breathe", "0,0,37"),
+		fill("6", "breathe", "0,37,0"),
+		fill("7", "strobe", "37,0,0"),
+		fill("8", "strobe", "0,0,37"),
+		fill("9", "strobe", "0,37,0"),
+		fill("10", "marquee", "37,0,0"),
+		fill("11", "marquee", "0,0,37"),
+		fill("12", "marquee", "0,37,0"),
+	}
+}
+
+// Store is the preset file. Presets keep the order they were added in.
+type Store struct {
+	path string
+
+	mu      sync.Mutex
+	presets []Preset
+}
+
+// DefaultPath is presets.json in the config directory.
+func DefaultPath() string {
+	return filepath.Join(config.Dir(), "presets.json")
+}
+
+// Load reads the preset file at path. A missing file yields Defaults; it is
+// created on the first change.
+func Load(path string) (*Store, error) {
+	s := &Store{path: path}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.presets = Defaults()
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &s.presets); err != nil {
+		return nil, fmt.Errorf("preset: %s: %v", path, err)
+	}
+	for _, p := range s.presets {
+		if err := validate(p); err != nil {
+			return nil, fmt.Errorf("preset: %s: %v", path, err)
+		}
+	}
+
+	return s, nil
+}
+
+// reserved are REPL commands a preset name must not shadow.
+var reserved = []string{"h", "q", "option", "exec", "play", "stop", "preset", "sma", "smb", "smc", "smd", "sme",
+	"set", "mode", "apply", "range", "run", "show", "help", "quit", "schedule", "alert", "layer", "follow", "gauge",
+	"normal", "breathe", "strobe", "single", "marquee"}
+
+func validate(p Preset) error {
+	if p.Name == "" || strings.ContainsAny(p.Name, " \t=") {
+		return fmt.Errorf("preset name %q must be one word without '='", p.Name)
+	}
+	for _, r := range reserved {
+		if p.Name == r {
+			return fmt.Errorf("preset name %q is a command", p.Name)
+		}
+	}
+
+	opts, err := p.Options()
+	if err != nil {
+		return fmt.Errorf("preset %q: %v", p.Name, err)
+	}
+	if _, _, _, err := lamp.ParseColor(p.Color); err != nil {
+		return fmt.Errorf("preset %q: %v", p.Name, err)
+	}
+	if p.Percent <= 0 || p.Percent > 100 {
+		return fmt.Errorf("preset %q: percent %d must be between 1 and 100", p.Name, p.Percent)
+	}
+	// the strip length is only known when the preset runs
+	if err := opts.Validate(255); err != nil {
+		return fmt.Errorf("preset %q: %v", p.Name, err)
+	}
+
+	return nil
+}
+
+// List returns all presets in order.
+func (s *Store) List() []Preset {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Preset(nil), s.presets...)
+}
+
+// Get returns the preset called name.
+func (s *Store) Get(name string) (Preset, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.presets {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
+
+// Save adds p, replacing a preset with the same name, and writes the file.
+func (s *Store) Save(p Preset) error {
+	if err := validate(p); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	presets := append([]Preset(nil), s.presets...)
+	replaced := false
+	for i := range presets {
+		if presets[i].Name == p.Name {
+			presets[i] = p
+			replaced = true
+		}
+	}
+	if !replaced {
+		presets = append(presets, p)
+	}
+
+	return s.write(presets)
+}
+
+// Delete removes the preset called name and writes the file.
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	presets := make([]Preset, 0, len(s.presets))
+	for _, p := range s.presets {
+		if p.Name != name {
+			presets = append(presets, p)
+		}
+	}
+	if len(presets) == len(s.presets) {
+		return fmt.Errorf("preset %q not found", name)
+	}
+
+	return s.write(presets)
+}
+
+// write saves presets to the file and makes them current. Caller must hold
+// the mutex.
+func (s *Store) write(presets []Preset) error {
+	b, err := json.MarshalIndent(presets, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+
+	s.presets = presets
+	return nil
+}
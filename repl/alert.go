@@ -0,0 +1,160 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"lampwith-tag/alert"
+	"lampwith-tag/i18n"
+	"lampwith-tag/lamp"
+	"lampwith-tag/scene"
+)
+
+// SetAlerts lets the alert command raise and clear alerts on m. The
+// other commands then send through m, so they change what the strip goes
+// back to while an alert shows. m plays scenes on the shell's player.
+func (sh *Shell) SetAlerts(m *alert.Manager) {
+	sh.alerts = m
+}
+
+// state is the strip the commands start from: while an alert shows, the
+// one it goes back to.
+func (sh *Shell) state() lamp.State {
+	if sh.alerts != nil {
+		return sh.alerts.State()
+	}
+	return sh.lc.State()
+}
+
+func (sh *Shell) setOptions(opts lamp.Options) error {
+	if sh.alerts != nil {
+		return sh.alerts.SetOptions(opts)
+	}
+	return sh.lc.SetOptions(opts)
+}
+
+func (sh *Shell) applyOptions(opts lamp.Options) error {
+	if sh.alerts != nil {
+		return sh.alerts.Apply(opts)
+	}
+	return sh.lc.Apply(opts)
+}
+
+func (sh *Shell) execOptions() error {
+	if sh.alerts != nil {
+		return sh.alerts.Apply(sh.alerts.State().Options)
+	}
+	return sh.lc.Exec()
+}
+
+func (sh *Shell) playScene(seq *scene.Sequence, done func(error)) error {
+	if sh.alerts != nil {
+		return sh.alerts.Play(seq, done)
+	}
+	sh.lc.StopMarquee()
+	sh.player.Play(seq, done)
+	return nil
+}
+
+func (sh *Shell) stopScene() {
+	if sh.alerts != nil {
+		sh.alerts.StopScene()
+		return
+	}
+	sh.player.Stop()
+}
+
+// alert lists the active alerts, raises one or clears them: "alert
+// critical press-3 5m", "alert clear press-3".
+func (sh *Shell) alert(p *plan, args []string) (step, error) {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	if sh.alerts == nil {
+		return nil, &UsageError{Msg: i18n.T("repl.no_alerts")}
+	}
+
+	switch args[0] {
+	case "list":
+		return func() error {
+			ShowAlerts(sh.out, sh.alerts.Active(), time.Now())
+			return nil
+		}, nil
+	case "clear":
+		source := ""
+		if len(args) > 1 {
+			source = args[1]
+			if !sh.hasAlert(source) {
+				return nil, &UsageError{Msg: i18n.T("repl.unknown_alert", source)}
+			}
+		}
+		p.sent = true
+		return func() error {
+			if err := sh.alerts.Clear(source); err != nil {
+				return &ControlError{Err: err}
+			}
+			if source == "" {
+				source = i18n.T("alert.all")
+			}
+			i18n.Fprintf(sh.out, "alert.cleared", source)
+			return nil
+		}, nil
+	}
+
+	level, err := alert.ParseLevel(args[0])
+	if err != nil {
+		return nil, &UsageError{Cmd: "alert", Msg: i18n.T("repl.unknown_level", args[0])}
+	}
+	switch {
+	case len(args) < 2:
+		return nil, &UsageError{Cmd: "alert", Msg: i18n.T("repl.missing_source")}
+	case len(args) > 3:
+		return nil, &UsageError{Cmd: "alert", Msg: i18n.T("repl.extra_args")}
+	}
+	source := args[1]
+	var ttl time.Duration
+	if len(args) == 3 {
+		if ttl, err = time.ParseDuration(args[2]); err != nil || ttl <= 0 {
+			return nil, &UsageError{Cmd: "alert", Msg: i18n.T("repl.bad_ttl", args[2])}
+		}
+	}
+	p.sent = true
+	return func() error {
+		if err := sh.alerts.Raise(level, source, ttl); err != nil {
+			return &ControlError{Err: err}
+		}
+		i18n.Fprintf(sh.out, "alert.raised", level, source)
+		return nil
+	}, nil
+}
+
+func (sh *Shell) hasAlert(source string) bool {
+	for _, a := range sh.alerts.Active() {
+		if a.Source == source {
+			return true
+		}
+	}
+	return false
+}
+
+// ShowAlerts prints alerts, the one the strip shows first.
+func ShowAlerts(w io.Writer, alerts []alert.Alert, now time.Time) {
+	if len(alerts) == 0 {
+		i18n.Fprintf(w, "alert.none")
+		return
+	}
+
+	i18n.Fprintf(w, "alert.header")
+	for i, a := range alerts {
+		until := i18n.T("alert.sticky")
+		if !a.Expires.IsZero() {
+			until = i18n.T("alert.expires", a.Expires.Sub(now).Round(time.Second))
+		}
+		shown := ""
+		if i == 0 {
+			shown = i18n.T("alert.shown")
+		}
+		fmt.Fprintf(w, "\t%-9s %-16s %s %s\n", a.Level, a.Source, until, shown)
+	}
+}
@@ -0,0 +1,596 @@
+package repl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"lampwith-tag/i18n"
+	"lampwith-tag/lamp"
+	"lampwith-tag/preset"
+	"lampwith-tag/scene"
+)
+
+type command struct {
+	name    string
+	aliases []string
+	// args is the argument syntax shown in usage lines.
+	args string
+	// parse checks the arguments against p and returns what the command
+	// does. Nothing is sent to the strip before every command of the line
+	// has been parsed.
+	parse func(sh *Shell, p *plan, args []string) (step, error)
+	// stops reports whether the command interrupts a playing scene.
+	stops func(args []string) bool
+}
+
+func (c *command) interrupts(args []string) bool {
+	return c.stops != nil && c.stops(args)
+}
+
+var settings = []string{"percent", "ranges", "position", "color", "period", "speed", "fade", "quantity"}
+
+var commands []*command
+
+func init() {
+	always := func([]string) bool { return true }
+
+	commands = []*command{
+		{name: "set", args: "percent|ranges|position|color|period|speed|fade|quantity <value>", parse: (*Shell).set},
+		{name: "mode", args: "normal|breathe|strobe|single|marquee", parse: (*Shell).mode},
+		{name: "apply", args: "<mode> [percent%] [position] [color] [setting=value]", parse: (*Shell).apply, stops: always},
+		{name: "range", args: "<ranges> [color] [mode]", parse: (*Shell).lightRanges, stops: always},
+		{name: "run", aliases: []string{"exec"}, parse: (*Shell).exec, stops: always},
+		{name: "show", aliases: []string{"option"}, parse: (*Shell).show},
+		{name: "play", args: "<file>", parse: (*Shell).play},
+		{name: "stop", parse: func(*Shell, *plan, []string) (step, error) { return nop, nil }, stops: always},
+		{name: "preset", args: "save|list|delete|run [name]", parse: (*Shell).preset,
+			stops: func(args []string) bool { return len(args) > 0 && args[0] == "run" }},
+		{name: "schedule", args: "list|pause|resume|skip|run [rule]", parse: (*Shell).schedule,
+			stops: func(args []string) bool { return len(args) > 0 && args[0] == "run" }},
+		{name: "layer", args: "list|clear|remove [name] | <name> <mode> [percent%] [ranges] [color] [blend=add] [opacity=50%] [z=1] | <name> play <file>", parse: (*Shell).layer},
+		{name: "alert", args: "list|clear [source] | info|warning|critical <source> [ttl]", parse: (*Shell).alert},
+		{name: "follow", args: "<file.wav|http://host/path#metric> [brightness|color] [color] [color] [ranges] [min=0] [max=1] [curve=linear|log|2] [every=1s]", parse: (*Shell).follow, stops: always},
+		{name: "gauge", args: "[value] [min=0] [max=100] [reverse|forward] [70:amber ...] [ease=500ms]", parse: (*Shell).feedGauge},
+		{name: "help", aliases: []string{"h"}, args: "[command]", parse: (*Shell).help},
+		{name: "quit", aliases: []string{"q"}, parse: func(*Shell, *plan, []string) (step, error) { return quit, nil }, stops: always},
+	}
+}
+
+func nop() error { return nil }
+
+func quit() error { return ErrQuit }
+
+func lookup(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+		for _, a := range c.aliases {
+			if a == name {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// usage returns the synopsis of the command called name.
+func usage(name string) string {
+	c := lookup(name)
+	if c == nil {
+		return name
+	}
+	return strings.TrimSpace(c.name + " " + c.args)
+}
+
+// setting changes one setting of p. cmd is the command whose usage applies.
+func (p *plan) setting(cmd, name, value string) error {
+	switch name {
+	case "percent":
+		n, err := number(cmd, "percent", strings.TrimSuffix(value, "%"))
+		if err != nil {
+			return err
+		}
+		if n <= 0 || n > 100 {
+			return &UsageError{Cmd: cmd, Msg: i18n.T("repl.percent_range", n)}
+		}
+		p.opts.ControlPercentage = n
+		p.opts.ControlRanges = ""
+
+	case "ranges":
+		// off 回到按百分比点亮
+		if value == "off" {
+			value = ""
+		}
+		if value != "" {
+			ranges, err := lamp.ParseRanges(value, p.quantity)
+			if err != nil {
+				return &UsageError{Cmd: cmd, Msg: i18n.Error(err)}
+			}
+			value = lamp.FormatRanges(ranges)
+		}
+		p.opts.ControlRanges = value
+
+	case "position":
+		n, err := number(cmd, "position", value)
+		if err != nil {
+			return err
+		}
+		if n <= 0 || n >= p.quantity {
+			return &UsageError{Cmd: cmd, Msg: i18n.T("repl.position_range", n, p.quantity-1)}
+		}
+		p.opts.ControlPosition = n
+
+	case "color", "rgb":
+		r, g, b, err := lamp.ParseColorSpec(value)
+		if err != nil {
+			return &UsageError{Cmd: cmd, Msg: i18n.T("repl.bad_color", value)}
+		}
+		p.opts.ControlColor = lamp.FormatColor(int(r), int(g), int(b))
+
+	case "period", "speed":
+		parse := lamp.ParsePeriod
+		if name == "speed" {
+			parse = lamp.ParseSpeed
+		}
+		d, err := parse(value)
+		if err != nil {
+			return &UsageError{Cmd: cmd, Msg: i18n.Error(err)}
+		}
+		p.opts.ControlPeriod = d
+
+	case "fade":
+		d, err := lamp.ParseFade(value)
+		if err != nil {
+			return &UsageError{Cmd: cmd, Msg: i18n.Error(err)}
+		}
+		p.opts.ControlFade = d
+
+	case "quantity":
+		n, err := number(cmd, "quantity", value)
+		if err != nil {
+			return err
+		}
+		if n <= 0 || n > 255 {
+			return &UsageError{Cmd: cmd, Msg: i18n.T("repl.quantity_range", n)}
+		}
+		// 位置或范围放不下时先报错, 不让 run 发到灯带之外
+		if err := p.opts.Validate(n); err != nil {
+			return &UsageError{Cmd: cmd, Msg: i18n.Error(err)}
+		}
+		p.quantity = n
+		return nil
+
+	default:
+		return &UsageError{Cmd: cmd, Msg: i18n.T("repl.unknown_setting", name, strings.Join(settings, ", "))}
+	}
+
+	if err := p.opts.Validate(p.quantity); err != nil {
+		return &UsageError{Cmd: cmd, Msg: i18n.Error(err)}
+	}
+	return nil
+}
+
+// setMode changes the mode of p. A new mode starts at its default speed.
+func (p *plan) setMode(mode int) {
+	if p.opts.ControlMode != mode {
+		p.opts.ControlPeriod = 0
+	}
+	p.opts.ControlMode = mode
+}
+
+func (sh *Shell) set(p *plan, args []string) (step, error) {
+	if len(args) < 2 {
+		return nil, &UsageError{Cmd: "set", Msg: i18n.T("repl.missing_value")}
+	}
+	name, value := args[0], strings.Join(args[1:], " ")
+	// "255 0 0" is r,g,b as well
+	if name == "color" && len(args) == 4 {
+		value = strings.Join(args[1:], ",")
+	}
+	// "1-5 10-12" is 1-5,10-12
+	if name == "ranges" {
+		value = strings.Join(args[1:], ",")
+	}
+
+	if err := p.setting("set", name, value); err != nil {
+		return nil, err
+	}
+
+	if name == "quantity" {
+		n := p.quantity
+		return func() error {
+			if err := sh.lc.SetQuantity(n); err != nil {
+				return &ControlError{Err: err}
+			}
+			i18n.Fprintf(sh.out, "quantity.changed", n)
+			return nil
+		}, nil
+	}
+
+	p.changed = true
+	opts := p.opts
+	return func() error {
+		if err := sh.setOptions(opts); err != nil {
+			return &ControlError{Err: err}
+		}
+		switch name {
+		case "percent":
+			i18n.Fprintf(sh.out, "percent.set", opts.ControlPercentage)
+		case "position":
+			i18n.Fprintf(sh.out, "position.set", opts.ControlPosition)
+		case "ranges":
+			i18n.Fprintf(sh.out, "ranges.set", rangesName(opts.ControlRanges))
+		case "period", "speed":
+			i18n.Fprintf(sh.out, "period.set", periodName(opts))
+		case "fade":
+			i18n.Fprintf(sh.out, "fade.set", fadeName(opts.ControlFade))
+		default:
+			if opts.ControlColor == "0,0,0" {
+				i18n.Fprintf(sh.out, "rgb.black")
+			}
+			i18n.Fprintf(sh.out, "rgb.set", opts.ControlColor)
+		}
+		return nil
+	}, nil
+}
+
+func (sh *Shell) mode(p *plan, args []string) (step, error) {
+	if len(args) != 1 {
+		return nil, &UsageError{Cmd: "mode", Msg: i18n.T("repl.missing_value")}
+	}
+	mode, err := lamp.ParseMode(args[0])
+	if err != nil {
+		return nil, &UsageError{Cmd: "mode", Msg: i18n.T("repl.unknown_mode", args[0])}
+	}
+
+	p.setMode(mode)
+	p.changed = true
+	opts := p.opts
+	return func() error {
+		if err := sh.setOptions(opts); err != nil {
+			return &ControlError{Err: err}
+		}
+		i18n.Fprintf(sh.out, "mode.set", modeName(mode))
+		return nil
+	}, nil
+}
+
+// apply sets the mode and the settings that follow it, then runs them:
+// "single 5 #ff0000", "breathe 50% green". A bare number is the position
+// in single mode and the percentage in the others.
+func (sh *Shell) apply(p *plan, args []string) (step, error) {
+	if len(args) == 0 {
+		return nil, &UsageError{Cmd: "apply", Msg: i18n.T("repl.missing_value")}
+	}
+	mode, err := lamp.ParseMode(args[0])
+	if err != nil {
+		return nil, &UsageError{Cmd: "apply", Msg: i18n.T("repl.unknown_mode", args[0])}
+	}
+	if err := p.applyArgs("apply", mode, args[1:]); err != nil {
+		return nil, err
+	}
+
+	p.sent = true
+	opts := p.opts
+	return func() error {
+		if err := sh.applyOptions(opts); err != nil {
+			return &ControlError{Err: err}
+		}
+		return nil
+	}, nil
+}
+
+// applyArgs sets mode and the settings of an apply line: percent%,
+// ranges, a position in single mode, a color or setting=value.
+func (p *plan) applyArgs(cmd string, mode int, args []string) error {
+	p.setMode(mode)
+
+	for _, arg := range args {
+		name, value, ok := strings.Cut(arg, "=")
+		switch {
+		case ok:
+		case isRange(arg):
+			name = "ranges"
+		case strings.HasSuffix(arg, "%"):
+			name = "percent"
+		case isNumber(arg) && mode == lamp.ModeSingle:
+			name = "position"
+		case isNumber(arg):
+			name = "percent"
+		default:
+			name = "color"
+		}
+		if !ok {
+			value = arg
+		}
+		if err := p.setting(cmd, name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lightRanges lights ranges in a fill mode: "range 10-20 red breathe".
+// Ranges, color and mode may come in any order; the mode stays as it is
+// if it is a fill mode and becomes normal otherwise.
+func (sh *Shell) lightRanges(p *plan, args []string) (step, error) {
+	var ranges []string
+	mode := p.opts.ControlMode
+	if mode == lamp.ModeSingle || mode == lamp.ModeMarquee {
+		mode = lamp.ModeNormal
+	}
+	for _, arg := range args {
+		if m, err := lamp.ParseMode(arg); err == nil {
+			if m == lamp.ModeSingle || m == lamp.ModeMarquee {
+				return nil, &UsageError{Cmd: "range", Msg: i18n.T("repl.range_mode", arg)}
+			}
+			mode = m
+			continue
+		}
+		if isRange(arg) || isNumber(arg) || strings.HasSuffix(arg, "%") {
+			ranges = append(ranges, arg)
+			continue
+		}
+		if err := p.setting("range", "color", arg); err != nil {
+			return nil, err
+		}
+	}
+	if len(ranges) == 0 {
+		return nil, &UsageError{Cmd: "range", Msg: i18n.T("repl.missing_value")}
+	}
+
+	p.setMode(mode)
+	if err := p.setting("range", "ranges", strings.Join(ranges, ",")); err != nil {
+		return nil, err
+	}
+
+	p.sent = true
+	opts := p.opts
+	return func() error {
+		if err := sh.applyOptions(opts); err != nil {
+			return &ControlError{Err: err}
+		}
+		return nil
+	}, nil
+}
+
+// isRange tells ranges such as 10-20, -5 or 1,4 from the other arguments.
+func isRange(s string) bool {
+	return strings.ContainsAny(s, "-,") && strings.Trim(s, "0123456789-,%") == ""
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+func (sh *Shell) exec(p *plan, args []string) (step, error) {
+	p.sent = true
+	return func() error {
+		if err := sh.execOptions(); err != nil {
+			return &ControlError{Err: err}
+		}
+		return nil
+	}, nil
+}
+
+func (sh *Shell) show(p *plan, args []string) (step, error) {
+	return func() error {
+		s := sh.state()
+		i18n.Fprintf(sh.out, "options.current", modeName(s.ControlMode), s.ControlPercentage, rangesName(s.ControlRanges), s.ControlPosition, s.ControlColor, periodName(s.Options), fadeName(s.ControlFade))
+		return nil
+	}, nil
+}
+
+func (sh *Shell) play(p *plan, args []string) (step, error) {
+	if len(args) == 0 {
+		return nil, &UsageError{Cmd: "play", Msg: i18n.T("repl.missing_value")}
+	}
+	// 文件名可以包含空格
+	path := strings.Join(args, " ")
+
+	seq, err := scene.Load(path)
+	if err != nil {
+		return nil, &UsageError{Msg: i18n.T("scene.error", err)}
+	}
+
+	return func() error {
+		err := sh.playScene(seq, func(err error) {
+			if err != nil {
+				i18n.Fprintf(sh.out, "scene.stopped", seq.Name, err)
+			}
+		})
+		if err != nil {
+			return &ControlError{Err: err}
+		}
+		i18n.Fprintf(sh.out, "scene.playing", seq.Name)
+		return nil
+	}, nil
+}
+
+func (sh *Shell) preset(p *plan, args []string) (step, error) {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	name := strings.Join(args[1:], " ")
+	if args[0] != "list" && name == "" {
+		return nil, &UsageError{Cmd: "preset", Msg: i18n.T("repl.missing_name")}
+	}
+
+	switch args[0] {
+	case "list":
+		return func() error {
+			sh.showPresets()
+			return nil
+		}, nil
+	case "save":
+		return func() error {
+			pr := preset.FromOptions(name, sh.state().Options)
+			if old, ok := sh.presets.Get(name); ok {
+				pr.Description = old.Description
+			}
+			if err := sh.presets.Save(pr); err != nil {
+				return &UsageError{Msg: i18n.T("preset.save_failed", err)}
+			}
+			i18n.Fprintf(sh.out, "preset.saved", name, describe(pr))
+			return nil
+		}, nil
+	case "delete":
+		return func() error {
+			if err := sh.presets.Delete(name); err != nil {
+				return &UsageError{Msg: i18n.T("preset.delete_failed", err)}
+			}
+			i18n.Fprintf(sh.out, "preset.deleted", name)
+			return nil
+		}, nil
+	case "run":
+		pr, ok := sh.presets.Get(name)
+		if !ok {
+			return nil, &UsageError{Msg: i18n.T("preset.not_found", name)}
+		}
+		opts, err := pr.Options()
+		if err == nil {
+			err = opts.Validate(p.quantity)
+		}
+		if err != nil {
+			return nil, &UsageError{Msg: i18n.Error(err)}
+		}
+		// 预设没有指定渐变时沿用当前的
+		if opts.ControlFade == 0 {
+			opts.ControlFade = p.opts.ControlFade
+		}
+		p.opts = opts
+		p.sent = true
+		return func() error {
+			if err := sh.applyOptions(opts); err != nil {
+				return &ControlError{Err: err}
+			}
+			return nil
+		}, nil
+	}
+	return nil, &UsageError{Cmd: "preset", Msg: i18n.T("repl.unknown_subcommand", args[0])}
+}
+
+func (sh *Shell) help(p *plan, args []string) (step, error) {
+	if len(args) == 0 {
+		return func() error {
+			sh.ShowHelp()
+			return nil
+		}, nil
+	}
+
+	c := lookup(args[0])
+	if c == nil {
+		return nil, &UsageError{Msg: i18n.T("repl.unknown_command", args[0])}
+	}
+	return func() error {
+		fmt.Fprintf(sh.out, "%s\n\t%s\n", usage(c.name), i18n.T("help.cmd."+c.name))
+		if len(c.aliases) > 0 {
+			i18n.Fprintf(sh.out, "help.aliases", strings.Join(c.aliases, ", "))
+		}
+		return nil
+	}, nil
+}
+
+// ShowHelp prints the presets and the commands.
+func (sh *Shell) ShowHelp() {
+	i18n.Fprintf(sh.out, "help.presets")
+	sh.showPresets()
+	i18n.Fprintf(sh.out, "help.commands")
+	for _, c := range commands {
+		fmt.Fprintf(sh.out, "\t%-56s%s\n", usage(c.name), i18n.T("help.cmd."+c.name))
+	}
+	i18n.Fprintf(sh.out, "help.short_forms")
+}
+
+func (sh *Shell) showPresets() {
+	for _, p := range sh.presets.List() {
+		fmt.Fprintf(sh.out, "\t  %-8s\t\t\t%s\n", p.Name, describe(p))
+	}
+}
+
+// describe returns the preset's description, or a summary of its options.
+func describe(p preset.Preset) string {
+	if p.Description != "" {
+		return p.Description
+	}
+
+	opts, err := p.Options()
+	if err != nil {
+		return i18n.Error(err)
+	}
+	if opts.ControlPeriod != 0 && opts.Period() != 0 {
+		return i18n.T("describe.period", summary(opts), lamp.FormatPeriod(opts.Period()))
+	}
+	return summary(opts)
+}
+
+// summary sums up opts in a few words.
+func summary(opts lamp.Options) string {
+	if opts.ControlMode == lamp.ModeSingle {
+		return i18n.T("describe.single", modeName(opts.ControlMode), opts.ControlPosition, opts.ControlColor)
+	}
+	if opts.ControlRanges != "" {
+		return i18n.T("describe.ranges", modeName(opts.ControlMode), opts.ControlRanges, opts.ControlColor)
+	}
+	if r, g, b, _ := lamp.ParseColor(opts.ControlColor); r == 0 && g == 0 && b == 0 {
+		return i18n.T("describe.off")
+	} else if name := colorName(r, g, b); name != "" && opts.ControlPercentage == 100 {
+		return i18n.T("describe.named", modeName(opts.ControlMode), name)
+	}
+	return i18n.T("describe.fill", modeName(opts.ControlMode), opts.ControlPercentage, opts.ControlColor)
+}
+
+// colorName 返回只有一个通道亮的颜色的名称
+func colorName(r, g, b byte) string {
+	switch {
+	case r > 0 && g == 0 && b == 0:
+		return i18n.T("color.red")
+	case r == 0 && g > 0 && b == 0:
+		return i18n.T("color.green")
+	case r == 0 && g == 0 && b > 0:
+		return i18n.T("color.blue")
+	}
+	return ""
+}
+
+// periodName shows the period of the mode with its frequency, and
+// whether it is the default.
+func periodName(o lamp.Options) string {
+	d := o.Period()
+	if d == 0 {
+		return i18n.T("period.none")
+	}
+	hz := strconv.FormatFloat(float64(time.Second)/float64(d), 'g', 3, 64)
+	if o.ControlPeriod == 0 {
+		return i18n.T("period.default", lamp.FormatPeriod(d), hz)
+	}
+	return i18n.T("period.value", lamp.FormatPeriod(d), hz)
+}
+
+// fadeName shows the fade time, or that changes cut at once.
+func fadeName(d time.Duration) string {
+	if d == 0 {
+		return i18n.T("fade.off")
+	}
+	return lamp.FormatPeriod(d)
+}
+
+// rangesName shows ranges, or that the fill modes use the percentage.
+func rangesName(ranges string) string {
+	if ranges == "" {
+		return i18n.T("ranges.none")
+	}
+	return ranges
+}
+
+func modeName(mode int) string {
+	name := lamp.ModeName(mode)
+	if _, err := lamp.ParseMode(name); err != nil {
+		name = "unknown"
+	}
+	return i18n.T("mode." + name)
+}
@@ -0,0 +1,142 @@
+package repl
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"lampwith-tag/alert"
+	"lampwith-tag/lamp"
+)
+
+// Complete returns the lines that complete line, for tab completion. It
+// completes the last word of the last command: commands and presets first,
+// then the arguments of the command.
+func (sh *Shell) Complete(line string) []string {
+	parts := splitStatements(line)
+	stmt := parts[len(parts)-1]
+	prefix := line[:len(line)-len(stmt)]
+
+	var lines []string
+	for _, l := range sh.complete(stmt) {
+		lines = append(lines, prefix+l)
+	}
+	return lines
+}
+
+func (sh *Shell) complete(line string) []string {
+	words, err := Split(line)
+	if err != nil {
+		return nil
+	}
+	// a trailing space starts a new word
+	if len(words) == 0 || strings.HasSuffix(line, " ") {
+		words = append(words, "")
+	}
+	last := words[len(words)-1]
+	head := line[:len(line)-len(last)]
+
+	var candidates []string
+	switch n := len(words); {
+	case n == 1:
+		for _, c := range commands {
+			candidates = append(candidates, c.name)
+			candidates = append(candidates, c.aliases...)
+		}
+		candidates = append(candidates, modeNames()...)
+		candidates = append(candidates, sh.presetNames()...)
+
+	case words[0] == "apply" && n == 2:
+		candidates = modeNames()
+	case words[0] == "apply" || isMode(words[0]):
+		candidates = lamp.ColorNames()
+
+	case words[0] == "range":
+		candidates = append(lamp.ColorNames(), "normal", "breathe", "strobe")
+	case words[0] == "set" && n == 2:
+		candidates = settings
+	case words[0] == "set" && n == 3 && words[1] == "color":
+		candidates = lamp.ColorNames()
+	case words[0] == "mode" && n == 2:
+		candidates = modeNames()
+	case (words[0] == "help" || words[0] == "h") && n == 2:
+		for _, c := range commands {
+			candidates = append(candidates, c.name)
+		}
+	case words[0] == "preset" && n == 2:
+		candidates = []string{"save", "list", "delete", "run"}
+	case words[0] == "preset" && n == 3 && words[1] != "list":
+		candidates = sh.presetNames()
+	case words[0] == "schedule" && n == 2:
+		candidates = []string{"list", "pause", "resume", "skip", "run"}
+	case words[0] == "schedule" && n == 3 && sh.sched != nil:
+		for _, e := range sh.sched.List(time.Now()) {
+			candidates = append(candidates, e.Rule.Name)
+		}
+	case words[0] == "layer" && n == 2:
+		candidates = []string{"list", "clear", "remove"}
+		if sh.layers != nil {
+			for _, l := range sh.layers.Layers() {
+				candidates = append(candidates, l.Name)
+			}
+		}
+	case words[0] == "layer" && n == 3 && words[1] != "remove":
+		candidates = append(modeNames(), "play")
+	case words[0] == "layer" && n == 3 && sh.layers != nil:
+		for _, l := range sh.layers.Layers() {
+			candidates = append(candidates, l.Name)
+		}
+	case words[0] == "layer" && n > 3 && words[2] == "play":
+		candidates, _ = filepath.Glob(last + "*")
+	case words[0] == "layer" && n > 3:
+		candidates = append(lamp.ColorNames(), "blend=replace", "blend=add", "blend=multiply")
+	case words[0] == "alert" && n == 2:
+		candidates = []string{"list", "clear"}
+		for _, l := range alert.Levels {
+			candidates = append(candidates, l.String())
+		}
+	case words[0] == "alert" && n == 3 && words[1] == "clear" && sh.alerts != nil:
+		for _, a := range sh.alerts.Active() {
+			candidates = append(candidates, a.Source)
+		}
+	case words[0] == "follow" && n == 2:
+		candidates, _ = filepath.Glob(last + "*")
+	case words[0] == "follow":
+		candidates = append(lamp.ColorNames(), "brightness", "color", "curve=linear", "curve=log")
+	case words[0] == "gauge":
+		candidates = []string{"value=", "min=", "max=", "reverse", "forward", "ease="}
+	case words[0] == "play" && n == 2:
+		candidates, _ = filepath.Glob(last + "*")
+	}
+
+	var lines []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, last) {
+			lines = append(lines, head+c)
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func (sh *Shell) presetNames() []string {
+	var names []string
+	for _, p := range sh.presets.List() {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func isMode(name string) bool {
+	_, err := lamp.ParseMode(name)
+	return err == nil
+}
+
+func modeNames() []string {
+	var names []string
+	for m := lamp.ModeNormal; m <= lamp.ModeMarquee; m++ {
+		names = append(names, lamp.ModeName(m))
+	}
+	return names
+}
@@ -0,0 +1,130 @@
+package repl
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"lampwith-tag/i18n"
+	"lampwith-tag/lamp"
+	"lampwith-tag/meter"
+)
+
+// follow drives the strip from a signal until the next command that sends
+// or stop: "follow song.wav red curve=log", "follow
+// http://plc/metrics#line_rate color green red max=120 every=2s 1-30".
+// The console reads its commands from stdin, so stdin signals are for the
+// -follow flag.
+func (sh *Shell) follow(p *plan, args []string) (step, error) {
+	if len(args) == 0 {
+		return nil, &UsageError{Cmd: "follow", Msg: i18n.T("repl.missing_value")}
+	}
+	spec := args[0]
+	if spec == "-" || spec == "stdin" || spec == "pcm" || strings.HasPrefix(spec, "pcm:") {
+		return nil, &UsageError{Msg: i18n.T("repl.follow_stdin")}
+	}
+
+	f := lamp.Follow{}
+	curve := meter.DefaultCurve
+	every := time.Second
+	var colors []lamp.Color
+	var err error
+	for _, arg := range args[1:] {
+		k, v, ok := strings.Cut(arg, "=")
+		switch {
+		case k == "min" || k == "max":
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, &UsageError{Cmd: "follow", Msg: i18n.T("repl.bad_number", k, v)}
+			}
+			if k == "min" {
+				curve.Min = n
+			} else {
+				curve.Max = n
+			}
+		case k == "curve":
+			if err := curve.ParseShape(v); err != nil {
+				return nil, &UsageError{Cmd: "follow", Msg: i18n.Error(err)}
+			}
+		case k == "every":
+			if every, err = time.ParseDuration(v); err != nil || every <= 0 {
+				return nil, &UsageError{Cmd: "follow", Msg: i18n.T("repl.bad_every", v)}
+			}
+		case ok:
+			return nil, &UsageError{Cmd: "follow", Msg: i18n.T("repl.unknown_setting", k, "min, max, curve, every")}
+		case arg == "brightness" || arg == "color":
+			f.Style, _ = lamp.ParseFollowStyle(arg)
+		case isRange(arg) || isNumber(arg):
+			if _, err := lamp.ParseRanges(arg, p.quantity); err != nil {
+				return nil, &UsageError{Cmd: "follow", Msg: i18n.Error(err)}
+			}
+			f.Ranges = arg
+		default:
+			r, g, b, err := lamp.ParseColorSpec(arg)
+			if err != nil {
+				return nil, &UsageError{Cmd: "follow", Msg: i18n.T("repl.bad_color", arg)}
+			}
+			colors = append(colors, lamp.Color{R: r, G: g, B: b})
+		}
+	}
+	if curve.Min == curve.Max {
+		return nil, &UsageError{Cmd: "follow", Msg: i18n.T("repl.bad_span", curve.Min)}
+	}
+
+	// 亮度跟随默认用当前颜色, 颜色跟随默认从绿到红
+	switch {
+	case len(colors) > 2:
+		return nil, &UsageError{Cmd: "follow", Msg: i18n.T("repl.extra_args")}
+	case f.Style == lamp.FollowColor:
+		f.Color, f.To = lamp.Color{G: 255}, lamp.Color{R: 255}
+		if len(colors) > 0 {
+			f.Color = colors[0]
+		}
+		if len(colors) > 1 {
+			f.To = colors[1]
+		}
+	case len(colors) == 1:
+		f.Color = colors[0]
+	case len(colors) > 1:
+		return nil, &UsageError{Cmd: "follow", Msg: i18n.T("repl.extra_args")}
+	default:
+		r, g, b, _ := lamp.ParseColorSpec(p.opts.ControlColor)
+		f.Color = lamp.Color{R: r, G: g, B: b}
+	}
+
+	p.sent = true
+	return func() error {
+		src, err := meter.Open(spec, nil, every)
+		if err != nil {
+			return &UsageError{Msg: i18n.T("follow.error", err)}
+		}
+		f.Level = func() float64 { return curve.Map(src.Value()) }
+		f.Done = src.Done()
+		ended := make(chan struct{})
+		f.Stopped = func() {
+			src.Close()
+			close(ended)
+		}
+		if err := sh.lc.Follow(f); err != nil {
+			src.Close()
+			return &ControlError{Err: err}
+		}
+		// 效果启动后才记录, 失败的 follow 不会一直占用灯带
+		sh.following = ended
+		i18n.Fprintf(sh.out, "follow.started", spec)
+		return nil
+	}, nil
+}
+
+// isFollowing reports whether a follow command drives the strip.
+func (sh *Shell) isFollowing() bool {
+	if sh.following == nil {
+		return false
+	}
+	select {
+	case <-sh.following:
+		return false
+	default:
+		return true
+	}
+}
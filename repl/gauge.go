@@ -0,0 +1,131 @@
+package repl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"lampwith-tag/i18n"
+	"lampwith-tag/lamp"
+)
+
+// SetGauge lets the gauge command show values on g.
+func (sh *Shell) SetGauge(g *lamp.Gauge) {
+	sh.gauge = g
+}
+
+// feedGauge moves the gauge to a value and changes its settings: "gauge
+// 73", "gauge value=73 min=0 max=100 reverse 0:green 70:amber 90:red
+// ease=1s". Without arguments it prints the gauge.
+func (sh *Shell) feedGauge(p *plan, args []string) (step, error) {
+	if sh.gauge == nil {
+		return nil, &UsageError{Msg: i18n.T("repl.no_gauge")}
+	}
+	if len(args) == 0 {
+		return func() error {
+			sh.showGauge()
+			return nil
+		}, nil
+	}
+
+	s := sh.gauge.Settings()
+	var (
+		value      float64
+		hasValue   bool
+		thresholds []lamp.Threshold
+	)
+	parse := func(name, v string) (float64, error) {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, &UsageError{Cmd: "gauge", Msg: i18n.T("repl.bad_number", name, v)}
+		}
+		return f, nil
+	}
+	for _, arg := range args {
+		k, v, ok := strings.Cut(arg, "=")
+		var err error
+		switch {
+		case !ok && isFloat(arg):
+			value, err = parse("value", arg)
+			hasValue = true
+		case k == "value":
+			value, err = parse("value", v)
+			hasValue = true
+		case k == "min":
+			s.Min, err = parse("min", v)
+		case k == "max":
+			s.Max, err = parse("max", v)
+		case k == "ease":
+			if s.Ease, err = lamp.ParseFade(v); err != nil {
+				err = &UsageError{Cmd: "gauge", Msg: i18n.Error(err)}
+			}
+		case ok:
+			err = &UsageError{Cmd: "gauge", Msg: i18n.T("repl.unknown_setting", k, "value, min, max, ease")}
+		case arg == "reverse" || arg == "forward":
+			s.Reverse = arg == "reverse"
+		default:
+			// 70:amber 表示从 70 起显示琥珀色
+			at, spec, ok := strings.Cut(arg, ":")
+			f, perr := strconv.ParseFloat(at, 64)
+			r, g, b, cerr := lamp.ParseColorSpec(spec)
+			if !ok || perr != nil || cerr != nil {
+				return nil, &UsageError{Cmd: "gauge", Msg: i18n.T("repl.bad_threshold", arg)}
+			}
+			thresholds = append(thresholds, lamp.Threshold{At: f, Color: lamp.Color{R: r, G: g, B: b}})
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if thresholds != nil {
+		s.Thresholds = thresholds
+	}
+	if err := s.Validate(); err != nil {
+		return nil, &UsageError{Cmd: "gauge", Msg: i18n.Error(err)}
+	}
+
+	if hasValue {
+		p.sent = true
+	}
+	return func() error {
+		if err := sh.gauge.Configure(s); err != nil {
+			return &ControlError{Err: err}
+		}
+		if !hasValue {
+			i18n.Fprintf(sh.out, "gauge.configured")
+			return nil
+		}
+		// 仪表接管灯带, 场景不再继续
+		sh.stopScene()
+		if err := sh.gauge.Set(value); err != nil {
+			return &ControlError{Err: err}
+		}
+		return nil
+	}, nil
+}
+
+func (sh *Shell) showGauge() {
+	s := sh.gauge.Settings()
+	dir := i18n.T("gauge.forward")
+	if s.Reverse {
+		dir = i18n.T("gauge.reverse")
+	}
+	i18n.Fprintf(sh.out, "gauge.current", formatFloat(sh.gauge.Value()), formatFloat(s.Min), formatFloat(s.Max), dir, fadeName(s.Ease))
+
+	th := s.Thresholds
+	if len(th) == 0 {
+		th = lamp.DefaultThresholds(s.Min, s.Max)
+	}
+	for _, t := range th {
+		fmt.Fprintf(sh.out, "\t%-8s %s\n", formatFloat(t.At), lamp.FormatColor(int(t.Color.R), int(t.Color.G), int(t.Color.B)))
+	}
+}
+
+func isFloat(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
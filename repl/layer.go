@@ -0,0 +1,190 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"lampwith-tag/i18n"
+	"lampwith-tag/lamp"
+	"lampwith-tag/scene"
+)
+
+// SetLayers lets the layer command stack layers on c.
+func (sh *Shell) SetLayers(c *lamp.Compositor) {
+	sh.layers = c
+	sh.layerPlayers = map[string]*scene.Player{}
+}
+
+// layer lists the layers, sets one, plays a scene on one or removes them:
+// "layer pick breathe 10-12 white blend=add opacity=60%", "layer bg play
+// scenes/andon.json", "layer remove pick".
+func (sh *Shell) layer(p *plan, args []string) (step, error) {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	if sh.layers == nil {
+		return nil, &UsageError{Msg: i18n.T("repl.no_layers")}
+	}
+
+	switch args[0] {
+	case "list":
+		return func() error {
+			sh.showLayers(sh.out)
+			return nil
+		}, nil
+	case "clear":
+		p.sent = true
+		return func() error {
+			for name := range sh.layerPlayers {
+				sh.stopLayerPlayer(name)
+			}
+			sh.layers.Clear()
+			i18n.Fprintf(sh.out, "layer.cleared")
+			return nil
+		}, nil
+	case "remove":
+		if len(args) < 2 {
+			return nil, &UsageError{Cmd: "layer", Msg: i18n.T("repl.missing_layer")}
+		}
+		name := args[1]
+		if _, ok := sh.layers.Layer(name); !ok {
+			return nil, &UsageError{Msg: i18n.T("repl.unknown_layer", name)}
+		}
+		p.sent = true
+		return func() error {
+			sh.stopLayerPlayer(name)
+			sh.layers.Remove(name)
+			i18n.Fprintf(sh.out, "layer.removed", name)
+			return nil
+		}, nil
+	}
+
+	name := args[0]
+	if len(args) < 2 {
+		return nil, &UsageError{Cmd: "layer", Msg: i18n.T("repl.missing_value")}
+	}
+	if args[1] == "play" {
+		return sh.playLayer(p, name, args[2:])
+	}
+
+	mode, err := lamp.ParseMode(args[1])
+	if err != nil {
+		return nil, &UsageError{Cmd: "layer", Msg: i18n.T("repl.unknown_mode", args[1])}
+	}
+
+	// 新图层从当前设置开始, 不透明, 放在最上面
+	l, ok := sh.layers.Layer(name)
+	if !ok {
+		l = lamp.Layer{Name: name, Options: p.opts, Opacity: 1}
+		for _, o := range sh.layers.Layers() {
+			l.Z = max(l.Z, o.Z)
+		}
+	}
+	var rest []string
+	for _, arg := range args[2:] {
+		k, v, _ := strings.Cut(arg, "=")
+		switch k {
+		case "blend":
+			if l.Blend, err = lamp.ParseBlend(v); err != nil {
+				return nil, &UsageError{Cmd: "layer", Msg: i18n.Error(err)}
+			}
+		case "opacity":
+			if l.Opacity, err = parseOpacity(v); err != nil {
+				return nil, &UsageError{Cmd: "layer", Msg: i18n.T("repl.bad_opacity", v)}
+			}
+		case "z":
+			if l.Z, err = number("layer", "z", v); err != nil {
+				return nil, err
+			}
+		case "quantity":
+			return nil, &UsageError{Cmd: "layer", Msg: i18n.T("repl.unknown_setting", k, strings.Join(settings, ", "))}
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	lp := &plan{opts: l.Options, quantity: p.quantity}
+	if err := lp.applyArgs("layer", mode, rest); err != nil {
+		return nil, err
+	}
+	l.Options = lp.opts
+
+	p.sent = true
+	return func() error {
+		sh.stopLayerPlayer(name)
+		if err := sh.layers.Set(l); err != nil {
+			return &ControlError{Err: err}
+		}
+		i18n.Fprintf(sh.out, "layer.set", name)
+		return nil
+	}, nil
+}
+
+// playLayer plays a scene on the layer called name.
+func (sh *Shell) playLayer(p *plan, name string, args []string) (step, error) {
+	if len(args) == 0 {
+		return nil, &UsageError{Cmd: "layer", Msg: i18n.T("repl.missing_value")}
+	}
+	seq, err := scene.Load(strings.Join(args, " "))
+	if err != nil {
+		return nil, &UsageError{Msg: i18n.T("scene.error", err)}
+	}
+
+	p.sent = true
+	return func() error {
+		pl := sh.layerPlayers[name]
+		if pl == nil {
+			pl = scene.NewPlayer(sh.layers.Target(name))
+			sh.layerPlayers[name] = pl
+		}
+		pl.Play(seq, func(err error) {
+			if err != nil {
+				i18n.Fprintf(sh.out, "scene.stopped", seq.Name, err)
+			}
+		})
+		i18n.Fprintf(sh.out, "layer.playing", seq.Name, name)
+		return nil
+	}, nil
+}
+
+func (sh *Shell) stopLayerPlayer(name string) {
+	if pl := sh.layerPlayers[name]; pl != nil {
+		pl.Stop()
+		delete(sh.layerPlayers, name)
+	}
+}
+
+// parseOpacity parses 60% or 0.6.
+func parseOpacity(s string) (float64, error) {
+	div := 1.0
+	if t, ok := strings.CutSuffix(s, "%"); ok {
+		s, div = t, 100
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	// NaN 不小于 0 也不大于 1, 要单独拒绝
+	if err != nil || math.IsNaN(f) || f/div < 0 || f/div > 1 {
+		return 0, fmt.Errorf("opacity %q out of range", s)
+	}
+	return f / div, nil
+}
+
+// showLayers prints the layers, the top one first.
+func (sh *Shell) showLayers(w io.Writer) {
+	layers := sh.layers.Layers()
+	if len(layers) == 0 {
+		i18n.Fprintf(w, "layer.none")
+		return
+	}
+
+	i18n.Fprintf(w, "layer.header")
+	for i := len(layers) - 1; i >= 0; i-- {
+		l := layers[i]
+		what := summary(l.Options)
+		if pl := sh.layerPlayers[l.Name]; pl != nil && pl.Playing() != "" {
+			what = i18n.T("layer.scene", pl.Playing())
+		}
+		fmt.Fprintf(w, "\t%-12s z=%-4d %-8s %3.0f%%  %s\n", l.Name, l.Z, l.Blend, l.Opacity*100, what)
+	}
+}
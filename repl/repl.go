@@ -0,0 +1,276 @@
+// Package repl is the console's command language.
+//
+// A line is split into words at spaces; double or single quotes keep
+// spaces inside a word. The first word names the command:
+//
+//	set color red        set percent 20        set position 5
+//	mode breathe         set period 2s         set speed 4Hz
+//	run                  show                  help set
+//	play "my scene.json" preset save warm
+//
+// The short forms of earlier versions still work: sma to sme, percent=20,
+// position=5, rgb=255,0,0, option and exec, and so do period=2s,
+// speed=4Hz and fade=500ms. A word that is not a command runs the preset
+// of that name.
+package repl
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"lampwith-tag/alert"
+	"lampwith-tag/i18n"
+	"lampwith-tag/lamp"
+	"lampwith-tag/preset"
+	"lampwith-tag/scene"
+	"lampwith-tag/schedule"
+)
+
+// ErrQuit is returned by Exec for the quit command.
+var ErrQuit = errors.New("quit")
+
+// UsageError is a line that does not fit the grammar of its command.
+type UsageError struct {
+	// Cmd is the command whose usage applies, or "" for an unknown one.
+	Cmd string
+	Msg string
+}
+
+func (e *UsageError) Error() string {
+	if e.Cmd == "" {
+		return e.Msg
+	}
+	return e.Msg + "\n" + i18n.T("repl.usage", usage(e.Cmd))
+}
+
+// ControlError is a command the strip did not take.
+type ControlError struct {
+	Err error
+}
+
+func (e *ControlError) Error() string { return i18n.T("repl.control_error", e.Err) }
+
+func (e *ControlError) Unwrap() error { return e.Err }
+
+// Shell runs command lines against one strip.
+type Shell struct {
+	lc      *lamp.LampWithClient
+	presets *preset.Store
+	player  *scene.Player
+	out     io.Writer
+
+	sched  *schedule.Scheduler
+	runner *schedule.Runner
+	alerts *alert.Manager
+
+	layers *lamp.Compositor
+	// layerPlayers plays scenes on layers, by layer name.
+	layerPlayers map[string]*scene.Player
+
+	// following is closed when the effect of the last follow ends.
+	following chan struct{}
+	gauge     *lamp.Gauge
+}
+
+// New returns a shell printing to out.
+func New(lc *lamp.LampWithClient, presets *preset.Store, player *scene.Player, out io.Writer) *Shell {
+	return &Shell{lc: lc, presets: presets, player: player, out: out}
+}
+
+// Exec runs one line. Commands separated by ';' run in order, but only
+// after the whole line has been checked, so a mistake anywhere sends
+// nothing. It returns ErrQuit for quit, a *UsageError for a line it cannot
+// parse and a *ControlError when the strip fails.
+func (sh *Shell) Exec(line string) error {
+	stmts, err := Statements(line)
+	if err != nil {
+		return &UsageError{Msg: i18n.Error(err)}
+	}
+
+	st := sh.state()
+	p := &plan{opts: st.Options, quantity: st.Quantity}
+	var (
+		steps      []step
+		interrupts bool
+	)
+	for _, words := range stmts {
+		words = sh.rewrite(words)
+
+		cmd := lookup(words[0])
+		if cmd == nil {
+			return &UsageError{Msg: i18n.T("repl.unknown_command", words[0])}
+		}
+		s, err := cmd.parse(sh, p, words[1:])
+		if err != nil {
+			return err
+		}
+		steps = append(steps, s)
+		interrupts = interrupts || cmd.interrupts(words[1:])
+	}
+	if len(steps) == 0 {
+		return nil
+	}
+
+	// 场景播放时只有 stop 和直接控制的命令会打断它
+	if interrupts {
+		sh.stopScene()
+		// 报警显示时跟随信号或仪表在等待, 不再在清除后重新开始
+		if sh.alerts != nil && sh.alerts.Showing() {
+			sh.lc.Drop()
+		} else if sh.isFollowing() || sh.gauge != nil && sh.gauge.Showing() {
+			sh.lc.StopMarquee()
+		}
+	}
+	// 图层、仪表或跟随信号占用灯带时, 只有直接控制的命令会打断它
+	if sh.player.Playing() == "" && !sh.holdsStrip() {
+		sh.lc.StopMarquee()
+	}
+
+	for _, s := range steps {
+		if err := s(); err != nil {
+			return err
+		}
+	}
+	if p.changed && !p.sent {
+		i18n.Fprintf(sh.out, "hint.option_exec")
+	}
+	return nil
+}
+
+// holdsStrip reports whether an alert, layers, the gauge or a follow run
+// on the strip until a command takes it over.
+func (sh *Shell) holdsStrip() bool {
+	return sh.isFollowing() ||
+		sh.alerts != nil && sh.alerts.Showing() ||
+		sh.layers != nil && sh.layers.Showing() ||
+		sh.gauge != nil && sh.gauge.Showing()
+}
+
+// plan is the strip as the commands parsed so far of a line leave it.
+type plan struct {
+	opts     lamp.Options
+	quantity int
+	// changed is set by commands that change options, sent by commands
+	// that send them.
+	changed, sent bool
+}
+
+// step runs a parsed command.
+type step func() error
+
+// rewrite turns the short forms and preset names into commands.
+func (sh *Shell) rewrite(words []string) []string {
+	// single 5 red 是 apply single 5 red 的简写
+	if _, err := lamp.ParseMode(words[0]); err == nil {
+		return append([]string{"apply"}, words...)
+	}
+
+	if len(words) == 1 {
+		switch w := words[0]; w {
+		case "sma", "smb", "smc", "smd", "sme":
+			return []string{"mode", lamp.ModeName(lamp.ModeNormal + int(w[2]-'a'))}
+		}
+		if lookup(words[0]) == nil {
+			if _, ok := sh.presets.Get(words[0]); ok {
+				return []string{"preset", "run", words[0]}
+			}
+		}
+	}
+
+	// percent=20 and friends; spaces around = never mattered
+	if k, v, ok := strings.Cut(strings.Join(words, ""), "="); ok {
+		switch k {
+		case "percent", "position", "period", "speed", "fade":
+			return []string{"set", k, v}
+		case "rgb":
+			return []string{"set", "color", v}
+		}
+	}
+
+	return words
+}
+
+// Statements breaks a line into commands at each ';' outside quotes and
+// each command into words. Empty commands are dropped.
+func Statements(line string) ([][]string, error) {
+	var stmts [][]string
+	for _, part := range splitStatements(line) {
+		words, err := Split(part)
+		if err != nil {
+			return nil, err
+		}
+		if len(words) > 0 {
+			stmts = append(stmts, words)
+		}
+	}
+	return stmts, nil
+}
+
+// splitStatements cuts line at each ';' outside quotes.
+func splitStatements(line string) []string {
+	var (
+		parts []string
+		quote rune
+		start int
+	)
+	for i, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ';':
+			parts = append(parts, line[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, line[start:])
+}
+
+// Split breaks a command into words. Quotes group words and are removed.
+func Split(line string) ([]string, error) {
+	var (
+		words []string
+		word  strings.Builder
+		quote rune
+		in    bool
+	)
+	for _, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote, in = r, true
+		case r == ' ' || r == '\t':
+			if in {
+				words = append(words, word.String())
+				word.Reset()
+				in = false
+			}
+		default:
+			word.WriteRune(r)
+			in = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New(i18n.T("repl.unclosed_quote", string(quote)))
+	}
+	if in {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// number parses the value of setting name.
+func number(cmd, name, s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, &UsageError{Cmd: cmd, Msg: i18n.T("repl.not_a_number", name, s)}
+	}
+	return n, nil
+}
@@ -0,0 +1,131 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"lampwith-tag/i18n"
+	"lampwith-tag/schedule"
+)
+
+// SetSchedule lets the schedule command list and override the rules of
+// s, running them with r.
+func (sh *Shell) SetSchedule(s *schedule.Scheduler, r *schedule.Runner) {
+	sh.sched, sh.runner = s, r
+}
+
+// schedule lists the rules, pauses, resumes or skips them, or runs one
+// now: "schedule pause morning", "schedule resume".
+func (sh *Shell) schedule(p *plan, args []string) (step, error) {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	if sh.sched == nil {
+		return nil, &UsageError{Msg: i18n.T("repl.no_schedule")}
+	}
+	name := ""
+	if len(args) > 1 {
+		name = args[1]
+		if _, ok := sh.sched.Rule(name); !ok {
+			return nil, &UsageError{Msg: i18n.T("repl.unknown_rule", name)}
+		}
+	}
+
+	switch args[0] {
+	case "list":
+		return func() error {
+			ShowSchedule(sh.out, sh.sched, time.Now())
+			return nil
+		}, nil
+	case "pause", "resume":
+		return func() error {
+			if args[0] == "pause" {
+				sh.sched.Pause(name)
+				i18n.Fprintf(sh.out, "schedule.paused", ruleName(name))
+			} else {
+				sh.sched.Resume(name)
+				i18n.Fprintf(sh.out, "schedule.resumed", ruleName(name))
+			}
+			return nil
+		}, nil
+	}
+
+	// skip 和 run 需要规则名
+	if name == "" {
+		return nil, &UsageError{Cmd: "schedule", Msg: i18n.T("repl.missing_rule")}
+	}
+	switch args[0] {
+	case "skip":
+		return func() error {
+			sh.sched.Skip(name, true)
+			for _, e := range sh.sched.List(time.Now()) {
+				if e.Rule.Name == name {
+					i18n.Fprintf(sh.out, "schedule.skipped", name, when(e.Next))
+				}
+			}
+			return nil
+		}, nil
+	case "run":
+		rule, _ := sh.sched.Rule(name)
+		p.sent = true
+		return func() error {
+			if err := sh.runner.Fire(schedule.Action{Rule: rule, At: time.Now()}); err != nil {
+				return &ControlError{Err: err}
+			}
+			i18n.Fprintf(sh.out, "schedule.ran", name)
+			return nil
+		}, nil
+	}
+	return nil, &UsageError{Cmd: "schedule", Msg: i18n.T("repl.unknown_subcommand", args[0])}
+}
+
+// ShowSchedule prints the rules of s and when they fire next after now.
+func ShowSchedule(w io.Writer, s *schedule.Scheduler, now time.Time) {
+	entries := s.List(now)
+	if len(entries) == 0 {
+		i18n.Fprintf(w, "schedule.none")
+		return
+	}
+
+	i18n.Fprintf(w, "schedule.header")
+	for _, e := range entries {
+		times := e.Rule.When
+		if e.Rule.Until != "" {
+			times += " → " + e.Rule.Until
+		}
+		what := i18n.T("schedule.preset", e.Rule.Preset)
+		if e.Rule.Scene != "" {
+			what = i18n.T("schedule.scene", e.Rule.Scene)
+		}
+
+		var next string
+		switch {
+		case e.Paused:
+			next = i18n.T("schedule.is_paused")
+		case e.Skip:
+			next = i18n.T("schedule.skips", when(e.Next))
+		case !e.End.IsZero():
+			next = i18n.T("schedule.window", when(e.Next), when(e.End))
+		default:
+			next = i18n.T("schedule.next", when(e.Next))
+		}
+		fmt.Fprintf(w, "\t%-12s %-28s %-20s %s\n", e.Rule.Name, times, what, next)
+	}
+}
+
+// when shows a time the schedule fires.
+func when(t time.Time) string {
+	if t.IsZero() {
+		return i18n.T("schedule.never")
+	}
+	return t.Format("Mon 01-02 15:04")
+}
+
+// ruleName is name, or all rules for "".
+func ruleName(name string) string {
+	if name == "" {
+		return i18n.T("schedule.all")
+	}
+	return name
+}
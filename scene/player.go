@@ -0,0 +1,159 @@
+package scene
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"lampwith-tag/lamp"
+)
+
+// Target is what a player plays on: a strip, or a layer of a compositor
+// (see lamp.Compositor.Target).
+type Target interface {
+	State() lamp.State
+	Apply(opts lamp.Options) error
+	FadeDone() <-chan struct{}
+	StopMarquee()
+}
+
+// Player plays one sequence at a time on a controller.
+type Player struct {
+	lc Target
+
+	mu     sync.Mutex
+	seq    *Sequence
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewPlayer returns a player for lc.
+func NewPlayer(lc Target) *Player {
+	return &Player{lc: lc}
+}
+
+// Play starts seq in the background, stopping whatever was playing. done,
+// if not nil, is called when playback ends with the error that ended it, or
+// nil when the sequence finished or was stopped.
+func (p *Player) Play(seq *Sequence, done func(err error)) {
+	p.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+
+	p.mu.Lock()
+	p.seq = seq
+	p.cancel = cancel
+	p.done = finished
+	p.mu.Unlock()
+
+	go func() {
+		defer close(finished)
+
+		err := p.loop(ctx, seq.Loop, seq.Steps)
+		if ctx.Err() != nil {
+			err = nil
+		}
+
+		p.mu.Lock()
+		if p.done == finished {
+			p.seq, p.cancel, p.done = nil, nil, nil
+		}
+		p.mu.Unlock()
+		cancel()
+
+		if done != nil {
+			done(err)
+		}
+	}()
+}
+
+// Stop stops playback, if any, and waits for it to end. A running marquee
+// step is stopped as well.
+func (p *Player) Stop() {
+	p.mu.Lock()
+	cancel, done := p.cancel, p.done
+	p.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+	p.lc.StopMarquee()
+}
+
+// Playing returns the name of the sequence being played, or "".
+func (p *Player) Playing() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.seq == nil {
+		return ""
+	}
+	return p.seq.Name
+}
+
+// Sequence returns the sequence being played, or nil.
+func (p *Player) Sequence() *Sequence {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.seq
+}
+
+func (p *Player) loop(ctx context.Context, n int, steps []Step) error {
+	for i := 0; n < 0 || i < n || i == 0; i++ {
+		for _, st := range steps {
+			if err := p.step(ctx, st); err != nil {
+				return err
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *Player) step(ctx context.Context, st Step) error {
+	switch {
+	case len(st.Steps) > 0:
+		return p.loop(ctx, st.Loop, st.Steps)
+	case st.Wait > 0:
+		sleep(ctx, time.Duration(st.Wait))
+		return nil
+	}
+
+	to := st.options(p.lc.State().Options)
+	if err := p.lc.Apply(to); err != nil {
+		return err
+	}
+	// 渐变在后台进行, 结束后才开始计时
+	select {
+	case <-p.lc.FadeDone():
+	case <-ctx.Done():
+		return nil
+	}
+	sleep(ctx, time.Duration(st.Duration))
+
+	return nil
+}
+
+// sleep waits for d and reports whether it was not cancelled.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
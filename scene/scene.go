@@ -0,0 +1,280 @@
+// Package scene loads lighting sequences from JSON files and plays them on a
+// lamp controller.
+//
+// A sequence is a list of steps. A step either sets the strip (mode, color,
+// percent, ranges, position, period or effect, optionally fading from the
+// previous color), waits, or repeats a nested list of steps:
+//
+//	{
+//	  "name": "andon",
+//	  "loop": -1,
+//	  "steps": [
+//	    {"name": "ok", "mode": "normal", "color": "0,80,0", "percent": 100, "fade": "1s", "duration": "10s"},
+//	    {"loop": 3, "steps": [
+//	      {"mode": "strobe", "color": "255,0,0", "period": "250ms", "duration": "2s"},
+//	      {"wait": "500ms"}
+//	    ]},
+//	    {"effect": "marquee", "color": "0,0,255", "duration": "5s"}
+//	  ]
+//	}
+//
+// loop is the number of times to run: 0 or 1 runs once, -1 runs until
+// stopped.
+package scene
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"lampwith-tag/lamp"
+)
+
+// Sequence is a named list of steps.
+type Sequence struct {
+	Name  string `json:"name"`
+	Loop  int    `json:"loop"`
+	Steps []Step `json:"steps"`
+}
+
+// Step is one entry of a sequence.
+type Step struct {
+	Name string `json:"name,omitempty"`
+
+	Mode     string `json:"mode,omitempty"`
+	Color    string `json:"color,omitempty"`
+	Percent  int    `json:"percent,omitempty"`
+	Position int    `json:"position,omitempty"`
+	Ranges   string `json:"ranges,omitempty"`
+	Effect   string `json:"effect,omitempty"`
+	// Period is the speed of breathe and strobe. A step that changes the
+	// mode without it goes back to the mode's default.
+	Period Duration `json:"period,omitempty"`
+
+	// Fade crossfades from what the strip shows before Duration starts.
+	Fade     Duration `json:"fade,omitempty"`
+	Duration Duration `json:"duration,omitempty"`
+	Wait     Duration `json:"wait,omitempty"`
+
+	Loop  int    `json:"loop,omitempty"`
+	Steps []Step `json:"steps,omitempty"`
+}
+
+// Duration is a time.Duration written as "500ms", "2s" or "1m30s".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"500ms\": %s", b)
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	if v < 0 {
+		return fmt.Errorf("duration %q is negative", s)
+	}
+
+	*d = Duration(v)
+	return nil
+}
+
+var effects = map[string]int{
+	"marquee": lamp.ModeMarquee,
+}
+
+// Load reads and validates a sequence file.
+func Load(path string) (*Sequence, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var seq Sequence
+	if err := json.Unmarshal(b, &seq); err != nil {
+		return nil, fmt.Errorf("scene: %s: %v", path, err)
+	}
+	if seq.Name == "" {
+		seq.Name = path
+	}
+
+	if err := seq.Validate(); err != nil {
+		return nil, fmt.Errorf("scene: %s: %v", path, err)
+	}
+
+	return &seq, nil
+}
+
+// Validate checks every step, so a file fails before anything is sent.
+func (seq *Sequence) Validate() error {
+	if seq.Loop < -1 {
+		return fmt.Errorf("loop %d must be -1 or more", seq.Loop)
+	}
+	if len(seq.Steps) == 0 {
+		return fmt.Errorf("no steps")
+	}
+	if err := validateSteps(seq.Steps, "steps"); err != nil {
+		return err
+	}
+	// 没有时长的无限循环会占满总线
+	if seq.Loop == -1 && length(seq.Steps) == 0 {
+		return fmt.Errorf("loop -1 needs steps that take time, set a duration, fade or wait")
+	}
+
+	return nil
+}
+
+// length is how long one pass of steps takes at least: durations, fades
+// and waits, with nested loops counted as often as they run.
+func length(steps []Step) time.Duration {
+	var d time.Duration
+	for _, st := range steps {
+		d += time.Duration(st.Duration) + time.Duration(st.Fade) + time.Duration(st.Wait)
+		if len(st.Steps) > 0 {
+			d += length(st.Steps) * time.Duration(max(st.Loop, 1))
+		}
+	}
+	return d
+}
+
+func validateSteps(steps []Step, path string) error {
+	for i, st := range steps {
+		where := fmt.Sprintf("%s[%d]", path, i)
+		if st.Name != "" {
+			where += " (" + st.Name + ")"
+		}
+
+		if err := st.validate(); err != nil {
+			return fmt.Errorf("%s: %v", where, err)
+		}
+		if len(st.Steps) > 0 {
+			if err := validateSteps(st.Steps, where+".steps"); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (st *Step) validate() error {
+	kinds := 0
+	if st.sets() {
+		kinds++
+	}
+	if st.Wait > 0 {
+		kinds++
+	}
+	if len(st.Steps) > 0 {
+		kinds++
+	}
+	if kinds != 1 {
+		return fmt.Errorf("a step must either set the strip, wait, or hold nested steps")
+	}
+
+	if st.Loop < -1 {
+		return fmt.Errorf("loop %d must be -1 or more", st.Loop)
+	}
+	if st.Loop != 0 && len(st.Steps) == 0 {
+		return fmt.Errorf("loop needs nested steps")
+	}
+	if st.Loop == -1 && length(st.Steps) == 0 {
+		return fmt.Errorf("loop -1 needs steps that take time, set a duration, fade or wait")
+	}
+
+	if !st.sets() {
+		return nil
+	}
+
+	if st.Mode != "" && st.Effect != "" {
+		return fmt.Errorf("mode and effect are exclusive")
+	}
+	if st.Mode != "" {
+		if _, err := lamp.ParseMode(st.Mode); err != nil {
+			return err
+		}
+	}
+	if _, ok := effects[st.Effect]; st.Effect != "" && !ok {
+		return fmt.Errorf("unknown effect %q", st.Effect)
+	}
+	if st.Color != "" {
+		if _, _, _, err := lamp.ParseColor(st.Color); err != nil {
+			return err
+		}
+	}
+	if time.Duration(st.Fade) > lamp.MaxFade {
+		return fmt.Errorf("fade %s must be %s or less", time.Duration(st.Fade), lamp.MaxFade)
+	}
+	if st.Percent < 0 || st.Percent > 100 {
+		return fmt.Errorf("percent %d must be between 1 and 100", st.Percent)
+	}
+	if st.Position < 0 {
+		return fmt.Errorf("position %d must be positive", st.Position)
+	}
+	if st.Percent != 0 && st.Ranges != "" {
+		return fmt.Errorf("percent and ranges are exclusive")
+	}
+	// the strip length is only known when playing
+	if st.Ranges != "" {
+		if _, err := lamp.ParseRanges(st.Ranges, 255); err != nil {
+			return err
+		}
+	}
+	if mode := st.mode(); st.Period != 0 && mode != 0 {
+		shortest, longest := lamp.PeriodRange(mode)
+		if p := time.Duration(st.Period); longest != 0 && (p < shortest || p > longest) {
+			return fmt.Errorf("%s period %s must be between %s and %s", lamp.ModeName(mode),
+				lamp.FormatPeriod(p), lamp.FormatPeriod(shortest), lamp.FormatPeriod(longest))
+		}
+	}
+
+	return nil
+}
+
+// sets reports whether the step changes the strip.
+func (st *Step) sets() bool {
+	return st.Mode != "" || st.Effect != "" || st.Color != "" || st.Percent != 0 || st.Position != 0 || st.Ranges != "" || st.Period != 0
+}
+
+// mode is the mode the step sets, 0 if it keeps the current one.
+func (st *Step) mode() int {
+	if st.Effect != "" {
+		return effects[st.Effect]
+	}
+	mode, _ := lamp.ParseMode(st.Mode)
+	return mode
+}
+
+// options merges the step into the current options.
+func (st *Step) options(cur lamp.Options) lamp.Options {
+	if mode := st.mode(); mode != 0 && mode != cur.ControlMode {
+		cur.ControlMode = mode
+		cur.ControlPeriod = 0
+	}
+	if st.Period != 0 {
+		cur.ControlPeriod = time.Duration(st.Period)
+	}
+	if st.Color != "" {
+		cur.ControlColor = st.Color
+	}
+	if st.Percent != 0 {
+		cur.ControlPercentage = st.Percent
+		cur.ControlRanges = ""
+	}
+	if st.Ranges != "" {
+		cur.ControlRanges = st.Ranges
+	}
+	if st.Position != 0 {
+		cur.ControlPosition = st.Position
+	}
+	cur.ControlFade = time.Duration(st.Fade)
+
+	return cur
+}
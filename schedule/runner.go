@@ -0,0 +1,216 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"lampwith-tag/alert"
+	"lampwith-tag/config"
+	"lampwith-tag/lamp"
+	"lampwith-tag/preset"
+	"lampwith-tag/scene"
+)
+
+// Strip is a strip the rules drive.
+type Strip struct {
+	Lamp   *lamp.LampWithClient
+	Player *scene.Player
+	// Alerts, if set, is the alert manager of the strip. The rules send
+	// through it, so a rule firing during an alert changes what the strip
+	// goes back to instead of hiding the alert.
+	Alerts *alert.Manager
+	// Layers and Gauge, if set, are put back at the end of a window when
+	// they showed at its start.
+	Layers *lamp.Compositor
+	Gauge  *lamp.Gauge
+}
+
+func (s Strip) state() lamp.State {
+	if s.Alerts != nil {
+		return s.Alerts.State()
+	}
+	return s.Lamp.State()
+}
+
+func (s Strip) scene() *scene.Sequence {
+	if s.Alerts != nil {
+		return s.Alerts.Scene()
+	}
+	return s.Player.Sequence()
+}
+
+func (s Strip) setOptions(opts lamp.Options) error {
+	if s.Alerts != nil {
+		return s.Alerts.SetOptions(opts)
+	}
+	return s.Lamp.SetOptions(opts)
+}
+
+func (s Strip) stopScene() {
+	if s.Alerts != nil {
+		s.Alerts.StopScene()
+		return
+	}
+	s.Player.Stop()
+}
+
+// apply stops the scene and sends opts.
+func (s Strip) apply(opts lamp.Options) error {
+	s.stopScene()
+	if s.Alerts != nil {
+		return s.Alerts.Apply(opts)
+	}
+	return s.Lamp.Apply(opts)
+}
+
+func (s Strip) play(seq *scene.Sequence) error {
+	if s.Alerts != nil {
+		return s.Alerts.Play(seq, nil)
+	}
+	s.Lamp.StopMarquee()
+	s.Player.Play(seq, nil)
+	return nil
+}
+
+// shown is what a strip showed at the start of a window.
+type shown struct {
+	opts lamp.Options
+	seq  *scene.Sequence
+	// layers and gauge are set when they were on the strip.
+	layers, gauge bool
+}
+
+// Runner carries out actions on the strips.
+type Runner struct {
+	Presets *preset.Store
+	// Strips returns the strips online now.
+	Strips func() []Strip
+
+	mu sync.Mutex
+	// saved holds what the strips showed before a window, by rule name.
+	saved map[string]map[*lamp.LampWithClient]shown
+}
+
+// Check reports rules whose preset or scene is missing.
+func (r *Runner) Check(rules []config.Rule) error {
+	var errs []error
+	for _, rl := range rules {
+		if _, err := r.load(rl); err != nil {
+			errs = append(errs, fmt.Errorf("schedule: rule %q: %v", rl.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Fire runs the preset or scene of a start on every strip, saving what
+// they show first if the rule has a window. The end of a window brings
+// back what was saved: the options, the scene that played, the layers or
+// the gauge.
+func (r *Runner) Fire(a Action) error {
+	if a.End {
+		return r.restore(a.Rule)
+	}
+
+	run, err := r.load(a.Rule)
+	if err != nil {
+		return fmt.Errorf("schedule: rule %q: %v", a.Rule.Name, err)
+	}
+
+	strips := r.Strips()
+	if a.Rule.Until != "" {
+		saved := map[*lamp.LampWithClient]shown{}
+		for _, s := range strips {
+			saved[s.Lamp] = shown{
+				opts:   s.state().Options,
+				seq:    s.scene(),
+				layers: s.Layers != nil && s.Layers.Showing(),
+				gauge:  s.Gauge != nil && s.Gauge.Showing(),
+			}
+		}
+		r.mu.Lock()
+		if r.saved == nil {
+			r.saved = map[string]map[*lamp.LampWithClient]shown{}
+		}
+		r.saved[a.Rule.Name] = saved
+		r.mu.Unlock()
+	}
+
+	var errs []error
+	for _, s := range strips {
+		if err := run(s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// load returns a function running the rule's preset or scene on a strip.
+func (r *Runner) load(rl config.Rule) (func(s Strip) error, error) {
+	if rl.Scene != "" {
+		seq, err := scene.Load(rl.Scene)
+		if err != nil {
+			return nil, err
+		}
+		return func(s Strip) error { return s.play(seq) }, nil
+	}
+
+	p, ok := r.Presets.Get(rl.Preset)
+	if !ok {
+		return nil, fmt.Errorf("no preset %q", rl.Preset)
+	}
+	opts, err := p.Options()
+	if err != nil {
+		return nil, err
+	}
+	return func(s Strip) error {
+		o := opts
+		// 预设没有指定渐变时沿用当前的
+		if o.ControlFade == 0 {
+			o.ControlFade = s.state().ControlFade
+		}
+		return s.apply(o)
+	}, nil
+}
+
+func (r *Runner) restore(rl config.Rule) error {
+	r.mu.Lock()
+	saved := r.saved[rl.Name]
+	delete(r.saved, rl.Name)
+	r.mu.Unlock()
+
+	var errs []error
+	for _, s := range r.Strips() {
+		w, ok := saved[s.Lamp]
+		if !ok {
+			continue
+		}
+		if err := s.restore(w); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// restore brings back what the strip showed at the start of a window.
+func (s Strip) restore(w shown) error {
+	if w.seq == nil && !w.layers && !w.gauge {
+		return s.apply(w.opts)
+	}
+
+	// 场景、图层和仪表接管灯带前先恢复选项, 之后 run 仍发送原来的设置
+	if err := s.setOptions(w.opts); err != nil {
+		return err
+	}
+	switch {
+	case w.seq != nil:
+		return s.play(w.seq)
+	case w.layers:
+		s.stopScene()
+		s.Layers.Show()
+	case w.gauge:
+		s.stopScene()
+		s.Gauge.Show()
+	}
+	return nil
+}
@@ -0,0 +1,361 @@
+// Package schedule applies presets and scenes at set times.
+//
+// A rule fires on a cron line in local time, or at sunrise or sunset with
+// an offset, and may hold its look until a second time:
+//
+//	{"name": "morning", "when": "0 8 * * 1-5", "preset": "warm"}
+//	{"name": "evening", "when": "30 18 * * *", "preset": "0"}
+//	{"name": "dusk",    "when": "sunset-15m", "preset": "lamp"}
+//	{"name": "service", "when": "0 22 * * 6", "until": "0 2 * * 0", "preset": "4"}
+//
+// Sun times need config.Location. A rule with until brings the strips
+// back to what they showed before it when the window ends.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"lampwith-tag/config"
+)
+
+// Spec is a parsed When or Until.
+type Spec interface {
+	// Next returns the first time after t the spec fires, or the zero
+	// time if it never does.
+	Next(t time.Time) time.Time
+}
+
+// Parse parses a cron line, a cron descriptor such as @daily, or a sun
+// event: sunrise or sunset, an optional offset (sunset-15m, sunrise+1h)
+// and optional days of the week in cron syntax (sunrise 1-5). loc is
+// needed for sun events only.
+func Parse(s string, loc *config.Location) (Spec, error) {
+	s = strings.TrimSpace(s)
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, errors.New("empty time")
+	}
+
+	if event, offset, ok := cutSunEvent(fields[0]); ok {
+		if loc == nil {
+			return nil, fmt.Errorf("%q needs the location in config.json", s)
+		}
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("%q: a sun event takes only an offset and days of the week", s)
+		}
+		sp := &sunSpec{rise: event == "sunrise", lat: loc.Lat, lon: loc.Lon}
+		if offset != "" {
+			d, err := time.ParseDuration(offset)
+			if err != nil {
+				return nil, fmt.Errorf("%q: bad offset: %v", s, err)
+			}
+			sp.offset = d
+		}
+		days := "*"
+		if len(fields) == 2 {
+			days = fields[1]
+		}
+		dow, err := cron.ParseStandard("0 0 * * " + days)
+		if err != nil {
+			return nil, fmt.Errorf("%q: bad days of the week: %v", s, err)
+		}
+		sp.days = dow.(*cron.SpecSchedule).Dow
+		return sp, nil
+	}
+
+	sp, err := cron.ParseStandard(s)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %v", s, err)
+	}
+	return sp, nil
+}
+
+// cutSunEvent splits "sunset-15m" into "sunset" and "-15m".
+func cutSunEvent(s string) (event, offset string, ok bool) {
+	for _, e := range []string{"sunrise", "sunset"} {
+		if rest, found := strings.CutPrefix(s, e); found {
+			if rest != "" && rest[0] != '+' && rest[0] != '-' {
+				return "", "", false
+			}
+			return e, strings.TrimPrefix(rest, "+"), true
+		}
+	}
+	return "", "", false
+}
+
+type sunSpec struct {
+	rise     bool
+	offset   time.Duration
+	lat, lon float64
+	// days is a bit per weekday, Sunday is bit 0.
+	days uint64
+}
+
+func (s *sunSpec) Next(t time.Time) time.Time {
+	// 从前一天开始, 负的偏移可能落在前一天
+	day := time.Date(t.Year(), t.Month(), t.Day()-1, 12, 0, 0, 0, t.Location())
+	for i := 0; i < 400; i++ {
+		d := day.AddDate(0, 0, i)
+		if s.days&(1<<uint(d.Weekday())) == 0 {
+			continue
+		}
+		rise, set, ok := sunTimes(d, s.lat, s.lon)
+		if !ok {
+			continue
+		}
+		at := set
+		if s.rise {
+			at = rise
+		}
+		if at = at.Add(s.offset); at.After(t) {
+			return at
+		}
+	}
+	return time.Time{}
+}
+
+// Action is a rule firing: the start of the rule, or the end of its
+// window.
+type Action struct {
+	Rule config.Rule
+	End  bool
+	At   time.Time
+}
+
+// Entry is a rule as List shows it.
+type Entry struct {
+	Rule config.Rule
+	// Next is the next start, End the next end of the window, zero if
+	// there is none.
+	Next, End time.Time
+	Paused    bool
+	// Skip is set when the next start will be skipped.
+	Skip bool
+}
+
+type rule struct {
+	config.Rule
+	when, until Spec
+	paused      bool
+	skip        bool
+}
+
+// Scheduler tells when the rules fire.
+type Scheduler struct {
+	mu    sync.Mutex
+	rules []*rule
+	wake  chan struct{}
+}
+
+// New checks rules and returns their scheduler.
+func New(rules []config.Rule, loc *config.Location) (*Scheduler, error) {
+	s := &Scheduler{wake: make(chan struct{}, 1)}
+	seen := map[string]bool{}
+	for _, r := range rules {
+		switch {
+		case r.Name == "" || strings.ContainsAny(r.Name, " \t"):
+			return nil, fmt.Errorf("schedule: rule name %q must be one word", r.Name)
+		case seen[r.Name]:
+			return nil, fmt.Errorf("schedule: rule %q is there twice", r.Name)
+		case (r.Preset == "") == (r.Scene == ""):
+			return nil, fmt.Errorf("schedule: rule %q must run either a preset or a scene", r.Name)
+		}
+		seen[r.Name] = true
+
+		when, err := Parse(r.When, loc)
+		if err != nil {
+			return nil, fmt.Errorf("schedule: rule %q: when %v", r.Name, err)
+		}
+		var until Spec
+		if r.Until != "" {
+			if until, err = Parse(r.Until, loc); err != nil {
+				return nil, fmt.Errorf("schedule: rule %q: until %v", r.Name, err)
+			}
+		}
+		s.rules = append(s.rules, &rule{Rule: r, when: when, until: until})
+	}
+	return s, nil
+}
+
+// Due returns the actions that fall after from and up to to, in time
+// order. Each rule starts and ends at most once: after a long gap only the
+// last of its times counts. Paused rules do not fire; a skipped start is
+// consumed.
+func (s *Scheduler) Due(from, to time.Time) []Action {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var actions []Action
+	for _, r := range s.rules {
+		if r.paused {
+			continue
+		}
+		if at := last(r.when, from, to); !at.IsZero() {
+			if r.skip {
+				r.skip = false
+			} else {
+				actions = append(actions, Action{Rule: r.Rule, At: at})
+			}
+		}
+		if r.until == nil {
+			continue
+		}
+		if at := last(r.until, from, to); !at.IsZero() {
+			actions = append(actions, Action{Rule: r.Rule, End: true, At: at})
+		}
+	}
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].At.Before(actions[j].At) })
+	return actions
+}
+
+// last returns the last time sp fires after from and up to to.
+func last(sp Spec, from, to time.Time) time.Time {
+	var at time.Time
+	for t := sp.Next(from); !t.IsZero() && !t.After(to); t = sp.Next(t) {
+		at = t
+	}
+	return at
+}
+
+// Next returns the first time after t any rule that is not paused fires,
+// or the zero time.
+func (s *Scheduler) Next(t time.Time) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	earlier := func(at time.Time) {
+		if !at.IsZero() && (next.IsZero() || at.Before(next)) {
+			next = at
+		}
+	}
+	for _, r := range s.rules {
+		if r.paused {
+			continue
+		}
+		earlier(r.when.Next(t))
+		if r.until != nil {
+			earlier(r.until.Next(t))
+		}
+	}
+	return next
+}
+
+// List returns the rules in config order with their next times after now.
+func (s *Scheduler) List(now time.Time) []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]Entry, len(s.rules))
+	for i, r := range s.rules {
+		entries[i] = Entry{Rule: r.Rule, Next: r.when.Next(now), Paused: r.paused, Skip: r.skip}
+		if r.until != nil {
+			entries[i].End = r.until.Next(now)
+		}
+	}
+	return entries
+}
+
+// Rule returns the rule called name.
+func (s *Scheduler) Rule(name string) (config.Rule, bool) {
+	r, err := s.find(name)
+	if err != nil {
+		return config.Rule{}, false
+	}
+	return r.Rule, true
+}
+
+// Pause stops the rule called name, or every rule for "", from firing
+// until Resume.
+func (s *Scheduler) Pause(name string) error {
+	return s.each(name, func(r *rule) { r.paused = true })
+}
+
+// Resume undoes Pause.
+func (s *Scheduler) Resume(name string) error {
+	return s.each(name, func(r *rule) { r.paused = false })
+}
+
+// Skip skips the next start of the rule called name, or undoes that.
+func (s *Scheduler) Skip(name string, skip bool) error {
+	return s.each(name, func(r *rule) { r.skip = skip })
+}
+
+func (s *Scheduler) each(name string, fn func(r *rule)) error {
+	if name == "" {
+		s.mu.Lock()
+		for _, r := range s.rules {
+			fn(r)
+		}
+		s.mu.Unlock()
+	} else {
+		r, err := s.find(name)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		fn(r)
+		s.mu.Unlock()
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (s *Scheduler) find(name string) (*rule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.rules {
+		if r.Name == name {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("schedule: no rule %q", name)
+}
+
+// Run calls fire for every action as it falls due, until stop is closed.
+func (s *Scheduler) Run(stop <-chan struct{}, fire func(Action)) {
+	from := time.Now()
+	for {
+		var (
+			timer *time.Timer
+			due   <-chan time.Time
+		)
+		if next := s.Next(from); !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			due = timer.C
+		}
+
+		select {
+		case <-stop:
+		case <-s.wake:
+		case <-due:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		// 被暂停或恢复唤醒时也先执行已经到期的规则, 再重新计算
+		now := time.Now()
+		for _, a := range s.Due(from, now) {
+			fire(a)
+		}
+		from = now
+	}
+}
@@ -0,0 +1,48 @@
+package schedule
+
+import (
+	"math"
+	"time"
+)
+
+// sunAngle is the altitude of the sun's centre at sunrise and sunset: the
+// upper limb on the horizon, with refraction.
+const sunAngle = -0.833
+
+// sunTimes returns sunrise and sunset on the day of t, in t's location, at
+// lat, lon. ok is false on days the sun does not rise or set (polar day or
+// night). It follows the sunrise equation as NOAA simplifies it and is
+// good to a minute or two.
+func sunTimes(t time.Time, lat, lon float64) (rise, set time.Time, ok bool) {
+	rad := math.Pi / 180
+	y, m, d := t.Date()
+
+	// 当天的日数 (从 J2000 起), 按经度换算到当地的正午
+	noon := time.Date(y, m, d, 12, 0, 0, 0, time.UTC)
+	n := math.Round(julian(noon) - 2451545.0 + 0.0008)
+	mean := n - lon/360
+
+	anomaly := math.Mod(357.5291+0.98560028*mean, 360)
+	center := 1.9148*math.Sin(anomaly*rad) + 0.0200*math.Sin(2*anomaly*rad) + 0.0003*math.Sin(3*anomaly*rad)
+	ecliptic := math.Mod(anomaly+center+180+102.9372, 360)
+	transit := 2451545.0 + mean + 0.0053*math.Sin(anomaly*rad) - 0.0069*math.Sin(2*ecliptic*rad)
+
+	declination := math.Asin(math.Sin(ecliptic*rad) * math.Sin(23.4397*rad))
+	cosHour := (math.Sin(sunAngle*rad) - math.Sin(lat*rad)*math.Sin(declination)) /
+		(math.Cos(lat*rad) * math.Cos(declination))
+	if cosHour < -1 || cosHour > 1 {
+		return time.Time{}, time.Time{}, false
+	}
+	hour := math.Acos(cosHour) / rad / 360
+
+	loc := t.Location()
+	return fromJulian(transit - hour).In(loc), fromJulian(transit + hour).In(loc), true
+}
+
+func julian(t time.Time) float64 {
+	return float64(t.Unix())/86400 + 2440587.5
+}
+
+func fromJulian(j float64) time.Time {
+	return time.Unix(0, int64((j-2440587.5)*86400*float64(time.Second))).Truncate(time.Second)
+}
@@ -0,0 +1,168 @@
+// Package session persists the last used port, strip quantity and options
+// so they survive a restart.
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"lampwith-tag/config"
+	"lampwith-tag/lamp"
+)
+
+// Session is the state file.
+type Session struct {
+	Port     string `json:"port"`
+	Quantity int    `json:"quantity"`
+	Mode     string `json:"mode"`
+	Percent  int    `json:"percent"`
+	Position int    `json:"position"`
+	Color    string `json:"color"`
+	Ranges   string `json:"ranges,omitempty"`
+	// Period is the speed of breathe and strobe, empty for the default.
+	Period string `json:"period,omitempty"`
+	Fade   string `json:"fade,omitempty"`
+}
+
+// Policy says what to do with the strip at startup or on quit.
+type Policy string
+
+const (
+	// Restore applies the last state.
+	Restore Policy = "restore"
+	// Keep leaves the strip unchanged.
+	Keep Policy = "keep"
+	// Off turns the strip off.
+	Off Policy = "off"
+)
+
+// ParsePolicy parses restore, keep or off.
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(s); p {
+	case Restore, Keep, Off:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown policy %q, want restore, keep or off", s)
+}
+
+// DefaultPath is state.json in the config directory.
+func DefaultPath() string {
+	return filepath.Join(config.Dir(), "state.json")
+}
+
+// Load reads the state file. A missing file yields an empty session.
+func Load(path string) (Session, error) {
+	var s Session
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+
+	if err := json.Unmarshal(b, &s); err != nil {
+		return Session{}, fmt.Errorf("session: %s: %v", path, err)
+	}
+	return s, nil
+}
+
+// Save writes the state file.
+func Save(path string, s Session) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// FromState captures the controller state for port.
+func FromState(port string, st lamp.State) Session {
+	s := Session{
+		Port:     port,
+		Quantity: st.Quantity,
+		Mode:     lamp.ModeName(st.ControlMode),
+		Percent:  st.ControlPercentage,
+		Position: st.ControlPosition,
+		Color:    st.ControlColor,
+		Ranges:   st.ControlRanges,
+	}
+	if st.ControlPeriod != 0 {
+		s.Period = lamp.FormatPeriod(st.ControlPeriod)
+	}
+	if st.ControlFade != 0 {
+		s.Fade = lamp.FormatPeriod(st.ControlFade)
+	}
+	return s
+}
+
+// Options returns the saved options. It fails for a session that never
+// saved any.
+func (s Session) Options() (lamp.Options, error) {
+	mode, err := lamp.ParseMode(s.Mode)
+	if err != nil {
+		return lamp.Options{}, err
+	}
+	var period, fade time.Duration
+	if s.Period != "" {
+		if period, err = lamp.ParsePeriod(s.Period); err != nil {
+			return lamp.Options{}, err
+		}
+	}
+	if s.Fade != "" {
+		if fade, err = lamp.ParseFade(s.Fade); err != nil {
+			return lamp.Options{}, err
+		}
+	}
+
+	return lamp.Options{
+		ControlMode:       mode,
+		ControlPercentage: s.Percent,
+		ControlPosition:   s.Position,
+		ControlColor:      s.Color,
+		ControlRanges:     s.Ranges,
+		ControlPeriod:     period,
+		ControlFade:       fade,
+	}, nil
+}
+
+// Track saves the session for port whenever the quantity or options of lc
+// change, until the returned function is called. Save errors go to onErr.
+func Track(lc *lamp.LampWithClient, path, port string, onErr func(error)) (stop func()) {
+	events, cancel := lc.Subscribe()
+	last := FromState(port, lc.State())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for ev := range events {
+			cur := FromState(port, ev.State)
+			if ev.Err != nil || cur == last {
+				continue
+			}
+			if err := Save(path, cur); err != nil && onErr != nil {
+				onErr(err)
+			}
+			last = cur
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
@@ -0,0 +1,111 @@
+package test
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+
+	"github.com/goburrow/modbus"
+)
+
+// simDevice is a Modbus RTU transporter that behaves like a tag-lamp
+// controller: it checks the request frame and answers
+// WriteMultipleRegisters with a valid response.
+type simDevice struct {
+	mu      sync.Mutex
+	slaveID byte
+	regs    [256]uint16
+	frames  [][]byte
+	fail    error
+	// exception, when set, answers writes with this Modbus exception.
+	exception byte
+}
+
+func newSimDevice() *simDevice {
+	return &simDevice{slaveID: 1}
+}
+
+// newSimClient returns a modbus client talking RTU frames to d.
+func newSimClient(d *simDevice) modbus.Client {
+	handler := modbus.NewRTUClientHandler("sim")
+	handler.SlaveId = d.slaveID
+
+	return modbus.NewClient2(handler, d)
+}
+
+func (d *simDevice) Send(adu []byte) ([]byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.fail != nil {
+		return nil, d.fail
+	}
+	if len(adu) < 4 || crc16(adu[:len(adu)-2]) != binary.LittleEndian.Uint16(adu[len(adu)-2:]) {
+		return nil, errors.New("sim: bad request crc")
+	}
+
+	pdu := adu[1 : len(adu)-2]
+	if pdu[0] != modbus.FuncCodeWriteMultipleRegisters {
+		return d.frame(pdu[0]|0x80, modbus.ExceptionCodeIllegalFunction), nil
+	}
+	if d.exception != 0 {
+		return d.frame(pdu[0]|0x80, d.exception), nil
+	}
+
+	address := binary.BigEndian.Uint16(pdu[1:])
+	quantity := binary.BigEndian.Uint16(pdu[3:])
+	values := pdu[6:]
+	for i := 0; i < int(quantity); i++ {
+		d.regs[int(address)+i] = binary.BigEndian.Uint16(values[i*2:])
+	}
+	d.frames = append(d.frames, append([]byte(nil), values...))
+
+	return d.frame(pdu[:5]...), nil
+}
+
+func (d *simDevice) frame(pdu ...byte) []byte {
+	adu := append([]byte{d.slaveID}, pdu...)
+	return binary.LittleEndian.AppendUint16(adu, crc16(adu))
+}
+
+// setFail makes every following request fail with err, or succeed again
+// when err is nil.
+func (d *simDevice) setFail(err error) {
+	d.mu.Lock()
+	d.fail = err
+	d.mu.Unlock()
+}
+
+// setException makes every following write answered with the Modbus
+// exception code, or accepted again when code is 0.
+func (d *simDevice) setException(code byte) {
+	d.mu.Lock()
+	d.exception = code
+	d.mu.Unlock()
+}
+
+// lastFrame returns the values of the last write.
+func (d *simDevice) lastFrame() []byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.frames) == 0 {
+		return nil
+	}
+	return d.frames[len(d.frames)-1]
+}
+
+func crc16(b []byte) uint16 {
+	crc := uint16(0xffff)
+	for _, v := range b {
+		crc ^= uint16(v)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xa001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
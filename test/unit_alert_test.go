@@ -0,0 +1,374 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"lampwith-tag/alert"
+	"lampwith-tag/i18n"
+	"lampwith-tag/lamp"
+	"lampwith-tag/lamprpc"
+	"lampwith-tag/preset"
+	"lampwith-tag/repl"
+	"lampwith-tag/scene"
+)
+
+var (
+	warningFrame  = []byte{4, 30, 120, 255, 0, 2}
+	criticalFrame = []byte{5, 30, 0, 255, 0, 25}
+)
+
+func TestAlertPriority(t *testing.T) {
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+	lc.SetQuantity(30)
+	opts := lc.State().Options
+	opts.ControlColor = "0,200,0"
+	if err := lc.Apply(opts); err != nil {
+		t.Fatal(err)
+	}
+	baseFrame := dev.lastFrame()
+	m := alert.NewManager(lc, nil)
+
+	if err := m.Raise(alert.Warning, "conveyor", 0); err != nil {
+		t.Fatal(err)
+	}
+	if f := dev.lastFrame(); !bytes.Equal(f, warningFrame) {
+		t.Errorf("warning % x, want % x", f, warningFrame)
+	}
+	if err := m.Raise(alert.Critical, "press-3", 0); err != nil {
+		t.Fatal(err)
+	}
+	// a lower level does not take over
+	n := len(dev.frames)
+	if err := m.Raise(alert.Info, "shift", 0); err != nil {
+		t.Fatal(err)
+	}
+	if f := dev.lastFrame(); !bytes.Equal(f, criticalFrame) || len(dev.frames) != n {
+		t.Errorf("critical % x, want % x", f, criticalFrame)
+	}
+
+	active := m.Active()
+	if len(active) != 3 || active[0].Source != "press-3" || active[2].Source != "shift" {
+		t.Errorf("active: %+v", active)
+	}
+
+	if err := m.Clear("press-3"); err != nil {
+		t.Fatal(err)
+	}
+	if f := dev.lastFrame(); !bytes.Equal(f, warningFrame) {
+		t.Errorf("after clearing critical % x, want the warning", f)
+	}
+	if err := m.Clear("press-3"); err == nil {
+		t.Error("cleared an alert twice")
+	}
+
+	// clearing the rest brings the strip back
+	if err := m.Clear(""); err != nil {
+		t.Fatal(err)
+	}
+	if f := dev.lastFrame(); !bytes.Equal(f, baseFrame) {
+		t.Errorf("after clearing all % x, want % x", f, baseFrame)
+	}
+	if len(m.Active()) != 0 {
+		t.Errorf("active after clear: %+v", m.Active())
+	}
+
+	if err := m.Raise(alert.Level(9), "x", 0); err == nil {
+		t.Error("unknown level accepted")
+	}
+	if err := m.Raise(alert.Info, "", 0); err == nil {
+		t.Error("alert without source accepted")
+	}
+	if l, err := alert.ParseLevel("Critical"); err != nil || l != alert.Critical {
+		t.Errorf("ParseLevel: %v, %v", l, err)
+	}
+}
+
+func TestAlertExpires(t *testing.T) {
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+	lc.SetQuantity(30)
+	if err := lc.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	baseFrame := dev.lastFrame()
+
+	seq := &scene.Sequence{Name: "idle", Loop: -1, Steps: []scene.Step{
+		{Mode: "normal", Color: "0,0,50", Percent: 100, Duration: scene.Duration(time.Hour)},
+	}}
+	player := scene.NewPlayer(lc)
+	player.Play(seq, nil)
+	time.Sleep(50 * time.Millisecond)
+	m := alert.NewManager(lc, player)
+
+	if err := m.Raise(alert.Critical, "press-3", 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if player.Playing() != "" {
+		t.Error("scene still playing under the alert")
+	}
+	// renewing keeps the alert up past its first ttl
+	time.Sleep(60 * time.Millisecond)
+	m.Raise(alert.Critical, "press-3", 100*time.Millisecond)
+	time.Sleep(60 * time.Millisecond)
+	if f := dev.lastFrame(); !bytes.Equal(f, criticalFrame) {
+		t.Errorf("renewed alert % x", f)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if len(m.Active()) != 0 {
+		t.Errorf("alert did not expire: %+v", m.Active())
+	}
+	if player.Playing() != "idle" {
+		t.Errorf("scene %q after the alert, want idle", player.Playing())
+	}
+	time.Sleep(50 * time.Millisecond)
+	if f := dev.lastFrame(); !bytes.Equal(f, []byte{3, 30, 0, 0, 50, 0}) {
+		t.Errorf("after the alert % x, want the scene (base % x)", f, baseFrame)
+	}
+	player.Stop()
+}
+
+func TestReplAlert(t *testing.T) {
+	sh, lc, dev, out := newShell(t)
+	if err := sh.Exec("alert"); err == nil {
+		t.Error("alert without a manager succeeded")
+	}
+	sh.SetAlerts(alert.NewManager(lc, nil))
+
+	if err := sh.Exec("alert warning conveyor; alert critical press-3 5m; alert list"); err != nil {
+		t.Fatal(err)
+	}
+	if f := dev.lastFrame(); !bytes.Equal(f, criticalFrame) {
+		t.Errorf("frame % x, want % x", f, criticalFrame)
+	}
+	for _, want := range []string{"critical  press-3", "expires in 5m0s (shown)", "warning   conveyor", "until cleared"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output misses %q:\n%s", want, out)
+		}
+	}
+
+	for _, line := range []string{"alert loud x", "alert info", "alert info x soon", "alert info x 1s extra", "alert clear nobody"} {
+		if err := sh.Exec(line); err == nil {
+			t.Errorf("%q succeeded", line)
+		}
+	}
+
+	if err := sh.Exec("alert clear"); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	sh.Exec("alert")
+	if !strings.Contains(out.String(), "No alerts") {
+		t.Errorf("list after clear:\n%s", out)
+	}
+}
+
+func TestRPCAlert(t *testing.T) {
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+	lc.SetQuantity(30)
+	ctx := context.Background()
+
+	if _, err := newRPCClient(t, lc).ListAlerts(ctx, &lamprpc.ListAlertsRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("without alerts: %v", err)
+	}
+
+	srv := lamprpc.NewServer(lc)
+	srv.SetAlerts(alert.NewManager(lc, nil))
+	client := serveRPC(t, srv)
+
+	res, err := client.RaiseAlert(ctx, &lamprpc.RaiseAlertRequest{Level: lamprpc.AlertLevel_ALERT_LEVEL_CRITICAL, Source: "press-3", TtlMs: 60000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Alerts) != 1 || res.Alerts[0].Source != "press-3" || res.Alerts[0].ExpiresMs == 0 {
+		t.Errorf("alerts: %v", res.Alerts)
+	}
+	if f := dev.lastFrame(); !bytes.Equal(f, criticalFrame) {
+		t.Errorf("frame % x, want % x", f, criticalFrame)
+	}
+
+	if _, err := client.RaiseAlert(ctx, &lamprpc.RaiseAlertRequest{Source: "x"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("no level: %v", err)
+	}
+	if _, err := client.ClearAlert(ctx, &lamprpc.ClearAlertRequest{Source: "nobody"}); status.Code(err) != codes.NotFound {
+		t.Errorf("unknown source: %v", err)
+	}
+	if res, err := client.ClearAlert(ctx, &lamprpc.ClearAlertRequest{Source: "press-3"}); err != nil || len(res.Alerts) != 0 {
+		t.Errorf("clear: %v, %v", res, err)
+	}
+}
+
+func TestAlertChangesUnderneath(t *testing.T) {
+	if err := i18n.SetLocale("en"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { i18n.SetLocale("zh") })
+	presets, err := preset.Load(filepath.Join(t.TempDir(), "presets.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+	lc.SetQuantity(30)
+	player := scene.NewPlayer(lc)
+	m := alert.NewManager(lc, player)
+	sh := repl.New(lc, presets, player, &bytes.Buffer{})
+	sh.SetAlerts(m)
+	srv := lamprpc.NewServer(lc)
+	srv.SetAlerts(m)
+	client := serveRPC(t, srv)
+
+	// The console changes the strip during an alert: the alert stays and
+	// the change shows once it clears.
+	m.Raise(alert.Critical, "press-3", 0)
+	if err := sh.Exec("breathe 50% blue; set color 0,0,99"); err != nil {
+		t.Fatal(err)
+	}
+	if f := dev.lastFrame(); !bytes.Equal(f, criticalFrame) {
+		t.Errorf("console hid the alert: % x", f)
+	}
+	m.Clear("")
+	if s := lc.State(); s.ControlMode != lamp.ModeBreathe || s.ControlPercentage != 50 || s.ControlColor != "0,0,99" {
+		t.Errorf("after the alert %+v, want the console's breathe", s.Options)
+	}
+	if f := dev.lastFrame(); f[0] != lamp.ModeBreathe || f[1] != 15 || f[4] != 99 {
+		t.Errorf("after the alert % x, want the console's breathe", f)
+	}
+
+	// So does gRPC, and it answers with what the strip goes back to.
+	m.Raise(alert.Critical, "press-3", 0)
+	st, err := client.Normal(context.Background(), &lamprpc.FillRequest{Percent: 20, Color: &lamprpc.Color{R: 7}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.GetMode() != lamprpc.Mode_MODE_NORMAL || st.GetPercent() != 20 {
+		t.Errorf("gRPC answered %v", st)
+	}
+	if f := dev.lastFrame(); !bytes.Equal(f, criticalFrame) {
+		t.Errorf("gRPC hid the alert: % x", f)
+	}
+	m.Clear("")
+	if f := dev.lastFrame(); !bytes.Equal(f, []byte{3, 6, 0, 7, 0, 0}) {
+		t.Errorf("after the alert % x, want gRPC's fill", f)
+	}
+
+	// A scene started during the alert plays after it, stop drops it.
+	path := writeScene(t, `{"name": "idle", "loop": -1, "steps": [{"mode": "normal", "color": "0,0,50", "percent": 100, "duration": "1h"}]}`)
+	m.Raise(alert.Warning, "conveyor", 0)
+	if err := sh.Exec("play " + path); err != nil {
+		t.Fatal(err)
+	}
+	if player.Playing() != "" || !bytes.Equal(dev.lastFrame(), warningFrame) {
+		t.Errorf("scene %q played over the alert", player.Playing())
+	}
+	m.Clear("")
+	if player.Playing() != "idle" {
+		t.Errorf("scene %q after the alert, want idle", player.Playing())
+	}
+	m.Raise(alert.Warning, "conveyor", 0)
+	sh.Exec("stop")
+	m.Clear("")
+	if player.Playing() != "" {
+		t.Errorf("stopped scene %q came back after the alert", player.Playing())
+	}
+
+	// An alert shown over layers moves to the whole strip when a command
+	// takes the strip from the layers.
+	layers := lc.NewCompositor()
+	m.UseLayers(layers)
+	layers.Set(lamp.Layer{Name: "bg", Options: fill(lamp.ModeNormal, "", "0,9,0"), Opacity: 1})
+	m.Raise(alert.Critical, "press-3", 0)
+	if err := sh.Exec("normal 100% red"); err != nil {
+		t.Fatal(err)
+	}
+	if f := dev.lastFrame(); !bytes.Equal(f, criticalFrame) {
+		t.Errorf("command over a layered alert: % x, want the alert", f)
+	}
+	if _, ok := layers.Layer("alert"); ok {
+		t.Error("alert layer left behind")
+	}
+	m.Clear("")
+	if f := dev.lastFrame(); !bytes.Equal(f, []byte{3, 30, 0, 255, 0, 0}) {
+		t.Errorf("after the alert % x, want the command", f)
+	}
+}
+
+func TestAlertOverEffects(t *testing.T) {
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+	lc.SetQuantity(10)
+	m := alert.NewManager(lc, nil)
+	g := lc.NewGauge()
+	s := lamp.DefaultGaugeSettings
+	s.Ease = 0
+	g.Configure(s)
+	green, red, blue := lamp.Color{G: 255}, lamp.Color{R: 255}, lamp.Color{B: 200}
+
+	// The gauge waits under the alert and comes back at its last value.
+	g.Set(50)
+	if !eventually(func() bool { return rgb(lc.State().Pixels[4]) == green }) {
+		t.Fatalf("pixels %v, want half the bar", lc.State().Pixels)
+	}
+	m.Raise(alert.Critical, "press-3", 0)
+	if f := dev.lastFrame(); f[0] != lamp.ModeStrobe {
+		t.Errorf("frame % x, want the alert", f)
+	}
+	if !g.Showing() {
+		t.Error("alert ended the gauge")
+	}
+	n := frameCount(dev)
+	g.Set(100)
+	time.Sleep(60 * time.Millisecond)
+	if frameCount(dev) != n {
+		t.Errorf("gauge drew over the alert: % x", dev.lastFrame())
+	}
+	m.Clear("")
+	if !eventually(func() bool { return rgb(lc.State().Pixels[9]) == red }) {
+		t.Errorf("pixels %v after the alert, want the full bar", lc.State().Pixels)
+	}
+
+	// So does a follow, without ending.
+	var stopped atomic.Int32
+	err := lc.Follow(lamp.Follow{Level: func() float64 { return 1 }, Color: blue, Stopped: func() { stopped.Add(1) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !eventually(func() bool { return rgb(lc.State().Pixels[0]) == blue }) {
+		t.Fatalf("pixels %v, want the follow", lc.State().Pixels)
+	}
+	if g.Showing() {
+		t.Error("gauge still showing under the follow")
+	}
+	m.Raise(alert.Warning, "conveyor", 0)
+	time.Sleep(60 * time.Millisecond)
+	if f := dev.lastFrame(); !bytes.Equal(f, []byte{4, 10, 120, 255, 0, 2}) || stopped.Load() != 0 {
+		t.Errorf("frame % x, stopped %d, want the alert over the follow", f, stopped.Load())
+	}
+	m.Clear("")
+	if !eventually(func() bool { return rgb(lc.State().Pixels[0]) == blue }) || stopped.Load() != 0 {
+		t.Errorf("pixels %v, stopped %d after the alert, want the follow", lc.State().Pixels, stopped.Load())
+	}
+
+	// A command during the alert ends it for good.
+	m.Raise(alert.Warning, "conveyor", 0)
+	m.Apply(fill(lamp.ModeNormal, "", "0,0,9"))
+	if stopped.Load() != 1 {
+		t.Errorf("follow stopped %d times, want once", stopped.Load())
+	}
+	m.Clear("")
+	time.Sleep(60 * time.Millisecond)
+	if f := dev.lastFrame(); !bytes.Equal(f, []byte{3, 10, 0, 0, 9, 0}) {
+		t.Errorf("frame % x after the alert, want the command", f)
+	}
+}
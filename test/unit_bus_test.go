@@ -0,0 +1,126 @@
+package test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"lampwith-tag/lamp"
+)
+
+func TestBusTiming(t *testing.T) {
+	bus := lamp.DefaultBus()
+
+	if c := bus.CharTime(); c != 520833*time.Nanosecond {
+		t.Errorf("char time %v at 19200 8N1", c)
+	}
+	// 15 byte request and 8 byte response, two gaps of 3.5 characters
+	want := 23*bus.CharTime() + 2*bus.Gap() + bus.Turnaround
+	if w := bus.WriteTime(3); w != want {
+		t.Errorf("write time %v, want %v", w, want)
+	}
+	if n := bus.WritesPerSecond(); n < 45 || n > 52 {
+		t.Errorf("%.1f writes/s at 19200, want about 48", n)
+	}
+
+	fast := bus
+	fast.Baud = 115200
+	if g := fast.Gap(); g != 1750*time.Microsecond {
+		t.Errorf("gap %v above 19200, want 1.75ms", g)
+	}
+	if fast.WritesPerSecond() <= bus.WritesPerSecond() {
+		t.Error("115200 baud carries no more writes than 19200")
+	}
+
+	even := bus
+	even.Parity = "E"
+	if even.CharTime() <= bus.CharTime() {
+		t.Error("parity bit not counted")
+	}
+}
+
+func TestEffectTooFastWarns(t *testing.T) {
+	var buf bytes.Buffer
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer slog.SetDefault(old)
+
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+
+	// 11 LEDs a frame every 125ms is more than 19200 baud carries
+	opts := lc.State().Options
+	opts.ControlMode = lamp.ModeBreathe
+	opts.ControlRanges = "2-12"
+	if err := lc.Apply(opts); err != nil {
+		t.Fatal(err)
+	}
+	lc.StopMarquee()
+
+	if !strings.Contains(buf.String(), "too fast for the bus") {
+		t.Errorf("no warning for breathe on 11 LEDs:\n%s", buf.String())
+	}
+
+	// one LED is fine
+	buf.Reset()
+	opts.ControlRanges = "5"
+	if err := lc.Apply(opts); err != nil {
+		t.Fatal(err)
+	}
+	lc.StopMarquee()
+	if strings.Contains(buf.String(), "too fast") {
+		t.Errorf("warning for breathe on 1 LED:\n%s", buf.String())
+	}
+}
+
+// logBuffer collects the log of effects running in the background.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestLayersAndGaugePaced(t *testing.T) {
+	var buf logBuffer
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer slog.SetDefault(old)
+
+	// at 1200 baud one write takes longer than a 50ms redraw
+	lc := lamp.New(newSimClient(newSimDevice()))
+	lc.SetQuantity(30)
+	lc.Bus.Baud = 1200
+
+	c := lc.NewCompositor()
+	if err := c.Set(lamp.Layer{Name: "bg", Options: fill(lamp.ModeBreathe, "", "0,0,255"), Opacity: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if !eventually(func() bool { return strings.Contains(buf.String(), "effect=layers") }) {
+		t.Errorf("no warning for breathing layers at 1200 baud:\n%s", buf.String())
+	}
+	c.Clear()
+
+	g := lc.NewGauge()
+	g.Set(80)
+	if !eventually(func() bool { return strings.Contains(buf.String(), "effect=gauge") }) {
+		t.Errorf("no warning for the moving gauge at 1200 baud:\n%s", buf.String())
+	}
+	if !eventually(func() bool { return g.Value() == 80 && rgb(lc.State().Pixels[23]) != (lamp.Color{}) }) {
+		t.Errorf("gauge never reached 80: %v", lc.State().Pixels)
+	}
+	lc.StopMarquee()
+}
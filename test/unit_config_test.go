@@ -0,0 +1,72 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"lampwith-tag/config"
+	"lampwith-tag/port"
+)
+
+func TestConfigPort(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	c, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Port("/dev/ttyUSB0").RS485.Enabled {
+		t.Fatal("RS-485 enabled without a config file")
+	}
+
+	data := `{"ports": {
+		"*": {"rs485": {"enabled": true, "rts_on_send": true}},
+		"/dev/ttyUSB0": {"rs485": {"enabled": false}},
+		"/dev/ttyS1": {"rs485": {"enabled": true, "delay_before_send_ms": 2}}
+	}}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err = config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Port("/dev/ttyUSB0").RS485.Enabled {
+		t.Error("/dev/ttyUSB0 should override the default")
+	}
+	if rs := c.Port("/dev/ttyUSB1").RS485; !rs.Enabled || !rs.RTSOnSend {
+		t.Errorf("/dev/ttyUSB1 = %+v, want the default", rs)
+	}
+	if got := c.Port("/dev/ttyS1").RS485.Serial().DelayRtsBeforeSend.Milliseconds(); got != 2 {
+		t.Errorf("delay before send = %dms, want 2", got)
+	}
+}
+
+func TestConfigPins(t *testing.T) {
+	ftdi := port.Descriptor{Name: "/dev/ttyUSB1", VID: "0403", PID: "6001", Serial: "A10K1", Description: "FTDI FT232R USB UART"}
+	ch340 := port.Descriptor{Name: "/dev/ttyUSB0", VID: "1a86", PID: "7523"}
+
+	if got, want := ftdi.String(), "/dev/ttyUSB1 [0403:6001 A10K1 FTDI FT232R USB UART]"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	var c config.Config
+	if !c.Pinned(ch340) {
+		t.Error("without pins every port may be used")
+	}
+
+	c.Pins = []port.Match{{VID: "0403", Serial: "A10K1"}}
+	if !c.Pinned(ftdi) {
+		t.Error("pinned FTDI adapter not selected")
+	}
+	if c.Pinned(ch340) {
+		t.Error("unpinned adapter selected")
+	}
+
+	c.Pins = []port.Match{{VID: "1A86", PID: "7523"}}
+	if !c.Pinned(ch340) {
+		t.Error("vid/pid should compare without case")
+	}
+}
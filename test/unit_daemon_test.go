@@ -0,0 +1,151 @@
+package test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"lampwith-tag/daemon"
+	"lampwith-tag/lamp"
+	"lampwith-tag/port"
+)
+
+func TestDaemonHotPlug(t *testing.T) {
+	devices := map[string]*simDevice{"sim0": newSimDevice()}
+	d := daemon.New(func(name string) (*lamp.LampWithClient, error) {
+		dev, ok := devices[name]
+		if !ok {
+			return nil, errors.New("no strip")
+		}
+		return lamp.New(newSimClient(dev)), nil
+	})
+	events, cancel := d.Subscribe()
+	defer cancel()
+
+	next := func() daemon.Event {
+		t.Helper()
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(time.Second):
+			t.Fatal("no event")
+		}
+		return daemon.Event{}
+	}
+
+	d.Handle(port.Event{Name: "sim0", Added: true})
+	if ev := next(); ev.Port != "sim0" || !ev.Online {
+		t.Fatalf("got %+v, want sim0 online", ev)
+	}
+	d.Handle(port.Event{Name: "sim1", Added: true})
+	if ev := next(); ev.Port != "sim1" || ev.Err == nil {
+		t.Fatalf("got %+v, want an attach error for sim1", ev)
+	}
+
+	d.Handle(port.Event{Name: "sim0"})
+	if ev := next(); ev.Port != "sim0" || ev.Online {
+		t.Fatalf("got %+v, want sim0 offline", ev)
+	}
+	if s := d.Strips(); len(s) != 1 || s[0].Online {
+		t.Fatalf("strips %+v, want sim0 offline", s)
+	}
+
+	// Plugged in again: the same controller comes back and gets its state.
+	dev := devices["sim0"]
+	dev.frames = nil
+	d.Handle(port.Event{Name: "sim0", Added: true})
+	if ev := next(); !ev.Online {
+		t.Fatalf("got %+v, want sim0 online", ev)
+	}
+	if dev.lastFrame() == nil {
+		t.Error("state not sent again on reattach")
+	}
+}
+
+func TestDaemonWatch(t *testing.T) {
+	// A fake port list: sim0 plugged in, sim1 added, a failed listing that
+	// must not take anything offline, then sim0 pulled out.
+	lists := [][]string{{"sim0"}, {"sim0", "sim1"}, nil, {"sim1"}}
+	var mu sync.Mutex
+	calls := 0
+	list := func() ([]string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		i := min(calls, len(lists)-1)
+		calls++
+		if lists[i] == nil {
+			return nil, errors.New("sim: cannot list ports")
+		}
+		return lists[i], nil
+	}
+
+	devices := map[string]*simDevice{"sim0": newSimDevice(), "sim1": newSimDevice()}
+	d := daemon.New(func(name string) (*lamp.LampWithClient, error) {
+		return lamp.New(newSimClient(devices[name])), nil
+	})
+	events, cancel := d.Subscribe()
+	defer cancel()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go d.Run(port.WatchList(list, 5*time.Millisecond, stop))
+
+	want := []daemon.Event{
+		{Port: "sim0", Online: true},
+		{Port: "sim1", Online: true},
+		{Port: "sim0"},
+	}
+	for _, w := range want {
+		select {
+		case ev := <-events:
+			if ev != w {
+				t.Fatalf("got %+v, want %+v", ev, w)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no event, want %+v", w)
+		}
+	}
+	s := d.Strips()
+	if len(s) != 2 || s[0].Online || !s[1].Online {
+		t.Fatalf("strips %+v, want sim0 offline and sim1 online", s)
+	}
+}
+
+func TestDaemonRetry(t *testing.T) {
+	dev := newSimDevice()
+	var mu sync.Mutex
+	attempts := 0
+	d := daemon.New(func(name string) (*lamp.LampWithClient, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		// The strip is not powered yet on the first attempt.
+		attempts++
+		if attempts == 1 {
+			return nil, errors.New("sim: no answer")
+		}
+		return lamp.New(newSimClient(dev)), nil
+	})
+	d.Retry = 10 * time.Millisecond
+	events, cancel := d.Subscribe()
+	defer cancel()
+
+	ports := make(chan port.Event, 1)
+	defer close(ports)
+	go d.Run(ports)
+	ports <- port.Event{Name: "sim0", Added: true}
+
+	for _, online := range []bool{false, true} {
+		select {
+		case ev := <-events:
+			if ev.Online != online || (ev.Err == nil) == !online {
+				t.Fatalf("got %+v, want online %v", ev, online)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no event")
+		}
+	}
+	if s := d.Strips(); len(s) != 1 || !s[0].Online {
+		t.Fatalf("strips %+v, want sim0 online", s)
+	}
+}
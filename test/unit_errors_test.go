@@ -0,0 +1,85 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/goburrow/modbus"
+	"github.com/goburrow/serial"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"lampwith-tag/lamp"
+	"lampwith-tag/lamprpc"
+)
+
+func TestErrorTaxonomy(t *testing.T) {
+	d := newSimDevice()
+	client := newSimClient(d)
+	lc := lamp.New(client)
+
+	var optErr *lamp.OptionError
+	if err := lc.SetQuantity(0); !errors.As(err, &optErr) || optErr.Option != "quantity" || !errors.Is(err, lamp.ErrInvalidOption) {
+		t.Errorf("SetQuantity(0) = %v, want a quantity OptionError", err)
+	}
+
+	_, err := client.ReadCoils(0, 1)
+	var excErr *lamp.ExceptionError
+	if !errors.As(lamp.Classify(err), &excErr) || excErr.Code != lamp.ExceptionIllegalFunction || excErr.Function != 1 {
+		t.Fatalf("Classify(%v) = %v, want an illegal function exception", err, lamp.Classify(err))
+	}
+	if excErr.Name() != "illegal function" {
+		t.Errorf("Name() = %q", excErr.Name())
+	}
+
+	for _, c := range []struct {
+		fail  error
+		check func(error) bool
+	}{
+		{serial.ErrTimeout, func(err error) bool { var e *lamp.TimeoutError; return errors.As(err, &e) }},
+		{errors.New("modbus: response crc '1' does not match expected '2'"), func(err error) bool { var e *lamp.FrameError; return errors.As(err, &e) }},
+		{syscall.EIO, func(err error) bool { var e *lamp.PortError; return errors.As(err, &e) }},
+	} {
+		d.setFail(c.fail)
+		if err := lc.Exec(); !c.check(err) {
+			t.Errorf("bus failing with %v: Exec() = %T %v", c.fail, err, err)
+		}
+	}
+}
+
+func TestErrorExceptions(t *testing.T) {
+	d := newSimDevice()
+	lc := lamp.New(newSimClient(d))
+	client := newRPCClient(t, lc)
+
+	for _, c := range []struct {
+		code byte
+		name string
+	}{
+		{lamp.ExceptionIllegalAddress, "illegal data address"},
+		{lamp.ExceptionIllegalValue, "illegal data value"},
+		{lamp.ExceptionDeviceBusy, "slave device busy"},
+	} {
+		d.setException(c.code)
+
+		err := lc.Exec()
+		var excErr *lamp.ExceptionError
+		if !errors.As(err, &excErr) || excErr.Code != c.code || excErr.Function != modbus.FuncCodeWriteMultipleRegisters {
+			t.Errorf("exception %d: Exec() = %T %v, want an ExceptionError", c.code, err, err)
+			continue
+		}
+		if excErr.Name() != c.name {
+			t.Errorf("exception %d: Name() = %q, want %q", c.code, excErr.Name(), c.name)
+		}
+
+		// The controller answered, so the RPC fails its precondition
+		// rather than reporting the strip unavailable.
+		_, err = client.Normal(context.Background(), &lamprpc.FillRequest{Percent: 50})
+		if status.Code(err) != codes.FailedPrecondition || !strings.Contains(status.Convert(err).Message(), c.name) {
+			t.Errorf("exception %d: RPC error %v, want FailedPrecondition", c.code, err)
+		}
+	}
+}
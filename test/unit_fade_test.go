@@ -0,0 +1,130 @@
+package test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"lampwith-tag/lamp"
+)
+
+func TestFadeCurve(t *testing.T) {
+	a, b := lamp.Color{R: 0, G: 200, B: 255}, lamp.Color{R: 200, G: 0, B: 255}
+	for _, c := range []lamp.FadeCurve{lamp.FadeLinear, lamp.FadePerceptual} {
+		if got := c.Mix(a, b, 0); got != a {
+			t.Errorf("%s at 0: %v", c, got)
+		}
+		if got := c.Mix(a, b, 1); got != b {
+			t.Errorf("%s at 1: %v", c, got)
+		}
+	}
+	if got := lamp.FadeLinear.Mix(a, b, 0.5); got != (lamp.Color{R: 100, G: 100, B: 255}) {
+		t.Errorf("linear half way: %v", got)
+	}
+	// half the perceived lightness is a fifth of the light
+	if got := lamp.FadePerceptual.Mix(lamp.Color{}, lamp.Color{R: 255}, 0.5); got.R != 55 {
+		t.Errorf("perceptual half way: %v, want R 55", got)
+	}
+
+	for s, want := range map[string]time.Duration{"500ms": 500 * time.Millisecond, "2s": 2 * time.Second, "300": 300 * time.Millisecond, "off": 0, "0": 0} {
+		if got, err := lamp.ParseFade(s); err != nil || got != want {
+			t.Errorf("ParseFade(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"soon", "-1s", ""} {
+		if _, err := lamp.ParseFade(s); err == nil {
+			t.Errorf("ParseFade(%q) succeeded", s)
+		}
+	}
+}
+
+func TestExecFade(t *testing.T) {
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+	lc.SetQuantity(10)
+
+	opts := lc.State().Options
+	opts.ControlColor = "200,0,0"
+	if err := lc.Apply(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// the whole strip fades in fills, then gets its target frame
+	opts.ControlColor = "0,0,200"
+	opts.ControlFade = 200 * time.Millisecond
+	start := time.Now()
+	if err := lc.Apply(opts); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-lc.FadeDone():
+	case <-time.After(2 * time.Second):
+		t.Fatal("fade did not end")
+	}
+	if d := time.Since(start); d < 150*time.Millisecond {
+		t.Errorf("fade took %v, want about 200ms", d)
+	}
+
+	dev.mu.Lock()
+	frames := dev.frames[1:]
+	dev.mu.Unlock()
+	if len(frames) < 3 {
+		t.Fatalf("%d frames, want a few fade steps and the target", len(frames))
+	}
+	for i, f := range frames[:len(frames)-1] {
+		if f[0] != 3 || f[1] != 10 || f[3] == 200 || f[4] == 0 {
+			t.Errorf("fade frame %d: % x", i, f)
+		}
+	}
+	if f, want := dev.lastFrame(), []byte{3, 10, 0, 0, 200, 0}; !bytes.Equal(f, want) {
+		t.Errorf("last frame % x, want % x", f, want)
+	}
+
+	// a new command cancels the fade
+	opts.ControlColor = "0,200,0"
+	opts.ControlFade = 5 * time.Second
+	lc.Apply(opts)
+	time.Sleep(100 * time.Millisecond)
+	opts.ControlFade = 0
+	opts.ControlColor = "9,9,9"
+	if err := lc.Apply(opts); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-lc.FadeDone():
+	default:
+		t.Error("fade still running after a new command")
+	}
+	n := len(dev.frames)
+	time.Sleep(150 * time.Millisecond)
+	if len(dev.frames) != n || !bytes.Equal(dev.lastFrame(), []byte{3, 10, 9, 9, 9, 0}) {
+		t.Errorf("frames after the cancelled fade, last % x", dev.lastFrame())
+	}
+}
+
+func TestReplFade(t *testing.T) {
+	sh, lc, dev, out := newShell(t)
+	lc.SetQuantity(10)
+
+	if err := sh.Exec("set fade 100ms; single 3 red; show"); err != nil {
+		t.Fatal(err)
+	}
+	<-lc.FadeDone()
+	if s := out.String(); !strings.Contains(s, "fade: 100ms") {
+		t.Errorf("show:\n%s", s)
+	}
+	// one LED fades on its own
+	for _, f := range dev.frames {
+		if f[0] != 6 || f[1] != 3 {
+			t.Errorf("frame % x, want LED 3 only", f)
+		}
+	}
+	if f, want := dev.lastFrame(), []byte{6, 3, 0, 255, 0, 0}; !bytes.Equal(f, want) {
+		t.Errorf("last frame % x, want % x", f, want)
+	}
+
+	if err := sh.Exec("set fade 2m"); err == nil {
+		t.Error("fade of 2m accepted")
+	}
+}
@@ -0,0 +1,326 @@
+package test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"lampwith-tag/lamp"
+	"lampwith-tag/meter"
+)
+
+// writeWAV writes a mono 16-bit sine of amplitude amp lasting d.
+func writeWAV(t *testing.T, amp float64, d time.Duration) string {
+	const rate = 8000
+	n := int(d.Seconds() * rate)
+	data := make([]byte, 2*n)
+	for i := 0; i < n; i++ {
+		v := amp * 32767 * math.Sin(2*math.Pi*440*float64(i)/rate)
+		binary.LittleEndian.PutUint16(data[2*i:], uint16(int16(v)))
+	}
+
+	var b []byte
+	b = append(b, "RIFF"...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(36+len(data)))
+	b = append(b, "WAVEfmt "...)
+	b = binary.LittleEndian.AppendUint32(b, 16)
+	b = binary.LittleEndian.AppendUint16(b, 1)
+	b = binary.LittleEndian.AppendUint16(b, 1)
+	b = binary.LittleEndian.AppendUint32(b, rate)
+	b = binary.LittleEndian.AppendUint32(b, rate*2)
+	b = binary.LittleEndian.AppendUint16(b, 2)
+	b = binary.LittleEndian.AppendUint16(b, 16)
+	// 其他块应该被跳过
+	b = append(b, "LIST"...)
+	b = binary.LittleEndian.AppendUint32(b, 3)
+	b = append(b, 0, 0, 0, 0)
+	b = append(b, "data"...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(data)))
+	b = append(b, data...)
+
+	path := filepath.Join(t.TempDir(), "tone.wav")
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func rgb(p lamp.Pixel) lamp.Color { return lamp.Color{R: p.R, G: p.G, B: p.B} }
+
+// eventually polls cond for up to a second.
+func eventually(cond func() bool) bool {
+	for i := 0; i < 100; i++ {
+		if cond() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestCurve(t *testing.T) {
+	c := meter.Curve{Min: 20, Max: 120}
+	for v, want := range map[float64]float64{0: 0, 20: 0, 70: 0.5, 120: 1, 500: 1} {
+		if got := c.Map(v); got != want {
+			t.Errorf("linear %v: %v, want %v", v, got, want)
+		}
+	}
+
+	if err := c.ParseShape("log"); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Map(70); math.Abs(got-math.Log10(5.5)) > 1e-9 {
+		t.Errorf("log 70: %v", got)
+	}
+	if err := c.ParseShape("2"); err != nil || c.String() != "2" {
+		t.Fatalf("exponent: %v, %s", err, c)
+	}
+	if got := c.Map(70); got != 0.25 {
+		t.Errorf("power 70: %v", got)
+	}
+
+	for _, s := range []string{"cubic", "0", "-1"} {
+		if err := c.ParseShape(s); err == nil {
+			t.Errorf("curve %q accepted", s)
+		}
+	}
+}
+
+func TestWAVLevel(t *testing.T) {
+	src, err := meter.WAV(writeWAV(t, 0.5, 300*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	// RMS of a sine is its amplitude over √2
+	time.Sleep(120 * time.Millisecond)
+	if v := src.Value(); math.Abs(v-0.5/math.Sqrt2) > 0.01 {
+		t.Errorf("level %v while playing, want %.3f", v, 0.5/math.Sqrt2)
+	}
+	select {
+	case <-src.Done():
+		t.Error("300ms of audio done after 120ms")
+	default:
+	}
+
+	select {
+	case <-src.Done():
+	case <-time.After(time.Second):
+		t.Fatal("audio never ended")
+	}
+	if v := src.Value(); v != 0 || src.Err() != nil {
+		t.Errorf("after the end: %v, %v", v, src.Err())
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.wav")
+	os.WriteFile(bad, []byte("RIFF....AVI LIST"), 0o644)
+	if _, err := meter.WAV(bad); err == nil {
+		t.Error("AVI file accepted")
+	}
+	if _, err := meter.Open("song.mp3", nil, 0); err == nil {
+		t.Error("mp3 accepted")
+	}
+}
+
+func TestWAVHeader(t *testing.T) {
+	good, err := os.ReadFile(writeWAV(t, 0.5, 10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, edit := range map[string]func([]byte) []byte{
+		"truncated": func(b []byte) []byte { return b[:30] },
+		"huge fmt":  func(b []byte) []byte { binary.LittleEndian.PutUint32(b[16:], 0xffffffff); return b },
+		"tiny fmt":  func(b []byte) []byte { binary.LittleEndian.PutUint32(b[16:], 8); return b },
+		"channels":  func(b []byte) []byte { binary.LittleEndian.PutUint16(b[22:], 0xffff); return b },
+		"rate":      func(b []byte) []byte { binary.LittleEndian.PutUint32(b[24:], 0xffffffff); return b },
+	} {
+		path := filepath.Join(t.TempDir(), "bad.wav")
+		os.WriteFile(path, edit(append([]byte(nil), good...)), 0o644)
+		if src, err := meter.WAV(path); err == nil {
+			src.Close()
+			t.Errorf("%s header accepted", name)
+		}
+	}
+
+	for _, spec := range []string{"pcm:0", "pcm:4000000000", "pcm:44100:0", "pcm:44100:100000"} {
+		if _, err := meter.Open(spec, strings.NewReader(""), 0); err == nil {
+			t.Errorf("%s accepted", spec)
+		}
+	}
+	src := meter.PCM(strings.NewReader(""), 1<<30, 1<<20)
+	select {
+	case <-src.Done():
+	case <-time.After(time.Second):
+		t.Fatal("oversized PCM format still reading")
+	}
+	if src.Err() == nil {
+		t.Error("oversized PCM format read without an error")
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	src, err := meter.Open("-", strings.NewReader("12\n\nlots\n 7.5 \n"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-src.Done()
+	if v := src.Value(); v != 7.5 {
+		t.Errorf("value %v, want 7.5", v)
+	}
+
+	// a square wave at half scale
+	pcm := make([]byte, 4000)
+	for i := 0; i < len(pcm); i += 2 {
+		v := int16(16384)
+		if i%4 == 0 {
+			v = -v
+		}
+		binary.LittleEndian.PutUint16(pcm[i:], uint16(v))
+	}
+	r, w := io.Pipe()
+	go w.Write(pcm)
+	src, err = meter.Open("pcm:8000", r, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !eventually(func() bool { return src.Value() == 0.5 }) {
+		t.Errorf("square wave level %v, want 0.5", src.Value())
+	}
+	// the level drops when the stream ends
+	w.Close()
+	<-src.Done()
+	if v := src.Value(); v != 0 {
+		t.Errorf("level %v after the end", v)
+	}
+}
+
+func TestPoll(t *testing.T) {
+	bodies := map[string]string{
+		"/plain": "42\n",
+		"/json":  `{"line": {"rate": 87.5, "name": "press"}}`,
+		"/prom":  "# HELP x\n# TYPE line_rate gauge\nline_rate_total 3\nline_rate{line=\"a\"} 64 1700000000\n",
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := bodies[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer ts.Close()
+
+	for spec, want := range map[string]float64{
+		ts.URL + "/plain":          42,
+		ts.URL + "/json#line.rate": 87.5,
+		ts.URL + "/prom#line_rate": 64,
+	} {
+		src, err := meter.Open(spec, nil, 10*time.Millisecond)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !eventually(func() bool { return src.Value() == want }) {
+			t.Errorf("%s: %v, want %v (%v)", spec, src.Value(), want, src.Err())
+		}
+		src.Close()
+		<-src.Done()
+	}
+
+	for _, spec := range []string{ts.URL + "/json#line.name", ts.URL + "/json#speed", ts.URL + "/missing"} {
+		src, _ := meter.Open(spec, nil, 10*time.Millisecond)
+		if !eventually(func() bool { return src.Err() != nil }) {
+			t.Errorf("%s: no error", spec)
+		}
+		src.Close()
+		<-src.Done()
+	}
+}
+
+func TestFollowFrames(t *testing.T) {
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+	lc.SetQuantity(10)
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	level := 0.5
+	err := lc.Follow(lamp.Follow{
+		Level:   func() float64 { return level },
+		Color:   lamp.Color{R: 200},
+		Ranges:  "3-4",
+		Done:    done,
+		Stopped: func() { close(stopped) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !eventually(func() bool { return rgb(lc.State().Pixels[2]) == lamp.Color{R: 100} }) {
+		t.Errorf("LED 3 %v, want half of red 200", lc.State().Pixels[2])
+	}
+	if p := lc.State().Pixels; rgb(p[0]) != (lamp.Color{}) || rgb(p[3]) != (lamp.Color{R: 100}) || rgb(p[4]) != (lamp.Color{}) {
+		t.Errorf("pixels %v, want only 3-4 lit", p)
+	}
+
+	close(done)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("follow did not end with its signal")
+	}
+
+	if err := lc.Follow(lamp.Follow{Level: func() float64 { return 0 }, Ranges: "12-20"}); err == nil {
+		t.Error("ranges past the strip accepted")
+	}
+	if _, err := lamp.ParseFollowStyle("hue"); err == nil {
+		t.Error("style hue accepted")
+	}
+}
+
+func TestReplFollow(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "90")
+	}))
+	defer ts.Close()
+
+	sh, lc, _, out := newShell(t)
+	lc.SetQuantity(10)
+	if err := sh.Exec("follow " + ts.URL + " color green red min=60 max=120 every=20ms"); err != nil {
+		t.Fatal(err)
+	}
+	half := lamp.Color{R: 128, G: 128}
+	if !eventually(func() bool { return rgb(lc.State().Pixels[9]) == half }) {
+		t.Errorf("LED 10 %v, want halfway from green to red", lc.State().Pixels[9])
+	}
+	if !strings.Contains(out.String(), ts.URL) {
+		t.Errorf("output %q", out)
+	}
+
+	// commands that send nothing leave it following, stop ends it
+	if err := sh.Exec("show; set color blue"); err != nil {
+		t.Fatal(err)
+	}
+	sh.Exec("stop")
+	lc.Apply(fill(lamp.ModeNormal, "", "0,0,9"))
+	time.Sleep(60 * time.Millisecond)
+	if p := rgb(lc.State().Pixels[9]); p != (lamp.Color{B: 9}) {
+		t.Errorf("LED 10 %v after stop, still following", p)
+	}
+
+	for _, line := range []string{"follow", "follow -", "follow pcm:8000", "follow x.wav curve=cubic", "follow x.wav min=1 max=1", "follow x.wav red green", "follow x.wav 20-30", "follow x.wav every=soon", "follow x.wav gain=2"} {
+		if err := sh.Exec(line); err == nil {
+			t.Errorf("%q succeeded", line)
+		}
+	}
+	if err := sh.Exec("follow missing.wav"); err == nil {
+		t.Error("missing file succeeded")
+	}
+}
@@ -0,0 +1,105 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"lampwith-tag/lamp"
+)
+
+func TestFramebufferDiff(t *testing.T) {
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+	lc.SetQuantity(10)
+	red := lamp.Color{R: 200}
+
+	fb := lc.NewFramebuffer()
+	if fb.Len() != 10 {
+		t.Fatalf("Len %d, want 10", fb.Len())
+	}
+
+	// two LEDs: one write each
+	fb.Set(2, red)
+	fb.Set(7, red)
+	if err := fb.Push(); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]byte{{6, 3, 0, 200, 0, 0}, {6, 8, 0, 200, 0, 0}}
+	if !reflect.DeepEqual(dev.frames, want) {
+		t.Errorf("frames % x, want % x", dev.frames, want)
+	}
+
+	// nothing changed: nothing sent
+	if err := fb.Push(); err != nil || len(dev.frames) != 2 {
+		t.Errorf("second push sent %d frames, %v", len(dev.frames)-2, err)
+	}
+
+	// most of the strip: one fill and the odd LED
+	fb.Fill(red)
+	fb.Set(4, lamp.Color{B: 9})
+	if err := fb.Push(); err != nil {
+		t.Fatal(err)
+	}
+	want = append(want, []byte{3, 10, 0, 200, 0, 0}, []byte{6, 5, 0, 0, 9, 0})
+	if !reflect.DeepEqual(dev.frames, want) {
+		t.Errorf("frames % x, want % x", dev.frames, want)
+	}
+	if front := fb.Front(); front[4] != (lamp.Color{B: 9}) || front[9] != red {
+		t.Errorf("front %v", front)
+	}
+
+	// a frame from exec changes what Push compares with
+	lc.Control([]byte{3, 10, 0, 0, 0, 0})
+	n := len(dev.frames)
+	fb.Fill(lamp.Color{})
+	fb.Set(0, red)
+	if err := fb.Push(); err != nil {
+		t.Fatal(err)
+	}
+	if got := dev.frames[n:]; !reflect.DeepEqual(got, [][]byte{{6, 1, 0, 200, 0, 0}}) {
+		t.Errorf("frames after exec % x", got)
+	}
+
+	lc.SetQuantity(12)
+	if err := fb.Push(); !errors.Is(err, lamp.ErrFrameSize) {
+		t.Errorf("push to a longer strip: %v, want ErrFrameSize", err)
+	}
+	fb.Reset()
+	if fb.Len() != 12 || fb.At(0) != red {
+		t.Errorf("after Reset: %d LEDs, first %v", fb.Len(), fb.At(0))
+	}
+}
+
+func TestFramebufferBuffer(t *testing.T) {
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+	lc.SetQuantity(100)
+
+	fb := lc.NewFramebuffer()
+	fb.Buffer = 100
+	for i := 10; i < 100; i++ {
+		fb.Set(i, lamp.Color{R: byte(i), G: 1, B: 2})
+	}
+	if err := fb.Push(); err != nil {
+		t.Fatal(err)
+	}
+
+	// LEDs 10 to 99 are bytes 30 to 299, registers 15 to 149
+	if n := len(dev.frames); n != 2 {
+		t.Fatalf("%d writes, want 2", n)
+	}
+	if r := dev.regs[100+15 : 100+17]; r[0] != 0x010a || r[1] != 0x0201 {
+		t.Errorf("registers % x, want 010a 0201", r)
+	}
+	if r := dev.regs[100+149]; r != 0x6302 {
+		t.Errorf("last register %04x, want 6302", r)
+	}
+	if p := lc.State().Pixels[99]; p.R != 99 || p.G != 1 || p.B != 2 {
+		t.Errorf("pixel model %+v", p)
+	}
+
+	if err := fb.Push(); err != nil || len(dev.frames) != 2 {
+		t.Errorf("second push wrote %d times, %v", len(dev.frames)-2, err)
+	}
+}
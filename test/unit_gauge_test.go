@@ -0,0 +1,209 @@
+package test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"lampwith-tag/lamp"
+	"lampwith-tag/lamprpc"
+)
+
+func TestGaugeFrame(t *testing.T) {
+	lc := lamp.New(newSimClient(newSimDevice()))
+	lc.SetQuantity(10)
+	g := lc.NewGauge()
+
+	amber, red, green := lamp.Color{R: 255, G: 191}, lamp.Color{R: 255}, lamp.Color{G: 255}
+	cases := map[float64][]lamp.Color{
+		// 7 LEDs and 0.3 of the 8th, amber from 70
+		73:  {amber, amber, amber, amber, amber, amber, amber, {R: 76, G: 57}, {}, {}},
+		50:  {green, green, green, green, green, {}, {}, {}, {}, {}},
+		95:  {red, red, red, red, red, red, red, red, red, {R: 128}},
+		-5:  make([]lamp.Color, 10),
+		150: {red, red, red, red, red, red, red, red, red, red},
+	}
+	for v, want := range cases {
+		frame := g.Frame(v)
+		for i := range want {
+			if frame[i] != want[i] {
+				t.Errorf("%v: LED %d %v, want %v", v, i+1, frame[i], want[i])
+			}
+		}
+	}
+
+	s := lamp.GaugeSettings{Min: 10, Max: 30, Reverse: true, Thresholds: []lamp.Threshold{{At: 0, Color: lamp.Color{B: 200}}}}
+	if err := g.Configure(s); err != nil {
+		t.Fatal(err)
+	}
+	frame := g.Frame(15)
+	if frame[9] != (lamp.Color{B: 200}) || frame[8] != (lamp.Color{B: 200}) || frame[7] != (lamp.Color{B: 100}) || frame[0] != (lamp.Color{}) {
+		t.Errorf("reversed 15 of 10..30: %v", frame)
+	}
+
+	for _, s := range []lamp.GaugeSettings{
+		{Min: 5, Max: 5},
+		{Min: 0, Max: 100, Thresholds: []lamp.Threshold{{At: 50}, {At: 10}}},
+		{Min: 0, Max: 100, Thresholds: []lamp.Threshold{{At: math.NaN()}}},
+		{Min: 0, Max: 100, Ease: -time.Second},
+	} {
+		if err := g.Configure(s); err == nil {
+			t.Errorf("settings %+v accepted", s)
+		}
+	}
+	if err := g.Set(math.Inf(1)); err == nil {
+		t.Error("value +Inf accepted")
+	}
+}
+
+func TestGaugeAnimates(t *testing.T) {
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+	lc.SetQuantity(10)
+	g := lc.NewGauge()
+	s := lamp.DefaultGaugeSettings
+	s.Ease = 300 * time.Millisecond
+	g.Configure(s)
+
+	if err := g.Set(100); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	// grows from empty, not there yet
+	if p := rgb(lc.State().Pixels[9]); p != (lamp.Color{}) {
+		t.Errorf("LED 10 %v a third of the way up", p)
+	}
+	if !eventually(func() bool { return rgb(lc.State().Pixels[9]) == lamp.Color{R: 255} }) {
+		t.Fatalf("LED 10 %v, want the full bar red", lc.State().Pixels[9])
+	}
+	if n := frameCount(dev); n < 4 {
+		t.Errorf("%d frames, want the bar to move in steps", n)
+	}
+
+	// settled: no frames until the next value
+	n := frameCount(dev)
+	time.Sleep(100 * time.Millisecond)
+	if frameCount(dev) != n {
+		t.Error("frames sent while the bar stood still")
+	}
+
+	s.Ease = 0
+	g.Configure(s)
+	g.Set(20)
+	if !eventually(func() bool {
+		return rgb(lc.State().Pixels[2]) == lamp.Color{} && rgb(lc.State().Pixels[1]) == lamp.Color{G: 255}
+	}) {
+		t.Errorf("pixels %v, want 2 green LEDs", lc.State().Pixels)
+	}
+	if !g.Showing() {
+		t.Error("gauge not showing")
+	}
+	lc.Apply(fill(lamp.ModeNormal, "", "0,0,9"))
+	if g.Showing() {
+		t.Error("gauge still showing after Apply")
+	}
+}
+
+func TestReplGauge(t *testing.T) {
+	sh, lc, _, out := newShell(t)
+	lc.SetQuantity(10)
+	if err := sh.Exec("gauge 5"); err == nil {
+		t.Error("gauge without a gauge succeeded")
+	}
+	g := lc.NewGauge()
+	sh.SetGauge(g)
+
+	if err := sh.Exec("gauge value=45 min=0 max=50 ease=0"); err != nil {
+		t.Fatal(err)
+	}
+	if !eventually(func() bool { return rgb(lc.State().Pixels[8]) == lamp.Color{R: 255} }) {
+		t.Errorf("pixels %v, want 9 red LEDs", lc.State().Pixels)
+	}
+	// commands that send nothing leave the gauge showing
+	if err := sh.Exec("show; set color blue"); err != nil || !g.Showing() {
+		t.Errorf("show stopped the gauge: %v", err)
+	}
+
+	if err := sh.Exec("gauge reverse 0:blue 40:#ff00ff"); err != nil {
+		t.Fatal(err)
+	}
+	if s := g.Settings(); !s.Reverse || len(s.Thresholds) != 2 || s.Max != 50 || g.Value() != 45 {
+		t.Errorf("settings %+v, value %v", s, g.Value())
+	}
+	sh.Exec("gauge")
+	for _, want := range []string{"Gauge settings changed", "Gauge at 45 of 0 to 50", "from the last LED", "255,0,255"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output misses %q:\n%s", want, out)
+		}
+	}
+
+	for _, line := range []string{"gauge 5:nocolor", "gauge sideways", "gauge min=10 max=5", "gauge 50:red 10:blue", "gauge speed=3", "gauge value=x", "gauge ease=soon"} {
+		if err := sh.Exec(line); err == nil {
+			t.Errorf("%q succeeded", line)
+		}
+	}
+
+	if err := sh.Exec("stop"); err != nil || g.Showing() {
+		t.Errorf("stop left the gauge showing: %v", err)
+	}
+}
+
+func TestRPCGauge(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	lc := lamp.New(newSimClient(newSimDevice()))
+	lc.SetQuantity(10)
+
+	if _, err := newRPCClient(t, lc).SetGauge(ctx, &lamprpc.SetGaugeRequest{Value: 1}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("SetGauge without a gauge: %v", err)
+	}
+
+	srv := lamprpc.NewServer(lc)
+	g := lc.NewGauge()
+	srv.ServeGauge(g)
+	client := serveRPC(t, srv)
+
+	res, err := client.SetGauge(ctx, &lamprpc.SetGaugeRequest{Value: 3, Settings: &lamprpc.GaugeSettings{
+		Min: 0, Max: 10,
+		Thresholds: []*lamprpc.GaugeThreshold{{At: 0, Color: &lamprpc.Color{G: 255}}, {At: 8, Color: &lamprpc.Color{R: 255}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Value != 3 || !res.Showing || res.Settings.Max != 10 || len(res.Settings.Thresholds) != 2 {
+		t.Errorf("SetGauge returned %v", res)
+	}
+	if !eventually(func() bool { return rgb(lc.State().Pixels[2]) == lamp.Color{G: 255} }) {
+		t.Errorf("pixels %v, want 3 green LEDs", lc.State().Pixels)
+	}
+
+	_, err = client.SetGauge(ctx, &lamprpc.SetGaugeRequest{Value: 1, Settings: &lamprpc.GaugeSettings{Min: 5, Max: 5}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("min = max: %v", err)
+	}
+
+	stream, err := client.FeedGauge(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []float64{4, 6, 9} {
+		if err := stream.Send(&lamprpc.GaugeValue{Value: v}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	res, err = stream.CloseAndRecv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Value != 9 || g.Value() != 9 {
+		t.Errorf("after the feed: %v", res)
+	}
+	if !eventually(func() bool { return rgb(lc.State().Pixels[8]) == lamp.Color{R: 255} }) {
+		t.Errorf("pixels %v, want 9 red LEDs", lc.State().Pixels)
+	}
+}
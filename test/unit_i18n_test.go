@@ -0,0 +1,124 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goburrow/modbus"
+
+	"lampwith-tag/i18n"
+	"lampwith-tag/lamp"
+	"lampwith-tag/link"
+	"lampwith-tag/meter"
+	"lampwith-tag/repl"
+)
+
+var verb = regexp.MustCompile(`%[-+# 0]*[0-9]*[vdsqxXc%]`)
+
+func TestI18nCatalogs(t *testing.T) {
+	locales := i18n.Locales()
+	if len(locales) < 2 {
+		t.Fatalf("locales %v, want en and zh", locales)
+	}
+
+	for _, a := range locales {
+		for key, msg := range i18n.Catalog(a) {
+			for _, b := range locales {
+				other, ok := i18n.Catalog(b)[key]
+				if !ok {
+					t.Errorf("key %q of %s is missing in %s", key, a, b)
+					continue
+				}
+				if va, vb := verbs(msg), verbs(other); va != vb {
+					t.Errorf("key %q: %s has verbs %q, %s has %q", key, a, va, b, vb)
+				}
+			}
+		}
+	}
+}
+
+// verbs returns the formatting verbs of a message that takes arguments.
+// Messages printed without arguments may contain a literal %.
+func verbs(msg string) string {
+	s := ""
+	for _, v := range verb.FindAllString(msg, -1) {
+		if v != "%%" {
+			s += v[len(v)-1:]
+		}
+	}
+	return s
+}
+
+func TestI18nDetect(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+
+	t.Setenv("LANG", "")
+	if l := i18n.Detect(""); l != "zh" {
+		t.Errorf("no LANG: %s, want zh", l)
+	}
+	t.Setenv("LANG", "en_US.UTF-8")
+	if l := i18n.Detect(""); l != "en" {
+		t.Errorf("LANG=en_US.UTF-8: %s, want en", l)
+	}
+	if l := i18n.Detect("zh"); l != "zh" {
+		t.Errorf("-lang zh: %s, want zh", l)
+	}
+	// an unknown -lang reaches SetLocale instead of turning into English
+	if l := i18n.Detect("fr"); l != "fr" {
+		t.Errorf("-lang fr: %s, want fr", l)
+	}
+	if err := i18n.SetLocale(i18n.Detect("fr")); err == nil {
+		t.Error("-lang fr accepted")
+	}
+}
+
+func TestI18nErrors(t *testing.T) {
+	if err := i18n.SetLocale("zh"); err != nil {
+		t.Fatal(err)
+	}
+	lc := lamp.New(newSimClient(newSimDevice()))
+
+	// Error stays English for logs and gRPC, the console tells it in zh
+	err := lc.SetQuantity(0)
+	if want := "lamp: invalid option: quantity 0 must be between 1 and 255"; err.Error() != want {
+		t.Errorf("Error() %q, want %q", err, want)
+	}
+	if got := i18n.Error(err); got != "灯珠数量 0 必须在 1 到 255 之间" {
+		t.Errorf("zh %q", got)
+	}
+
+	sh := repl.New(lc, nil, nil, &bytes.Buffer{})
+	if err := sh.Exec("set ranges 50-60"); err == nil || !strings.Contains(err.Error(), `范围 "50-60" 超出第 1 到 30 颗灯珠或为空`) {
+		t.Errorf("console error %v", err)
+	}
+
+	exc := &lamp.ExceptionError{Function: 16, Code: lamp.ExceptionDeviceBusy}
+	if got := i18n.Error(exc); !strings.Contains(got, "控制器忙") || !strings.Contains(exc.Error(), "slave device busy") {
+		t.Errorf("exception %q, %q", got, exc)
+	}
+	if got := i18n.T("repl.control_error", &lamp.TimeoutError{Err: errors.New("x")}); !strings.Contains(got, "控制器没有应答") {
+		t.Errorf("control error %q", got)
+	}
+
+	// errors along the chain are told too
+	dev := newSimDevice()
+	dev.setFail(errors.New("sim: i/o error"))
+	dial := func() (modbus.Client, io.Closer, error) { return newSimClient(dev), nopCloser{}, nil }
+	client, err := link.New(dial, link.Policy{Retries: 1, Backoff: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = lamp.New(client).Control([]byte{3, 1, 0, 0, 0, 0})
+	if got := i18n.Error(err); !strings.Contains(got, "尝试 2 次后放弃: sim: i/o error") {
+		t.Errorf("link %q", got)
+	}
+	if _, err := meter.Open("song.mp3", nil, 0); err == nil || !strings.Contains(i18n.Error(err), "不是 .wav 文件") {
+		t.Errorf("meter %v", err)
+	}
+}
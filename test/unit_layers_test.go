@@ -0,0 +1,242 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"lampwith-tag/alert"
+	"lampwith-tag/lamp"
+	"lampwith-tag/scene"
+)
+
+func fill(mode int, ranges, color string) lamp.Options {
+	return lamp.Options{ControlMode: mode, ControlPercentage: 100, ControlPosition: 1, ControlRanges: ranges, ControlColor: color}
+}
+
+func frameCount(dev *simDevice) int {
+	dev.mu.Lock()
+	defer dev.mu.Unlock()
+	return len(dev.frames)
+}
+
+// waitFrames waits for the compositor to push and returns the frames sent.
+func waitFrames(dev *simDevice, after int) [][]byte {
+	for i := 0; i < 50 && frameCount(dev) <= after; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	dev.mu.Lock()
+	defer dev.mu.Unlock()
+	return dev.frames[after:]
+}
+
+func TestCompositorBlend(t *testing.T) {
+	lc := lamp.New(newSimClient(newSimDevice()))
+	lc.SetQuantity(10)
+	c := lc.NewCompositor()
+
+	layers := []lamp.Layer{
+		{Name: "bg", Options: fill(lamp.ModeNormal, "", "0,100,0"), Opacity: 1},
+		{Name: "pick", Options: fill(lamp.ModeNormal, "3-4", "255,0,0"), Blend: lamp.BlendAdd, Opacity: 0.5},
+		{Name: "tint", Options: fill(lamp.ModeSingle, "", "255,128,255"), Blend: lamp.BlendMultiply, Opacity: 1},
+	}
+	layers[2].ControlPosition = 9
+	for _, l := range layers {
+		if err := c.Set(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	frame, tick := c.Frame(0)
+	want := map[int]lamp.Color{0: {G: 100}, 2: {R: 128, G: 100}, 3: {R: 128, G: 100}, 4: {G: 100}, 8: {G: 50}, 9: {G: 100}}
+	for i, c := range want {
+		if frame[i] != c {
+			t.Errorf("LED %d: %v, want %v", i+1, frame[i], c)
+		}
+	}
+	if tick != 0 {
+		t.Errorf("tick %v for steady layers", tick)
+	}
+
+	// a higher Z goes on top whatever the order
+	c.Set(lamp.Layer{Name: "top", Options: fill(lamp.ModeNormal, "3", "0,0,255"), Opacity: 1, Z: 5})
+	c.Set(lamp.Layer{Name: "under", Options: fill(lamp.ModeNormal, "3", "255,255,255"), Opacity: 1, Z: 1})
+	if frame, _ := c.Frame(0); frame[2] != (lamp.Color{B: 255}) {
+		t.Errorf("LED 3: %v, want the Z 5 layer", frame[2])
+	}
+	if names := c.Layers(); names[len(names)-1].Name != "top" {
+		t.Errorf("top layer %q", names[len(names)-1].Name)
+	}
+
+	for _, l := range []lamp.Layer{
+		{Options: fill(lamp.ModeNormal, "", "red"), Opacity: 1},
+		{Name: "x", Options: fill(lamp.ModeNormal, "", "red"), Opacity: 2},
+		{Name: "x", Options: fill(lamp.ModeNormal, "20-30", "red"), Opacity: 1},
+	} {
+		if err := c.Set(l); err == nil {
+			t.Errorf("layer %+v accepted", l)
+		}
+	}
+	if _, err := lamp.ParseBlend("screen"); err == nil {
+		t.Error("blend screen accepted")
+	}
+}
+
+func TestCompositorAnimates(t *testing.T) {
+	lc := lamp.New(newSimClient(newSimDevice()))
+	lc.SetQuantity(10)
+	c := lc.NewCompositor()
+
+	breathe := lamp.Layer{Name: "b", Options: fill(lamp.ModeBreathe, "1-2", "200,0,0"), Opacity: 1}
+	breathe.ControlPeriod = 2 * time.Second
+	strobe := lamp.Layer{Name: "s", Options: fill(lamp.ModeStrobe, "5", "0,0,200"), Opacity: 1}
+	strobe.ControlPeriod = 200 * time.Millisecond
+	c.Set(breathe)
+	c.Set(strobe)
+
+	frame, tick := c.Frame(0)
+	if frame[0] != (lamp.Color{}) || frame[4] != (lamp.Color{B: 200}) {
+		t.Errorf("at 0: %v", frame)
+	}
+	if tick != 50*time.Millisecond {
+		t.Errorf("tick %v, want 50ms", tick)
+	}
+	frame, _ = c.Frame(1150 * time.Millisecond)
+	// 0.85 of the way up, strobe in its dark half
+	if frame[0] != (lamp.Color{R: 170}) || frame[4] != (lamp.Color{}) {
+		t.Errorf("at 1.15s: %v", frame)
+	}
+}
+
+func TestCompositorPush(t *testing.T) {
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+	lc.SetQuantity(10)
+	c := lc.NewCompositor()
+
+	if err := c.Set(lamp.Layer{Name: "bg", Options: fill(lamp.ModeNormal, "", "0,100,0"), Opacity: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if frames := waitFrames(dev, 0); len(frames) != 1 || frames[0][0] != lamp.ModeNormal {
+		t.Errorf("background frames % x, want one fill", frames)
+	}
+	if !c.Showing() {
+		t.Error("compositor not showing")
+	}
+
+	// only the highlighted LEDs are sent
+	n := frameCount(dev)
+	c.Set(lamp.Layer{Name: "pick", Options: fill(lamp.ModeNormal, "3-4", "255,0,0"), Opacity: 1})
+	frames := waitFrames(dev, n)
+	if len(frames) != 2 || frames[0][0] != lamp.ModeSingle || frames[0][1] != 3 || frames[1][1] != 4 {
+		t.Errorf("highlight frames % x, want LEDs 3 and 4", frames)
+	}
+
+	// alerts go on top while layers show, and leave them when cleared
+	alerts := alert.NewManager(lc, nil)
+	alerts.UseLayers(c)
+	if err := alerts.Raise(alert.Info, "shift", 0); err != nil {
+		t.Fatal(err)
+	}
+	if l, ok := c.Layer("alert"); !ok || l.Z != alert.LayerZ {
+		t.Errorf("alert layer %+v, %v", l, ok)
+	}
+	waitFrames(dev, frameCount(dev))
+	if p := lc.State().Pixels[2]; p.B != 255 {
+		t.Errorf("LED 3 under the alert: %+v", p)
+	}
+	alerts.Clear("")
+	if _, ok := c.Layer("alert"); ok {
+		t.Error("alert layer left after clear")
+	}
+	waitFrames(dev, frameCount(dev))
+	if p := lc.State().Pixels[2]; p.R != 255 || p.B != 0 {
+		t.Errorf("LED 3 after the alert: %+v", p)
+	}
+
+	// a direct command takes the strip over
+	if err := lc.Apply(fill(lamp.ModeNormal, "", "9,9,9")); err != nil {
+		t.Fatal(err)
+	}
+	if c.Showing() {
+		t.Error("compositor still showing after Apply")
+	}
+}
+
+func TestLayerScene(t *testing.T) {
+	lc := lamp.New(newSimClient(newSimDevice()))
+	lc.SetQuantity(10)
+	c := lc.NewCompositor()
+	c.Set(lamp.Layer{Name: "pick", Options: fill(lamp.ModeNormal, "3", "255,0,0"), Opacity: 1})
+
+	seq := &scene.Sequence{Name: "bg", Loop: -1, Steps: []scene.Step{
+		{Mode: "normal", Color: "0,0,50", Percent: 100, Duration: scene.Duration(time.Hour)},
+	}}
+	player := scene.NewPlayer(c.Target("bg"))
+	player.Play(seq, nil)
+	defer player.Stop()
+	time.Sleep(50 * time.Millisecond)
+
+	l, ok := c.Layer("bg")
+	if !ok || l.ControlColor != "0,0,50" || l.Opacity != 1 {
+		t.Fatalf("scene layer %+v, %v", l, ok)
+	}
+	// the scene's layer went on top of the highlight
+	if frame, _ := c.Frame(0); frame[2] != (lamp.Color{B: 50}) {
+		t.Errorf("LED 3: %v", frame[2])
+	}
+}
+
+func TestReplLayer(t *testing.T) {
+	sh, lc, _, out := newShell(t)
+	lc.SetQuantity(10)
+	if err := sh.Exec("layer"); err == nil {
+		t.Error("layer without a compositor succeeded")
+	}
+	c := lc.NewCompositor()
+	sh.SetLayers(c)
+
+	if err := sh.Exec("layer bg normal green; layer pick normal 3-4 red blend=add opacity=50%; layer list"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"pick", "add", " 50%", "bg", "replace"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output misses %q:\n%s", want, out)
+		}
+	}
+	if l, _ := c.Layer("pick"); l.ControlRanges != "3-4" || l.ControlColor != "255,0,0" {
+		t.Errorf("pick layer %+v", l)
+	}
+	if frame, _ := c.Frame(0); frame[2] != (lamp.Color{R: 128, G: 255}) || frame[5] != (lamp.Color{G: 255}) {
+		t.Errorf("frame %v", frame)
+	}
+
+	// commands that send nothing leave the layers running
+	time.Sleep(30 * time.Millisecond)
+	if err := sh.Exec("show"); err != nil || !c.Showing() {
+		t.Errorf("show stopped the layers: %v", err)
+	}
+
+	for _, line := range []string{"layer pick normal blend=screen", "layer pick normal opacity=150%", "layer remove nope", "layer x sideways", "layer x normal quantity=5", "layer x play", "layer y normal red; layer x normal opacity=NaN"} {
+		if err := sh.Exec(line); err == nil {
+			t.Errorf("%q succeeded", line)
+		}
+	}
+	// the whole line is checked first: y was not added before x failed
+	for _, l := range c.Layers() {
+		if l.Name == "y" || l.Name == "x" {
+			t.Errorf("layer %s added by a bad line", l.Name)
+		}
+	}
+
+	if err := sh.Exec("layer remove pick"); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Layers()) != 1 {
+		t.Errorf("layers after remove: %+v", c.Layers())
+	}
+	if err := sh.Exec("layer clear"); err != nil || len(c.Layers()) != 0 {
+		t.Errorf("clear: %v, %+v", err, c.Layers())
+	}
+}
@@ -0,0 +1,175 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goburrow/modbus"
+
+	"lampwith-tag/lamp"
+	"lampwith-tag/link"
+)
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+func TestLinkReconnect(t *testing.T) {
+	d := newSimDevice()
+	dials := 0
+	unplugged := false
+	dial := func() (modbus.Client, io.Closer, error) {
+		dials++
+		if unplugged {
+			return nil, nil, errors.New("sim: no such device")
+		}
+		return newSimClient(d), nopCloser{}, nil
+	}
+
+	policy := link.Policy{Retries: 2, Backoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+	client, err := link.New(dial, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lc := lamp.New(client)
+	reconnected := make(chan struct{}, 1)
+	client.OnReconnect = func() { reconnected <- struct{}{} }
+
+	// The adapter is gone: every attempt fails.
+	d.setFail(errors.New("sim: i/o error"))
+	unplugged = true
+	if err := lc.Control([]byte{3, 0x64, 9, 0, 0, 0}); err == nil {
+		t.Fatal("control succeeded without a device")
+	}
+	if dials != 3 {
+		t.Errorf("%d dials, want 3", dials)
+	}
+
+	// It is back: the next request reopens the port and goes through.
+	d.setFail(nil)
+	unplugged = false
+	frame := []byte{3, 0x64, 0, 9, 0, 0}
+	if err := lc.Control(frame); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(d.lastFrame(), frame) {
+		t.Errorf("device got % x, want % x", d.lastFrame(), frame)
+	}
+	select {
+	case <-reconnected:
+	case <-time.After(time.Second):
+		t.Fatal("OnReconnect not called")
+	}
+}
+
+func TestLinkDeviceException(t *testing.T) {
+	d := newSimDevice()
+	dials := 0
+	client, err := link.New(func() (modbus.Client, io.Closer, error) {
+		dials++
+		return newSimClient(d), nopCloser{}, nil
+	}, link.DefaultPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The device answers reads with an exception. That is an answer, not a
+	// broken link, so there is no retry.
+	if err := lamp.New(client).Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if dials != 1 {
+		t.Errorf("%d dials, want 1", dials)
+	}
+}
+
+func TestLinkReopenAfterClose(t *testing.T) {
+	d := newSimDevice()
+	client, err := link.New(func() (modbus.Client, io.Closer, error) {
+		return newSimClient(d), nopCloser{}, nil
+	}, link.DefaultPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	reconnected := make(chan struct{}, 1)
+	client.OnReconnect = func() { reconnected <- struct{}{} }
+
+	// The daemon closes the port of a strip that was pulled out and sends
+	// the state itself when it comes back: the state must go out once.
+	client.Close()
+	lc := lamp.New(client)
+	if err := lc.Control([]byte{3, 0x64, 9, 0, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-reconnected:
+		t.Fatal("OnReconnect called for a port reopened after Close")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestLinkReconnectResends(t *testing.T) {
+	d := newSimDevice()
+	var unplugged atomic.Bool
+	dial := func() (modbus.Client, io.Closer, error) {
+		if unplugged.Load() {
+			return nil, nil, errors.New("sim: no such device")
+		}
+		return newSimClient(d), nopCloser{}, nil
+	}
+	client, err := link.New(dial, link.Policy{Retries: 1, Backoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lc := lamp.New(client)
+	lc.SetQuantity(10)
+	reconnected := make(chan error, 1)
+	client.OnReconnect = func() { reconnected <- lc.Resend() }
+
+	lc.SetOptions(fill(lamp.ModeNormal, "", "9,0,0"))
+	g := lc.NewGauge()
+	s := lamp.DefaultGaugeSettings
+	s.Ease = 0
+	g.Configure(s)
+	g.Set(50)
+	if !eventually(func() bool { return bytes.Equal(d.lastFrame(), []byte{lamp.ModeNormal, 5, 255, 0, 0, 0}) }) {
+		t.Fatalf("frame % x, want half the bar", d.lastFrame())
+	}
+
+	// The port goes away while the bar moves and comes back: the state
+	// goes out again and the gauge draws over it.
+	d.setFail(errors.New("sim: i/o error"))
+	unplugged.Store(true)
+	g.Set(20)
+	time.Sleep(30 * time.Millisecond)
+	d.setFail(nil)
+	unplugged.Store(false)
+	g.Set(60)
+	select {
+	case err := <-reconnected:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnReconnect not called")
+	}
+	if !g.Showing() {
+		t.Error("gauge not showing after the reconnect")
+	}
+	if !eventually(func() bool { return bytes.Equal(d.lastFrame(), []byte{lamp.ModeNormal, 6, 255, 0, 0, 0}) }) {
+		t.Errorf("frame % x after the reconnect, want the bar", d.lastFrame())
+	}
+	var sent bool
+	d.mu.Lock()
+	for _, f := range d.frames {
+		sent = sent || bytes.Equal(f, []byte{lamp.ModeNormal, 10, 0, 9, 0, 0})
+	}
+	d.mu.Unlock()
+	if !sent {
+		t.Error("the options were not sent again")
+	}
+}
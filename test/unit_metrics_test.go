@@ -0,0 +1,59 @@
+package test
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goburrow/modbus"
+	"github.com/goburrow/serial"
+
+	"lampwith-tag/lamp"
+	"lampwith-tag/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New()
+	d := newSimDevice()
+	handler := modbus.NewRTUClientHandler("sim")
+	handler.SlaveId = d.slaveID
+	lc := lamp.New(modbus.NewClient2(handler, m.Transport(d, "sim0")))
+
+	stop := m.Watch("sim0", lc)
+	lc.SetColor(255, 0, 0)
+	lc.SetMode(lamp.ModeBreathe)
+	if err := lc.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	lc.Ping() // answered with an illegal function exception
+	d.setFail(serial.ErrTimeout)
+	lc.Exec()
+	m.Reconnected("sim0")
+	m.SetOnline("sim0", true)
+	stop()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	for _, want := range []string{
+		`lampwith_modbus_transactions_total{function="16",port="sim0"} 2`,
+		`lampwith_modbus_transactions_total{function="3",port="sim0"} 1`,
+		`lampwith_modbus_errors_total{exception="1",port="sim0",type="exception"} 1`,
+		`lampwith_modbus_errors_total{exception="",port="sim0",type="timeout"} 1`,
+		`lampwith_modbus_request_duration_seconds_count{port="sim0"} 3`,
+		`lampwith_reconnects_total{port="sim0"} 1`,
+		`lampwith_strip_online{port="sim0"} 1`,
+		`lampwith_strip_mode{port="sim0"} 4`,
+		`lampwith_strip_color{channel="r",port="sim0"} 255`,
+		`lampwith_frames_total{mode="breathe",port="sim0"} 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+	if t.Failed() {
+		t.Log(string(body))
+	}
+}
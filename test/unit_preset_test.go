@@ -0,0 +1,56 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"lampwith-tag/lamp"
+	"lampwith-tag/preset"
+)
+
+func TestPresetStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "presets.json")
+
+	s, err := preset.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(s.List()); n != 13 {
+		t.Fatalf("%d default presets, want 13", n)
+	}
+
+	opts := lamp.Options{ControlMode: lamp.ModeBreathe, ControlPercentage: 40, ControlPosition: 2, ControlColor: "1,2,3"}
+	if err := s.Save(preset.FromOptions("warm", opts)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete("0"); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = preset.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Get("0"); ok {
+		t.Error("deleted preset was saved")
+	}
+	p, ok := s.Get("warm")
+	if !ok {
+		t.Fatal("saved preset missing after reload")
+	}
+	if got, _ := p.Options(); got != opts {
+		t.Errorf("options %+v, want %+v", got, opts)
+	}
+	if list := s.List(); list[len(list)-1].Name != "warm" {
+		t.Errorf("new preset not appended: %v", list)
+	}
+
+	for _, name := range []string{"q", "exec", "", "two words", "percent=5", "alert", "layer", "follow", "gauge"} {
+		if err := s.Save(preset.FromOptions(name, opts)); err == nil {
+			t.Errorf("saved preset named %q", name)
+		}
+	}
+	if err := s.Delete("missing"); err == nil {
+		t.Error("deleted a missing preset")
+	}
+}
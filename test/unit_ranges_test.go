@@ -0,0 +1,156 @@
+package test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"lampwith-tag/lamp"
+)
+
+func TestParseRanges(t *testing.T) {
+	cases := map[string][]lamp.Range{
+		"10-20":       {{From: 10, To: 20}},
+		"7":           {{From: 7, To: 7}},
+		"25-":         {{From: 25, To: 30}},
+		"-5":          {{From: 26, To: 30}},
+		"20%":         {{From: 1, To: 6}},
+		"-20%":        {{From: 25, To: 30}},
+		"25%-50%":     {{From: 8, To: 15}},
+		"12-15, 1-3":  {{From: 1, To: 3}, {From: 12, To: 15}},
+		"1-5,4-8,9":   {{From: 1, To: 9}},
+		"1-2,4-5,3-3": {{From: 1, To: 5}},
+	}
+	for s, want := range cases {
+		got, err := lamp.ParseRanges(s, 30)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseRanges(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+
+	for _, s := range []string{"", "0", "20-10", "25-31", "-31", "a-b", "150%", "1-5,x", "-0"} {
+		if _, err := lamp.ParseRanges(s, 30); err == nil {
+			t.Errorf("ParseRanges(%q) succeeded", s)
+		}
+	}
+
+	if s := lamp.FormatRanges([]lamp.Range{{From: 1, To: 3}, {From: 7, To: 7}}); s != "1-3,7" {
+		t.Errorf("FormatRanges = %q", s)
+	}
+}
+
+func TestExecRanges(t *testing.T) {
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+	lc.SetQuantity(10)
+
+	// a range from the first LED is one native frame
+	opts := lc.State().Options
+	opts.ControlColor = "255,0,0"
+	opts.ControlRanges = "1-4"
+	if err := lc.Apply(opts); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(dev.frames); n != 1 {
+		t.Errorf("%d frames for 1-4, want 1", n)
+	}
+	if f, want := dev.lastFrame(), []byte{3, 4, 0, 255, 0, 0}; !bytes.Equal(f, want) {
+		t.Errorf("frame % x, want % x", f, want)
+	}
+
+	// anything else is a fill of the leading range, then pixel by pixel
+	opts.ControlRanges = "1-2,6-7,-1"
+	if err := lc.Apply(opts); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]byte{
+		{3, 2, 0, 255, 0, 0},
+		{6, 6, 0, 255, 0, 0},
+		{6, 7, 0, 255, 0, 0},
+		{6, 10, 0, 255, 0, 0},
+	}
+	if got := dev.frames[1:]; !reflect.DeepEqual(got, want) {
+		t.Errorf("frames % x, want % x", got, want)
+	}
+
+	lit := ""
+	for _, p := range lc.State().Pixels {
+		if p.R > 0 {
+			lit += "x"
+		} else {
+			lit += "."
+		}
+	}
+	if lit != "xx...xx..x" {
+		t.Errorf("pixels %s, want xx...xx..x", lit)
+	}
+
+	// breathe on ranges is animated in the background until stopped
+	opts.ControlMode = lamp.ModeBreathe
+	opts.ControlRanges = "5-6"
+	if err := lc.Apply(opts); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	lc.StopMarquee()
+	n := len(dev.frames)
+	if n < 10 {
+		t.Errorf("%d frames after animating breathe, want more", n)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if len(dev.frames) != n {
+		t.Error("frames sent after StopMarquee")
+	}
+}
+
+func TestMarqueeOneLED(t *testing.T) {
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+	lc.SetQuantity(1)
+
+	// no LED to run over: the marquee sends nothing and ends
+	lc.Marquee("r")
+	time.Sleep(100 * time.Millisecond)
+	lc.StopMarquee()
+	for _, f := range dev.frames {
+		if f[0] == lamp.ModeSingle {
+			t.Errorf("marquee frame % x on a single LED", f)
+		}
+	}
+}
+
+func TestSetQuantityFits(t *testing.T) {
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+	lc.SetQuantity(250)
+	opts := lc.State().Options
+	opts.ControlMode = lamp.ModeSingle
+	opts.ControlPosition = 200
+	opts.ControlRanges = "100-120"
+	if err := lc.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// a shorter strip brings the options in instead of sending past its end
+	lc.SetQuantity(10)
+	if s := lc.State(); s.ControlPosition != 9 || s.ControlRanges != "" {
+		t.Errorf("position %d, ranges %q on 10 LEDs, want 9 and none", s.ControlPosition, s.ControlRanges)
+	}
+	if err := lc.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if f := dev.lastFrame(); f[0] != lamp.ModeSingle || f[1] != 9 {
+		t.Errorf("frame % x, want LED 9", f)
+	}
+
+	// ranges that still fit stay
+	opts = lc.State().Options
+	opts.ControlMode = lamp.ModeNormal
+	opts.ControlRanges = "2-4"
+	lc.SetOptions(opts)
+	lc.SetQuantity(5)
+	if s := lc.State(); s.ControlRanges != "2-4" {
+		t.Errorf("ranges %q on 5 LEDs, want 2-4", s.ControlRanges)
+	}
+}
@@ -0,0 +1,281 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"lampwith-tag/i18n"
+	"lampwith-tag/lamp"
+	"lampwith-tag/preset"
+	"lampwith-tag/repl"
+	"lampwith-tag/scene"
+)
+
+func newShell(t *testing.T) (*repl.Shell, *lamp.LampWithClient, *simDevice, *bytes.Buffer) {
+	t.Helper()
+	if err := i18n.SetLocale("en"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { i18n.SetLocale("zh") })
+
+	presets, err := preset.Load(filepath.Join(t.TempDir(), "presets.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+	out := &bytes.Buffer{}
+	return repl.New(lc, presets, scene.NewPlayer(lc), out), lc, dev, out
+}
+
+func TestReplSplit(t *testing.T) {
+	cases := map[string][]string{
+		"":                         nil,
+		"  set  color   red ":      {"set", "color", "red"},
+		`play "my scene.json"`:     {"play", "my scene.json"},
+		`preset save 'warm white'`: {"preset", "save", "warm white"},
+		`set color ""`:             {"set", "color", ""},
+	}
+	for line, want := range cases {
+		got, err := repl.Split(line)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("Split(%q) = %q, %v; want %q", line, got, err, want)
+		}
+	}
+
+	if _, err := repl.Split(`play "my scene`); err == nil {
+		t.Error("unclosed quote split without error")
+	}
+}
+
+func TestReplSet(t *testing.T) {
+	sh, lc, dev, _ := newShell(t)
+
+	for _, line := range []string{"set color red", "set percent 20", "mode breathe", "run"} {
+		if err := sh.Exec(line); err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+	}
+	// 20% of 30 LEDs, channels in g,r,b order
+	want := []byte{4, 6, 0, 255, 0, 0x05}
+	if f := dev.lastFrame(); !bytes.Equal(f, want) {
+		t.Errorf("frame % x, want % x", f, want)
+	}
+
+	for line, color := range map[string]string{
+		"set color #00ff80": "0,255,128",
+		"set color 1 2 3":   "1,2,3",
+		"set color 4,5,6":   "4,5,6",
+		"set color amber":   "255,191,0",
+		"rgb = 7, 8, 9":     "7,8,9",
+		"set color 'warm'":  "255,160,60",
+	} {
+		if err := sh.Exec(line); err != nil {
+			t.Errorf("%s: %v", line, err)
+			continue
+		}
+		if c := lc.State().ControlColor; c != color {
+			t.Errorf("%s: color %s, want %s", line, c, color)
+		}
+	}
+}
+
+func TestReplShortForms(t *testing.T) {
+	sh, lc, _, _ := newShell(t)
+
+	for _, line := range []string{"smd", "position=5", "percent = 30", "exec", "option"} {
+		if err := sh.Exec(line); err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+	}
+	s := lc.State()
+	if s.ControlMode != lamp.ModeSingle || s.ControlPosition != 5 || s.ControlPercentage != 30 {
+		t.Errorf("state %+v, want single, position 5, 30%%", s.Options)
+	}
+
+	if err := sh.Exec("q"); !errors.Is(err, repl.ErrQuit) {
+		t.Errorf("q: %v, want ErrQuit", err)
+	}
+}
+
+func TestReplErrors(t *testing.T) {
+	sh, lc, _, _ := newShell(t)
+	before := lc.State().Options
+
+	cases := map[string]string{
+		"percent=2a":         `percent must be a whole number, not "2a"`,
+		"percentage=20":      "Unknown command",
+		"rgb=a,b,c":          `Unknown color "a,b,c"`,
+		"set percent 101":    "between 1 and 100",
+		"set position 30":    "between 1 and 29",
+		"set brightness 3":   `Unknown setting "brightness"`,
+		"mode disco":         `Unknown mode "disco"`,
+		"preset run":         "Missing preset name",
+		"preset run nothing": "No preset [nothing]",
+		"dance":              `Unknown command "dance"`,
+	}
+	for line, want := range cases {
+		err := sh.Exec(line)
+		var ue *repl.UsageError
+		if !errors.As(err, &ue) || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: %v, want a usage error with %q", line, err, want)
+		}
+	}
+
+	if err := sh.Exec("set percent"); err == nil || !strings.Contains(err.Error(), "Usage: set percent|ranges|position|color|period|speed|fade|quantity <value>") {
+		t.Errorf("set percent: %v, want the usage of set", err)
+	}
+	if after := lc.State().Options; after != before {
+		t.Errorf("options changed by bad input: %+v, want %+v", after, before)
+	}
+}
+
+func TestReplControlError(t *testing.T) {
+	sh, _, dev, _ := newShell(t)
+	dev.setFail(errors.New("bus down"))
+
+	err := sh.Exec("run")
+	var ce *repl.ControlError
+	if !errors.As(err, &ce) {
+		t.Errorf("run on a failing bus: %v, want a control error", err)
+	}
+}
+
+func TestReplHelp(t *testing.T) {
+	sh, _, _, out := newShell(t)
+
+	if err := sh.Exec("help set"); err != nil {
+		t.Fatal(err)
+	}
+	if s := out.String(); !strings.Contains(s, "set percent|ranges|position|color|period|speed|fade|quantity <value>") || !strings.Contains(s, "set color red") {
+		t.Errorf("help set:\n%s", s)
+	}
+
+	out.Reset()
+	if err := sh.Exec("h"); err != nil {
+		t.Fatal(err)
+	}
+	for _, cmd := range []string{"mode normal|breathe", "preset save|list|delete|run", "play <file>", "sma to sme"} {
+		if !strings.Contains(out.String(), cmd) {
+			t.Errorf("help does not mention %q", cmd)
+		}
+	}
+}
+
+func TestReplComplete(t *testing.T) {
+	sh, _, _, _ := newShell(t)
+
+	cases := map[string][]string{
+		"se":              {"set"},
+		"set c":           {"set color"},
+		"set color ma":    {"set color magenta"},
+		"mode b":          {"mode breathe"},
+		"preset ":         {"preset delete", "preset list", "preset run", "preset save"},
+		"preset delete 9": {"preset delete 9"},
+		"help q":          {"help quit"},
+	}
+	for line, want := range cases {
+		if got := sh.Complete(line); !reflect.DeepEqual(got, want) {
+			t.Errorf("Complete(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
+
+func TestReplInline(t *testing.T) {
+	sh, lc, dev, _ := newShell(t)
+
+	if err := sh.Exec("single 5 #ff0000"); err != nil {
+		t.Fatal(err)
+	}
+	if f, want := dev.lastFrame(), []byte{6, 5, 0, 255, 0, 0}; !bytes.Equal(f, want) {
+		t.Errorf("single 5 #ff0000: frame % x, want % x", f, want)
+	}
+
+	if err := sh.Exec("breathe 50% green"); err != nil {
+		t.Fatal(err)
+	}
+	if f, want := dev.lastFrame(), []byte{4, 15, 255, 0, 0, 0x05}; !bytes.Equal(f, want) {
+		t.Errorf("breathe 50%% green: frame % x, want % x", f, want)
+	}
+	if s := lc.State(); s.ControlMode != lamp.ModeBreathe || s.ControlPercentage != 50 || s.ControlPosition != 5 {
+		t.Errorf("state %+v", s.Options)
+	}
+}
+
+func TestReplChain(t *testing.T) {
+	sh, lc, dev, out := newShell(t)
+
+	if err := sh.Exec("set color blue; set percent 10 ;; run"); err != nil {
+		t.Fatal(err)
+	}
+	if f, want := dev.lastFrame(), []byte{3, 3, 0, 0, 255, 0}; !bytes.Equal(f, want) {
+		t.Errorf("frame % x, want % x", f, want)
+	}
+	if strings.Contains(out.String(), "Type 'show'") {
+		t.Error("hint to run printed for a line that ran")
+	}
+
+	// a mistake anywhere in the line sends nothing
+	before, frames := lc.State().Options, len(dev.frames)
+	for _, line := range []string{
+		"set color red; run; set percent 0",
+		"single 5 red; mode disco",
+		"strobe red; breathe 50% nocolor",
+		`set color red; play "no such file.json"`,
+		"set quantity 10; set position 12; run",
+		"set quantity 250; set position 200; set quantity 10; run",
+	} {
+		if err := sh.Exec(line); err == nil {
+			t.Errorf("%s: no error", line)
+		}
+	}
+	if after := lc.State(); after.Options != before || after.Quantity != 30 {
+		t.Errorf("bad lines left %d LEDs, %+v, want 30, %+v", after.Quantity, after.Options, before)
+	}
+	if len(dev.frames) != frames {
+		t.Errorf("%d frames sent by bad lines", len(dev.frames)-frames)
+	}
+
+	if got, want := sh.Complete("set color red; mo"), []string{"set color red; mode"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Complete after ; = %q, want %q", got, want)
+	}
+}
+
+func TestReplRanges(t *testing.T) {
+	sh, lc, dev, _ := newShell(t)
+
+	if err := sh.Exec("range 28- blue 1-2"); err != nil {
+		t.Fatal(err)
+	}
+	if s := lc.State(); s.ControlRanges != "1-2,28-30" || s.ControlColor != "0,0,255" || s.ControlMode != lamp.ModeNormal {
+		t.Errorf("state %+v", s.Options)
+	}
+	if f, want := dev.lastFrame(), []byte{6, 30, 0, 0, 255, 0}; !bytes.Equal(f, want) {
+		t.Errorf("frame % x, want % x", f, want)
+	}
+
+	if err := sh.Exec("strobe -20% red"); err != nil {
+		t.Fatal(err)
+	}
+	lc.StopMarquee()
+	if s := lc.State(); s.ControlRanges != "25-30" || s.ControlMode != lamp.ModeStrobe {
+		t.Errorf("state %+v", s.Options)
+	}
+
+	if err := sh.Exec("set percent 50"); err != nil {
+		t.Fatal(err)
+	}
+	if r := lc.State().ControlRanges; r != "" {
+		t.Errorf("ranges %q after set percent, want none", r)
+	}
+
+	for _, line := range []string{"range 5-40", "range 1-3 single", "set ranges 9-3"} {
+		if err := sh.Exec(line); err == nil {
+			t.Errorf("%s: no error", line)
+		}
+	}
+}
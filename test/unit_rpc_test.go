@@ -0,0 +1,189 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"lampwith-tag/lamp"
+	"lampwith-tag/lamprpc"
+)
+
+func newRPCClient(t *testing.T, lc *lamp.LampWithClient) lamprpc.LampServiceClient {
+	return serveRPC(t, lamprpc.NewServer(lc))
+}
+
+func serveRPC(t *testing.T, srv *lamprpc.Server) lamprpc.LampServiceClient {
+	lis := bufconn.Listen(1 << 16)
+	s := grpc.NewServer()
+	lamprpc.RegisterLampServiceServer(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return lamprpc.NewLampServiceClient(conn)
+}
+
+func TestRPCModes(t *testing.T) {
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+	client := newRPCClient(t, lc)
+	ctx := context.Background()
+	red := &lamprpc.Color{R: 255}
+
+	cases := []struct {
+		name string
+		call func() (*lamprpc.LampState, error)
+		want []byte
+	}{
+		{"normal", func() (*lamprpc.LampState, error) {
+			return client.Normal(ctx, &lamprpc.FillRequest{Percent: 50, Color: red})
+		}, []byte{0x03, 15, 0, 255, 0, 0x00}},
+		{"breathe", func() (*lamprpc.LampState, error) {
+			return client.Breathe(ctx, &lamprpc.FillRequest{Percent: 100, Color: red})
+		}, []byte{0x04, 30, 0, 255, 0, 0x05}},
+		{"strobe", func() (*lamprpc.LampState, error) {
+			return client.Strobe(ctx, &lamprpc.FillRequest{Percent: 10, Color: red})
+		}, []byte{0x05, 3, 0, 255, 0, 0x64}},
+		{"strobe 4Hz", func() (*lamprpc.LampState, error) {
+			return client.Strobe(ctx, &lamprpc.FillRequest{Percent: 10, Color: red, PeriodMs: 250})
+		}, []byte{0x05, 3, 0, 255, 0, 25}},
+		{"single", func() (*lamprpc.LampState, error) {
+			return client.Single(ctx, &lamprpc.SingleRequest{Position: 7, Color: &lamprpc.Color{G: 9}})
+		}, []byte{0x06, 7, 9, 0, 0, 0x00}},
+	}
+
+	for _, c := range cases {
+		st, err := c.call()
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if got := dev.lastFrame(); !bytes.Equal(got, c.want) {
+			t.Errorf("%s: frame % x, want % x", c.name, got, c.want)
+		}
+		if st.GetQuantity() != 30 {
+			t.Errorf("%s: quantity %d, want 30", c.name, st.GetQuantity())
+		}
+	}
+
+	if _, err := client.Marquee(ctx, &lamprpc.MarqueeRequest{Color: red}); err != nil {
+		t.Fatal(err)
+	}
+	lc.StopMarquee()
+	if got := lc.State().ControlMode; got != lamp.ModeMarquee {
+		t.Errorf("mode %d, want marquee", got)
+	}
+}
+
+func TestRPCFillClearsRanges(t *testing.T) {
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+	client := newRPCClient(t, lc)
+	opts := lc.State().Options
+	opts.ControlRanges = "2-3"
+	lc.SetOptions(opts)
+
+	st, err := client.GetState(context.Background(), &lamprpc.GetStateRequest{})
+	if err != nil || st.GetRanges() != "2-3" {
+		t.Fatalf("state %v, %v, want ranges 2-3", st, err)
+	}
+	// percent lights the start of the strip again, as set percent does
+	st, err = client.Normal(context.Background(), &lamprpc.FillRequest{Percent: 50, Color: &lamprpc.Color{R: 255}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.GetRanges() != "" {
+		t.Errorf("ranges %q after Normal", st.GetRanges())
+	}
+	if got, want := dev.lastFrame(), []byte{0x03, 15, 0, 255, 0, 0x00}; !bytes.Equal(got, want) {
+		t.Errorf("frame % x, want % x", got, want)
+	}
+}
+
+func TestRPCInvalidArgument(t *testing.T) {
+	dev := newSimDevice()
+	client := newRPCClient(t, lamp.New(newSimClient(dev)))
+
+	_, err := client.Normal(context.Background(), &lamprpc.FillRequest{Percent: 0})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("err %v, want InvalidArgument", err)
+	}
+	if dev.lastFrame() != nil {
+		t.Errorf("invalid request reached the bus")
+	}
+}
+
+func TestRPCStreamState(t *testing.T) {
+	dev := newSimDevice()
+	client := newRPCClient(t, lamp.New(newSimClient(dev)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.StreamState(ctx, &lamprpc.StreamStateRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.GetState().GetMode() != lamprpc.Mode_MODE_NORMAL {
+		t.Fatalf("initial state %v", u)
+	}
+
+	if _, err := client.Breathe(ctx, &lamprpc.FillRequest{Percent: 20, Color: &lamprpc.Color{B: 80}}); err != nil {
+		t.Fatal(err)
+	}
+	if !recvUntil(t, stream, func(u *lamprpc.StateUpdate) bool {
+		st := u.GetState()
+		return st.GetMode() == lamprpc.Mode_MODE_BREATHE && st.GetPercent() == 20 && st.GetColor().GetB() == 80
+	}) {
+		t.Fatal("breathe state not streamed")
+	}
+
+	dev.setFail(errors.New("sim: unplugged"))
+	_, err = client.Strobe(ctx, &lamprpc.FillRequest{Percent: 20})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("err %v, want Unavailable", err)
+	}
+	if !recvUntil(t, stream, func(u *lamprpc.StateUpdate) bool {
+		return u.GetError().GetMessage() == "sim: unplugged"
+	}) {
+		t.Fatal("bus error not streamed")
+	}
+}
+
+func recvUntil(t *testing.T, stream lamprpc.LampService_StreamStateClient, match func(*lamprpc.StateUpdate) bool) bool {
+	t.Helper()
+
+	for {
+		u, err := stream.Recv()
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		if match(u) {
+			return true
+		}
+	}
+}
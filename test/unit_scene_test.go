@@ -0,0 +1,130 @@
+package test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"lampwith-tag/lamp"
+	"lampwith-tag/scene"
+)
+
+func writeScene(t *testing.T, body string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "scene.json")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSceneLoadRejectsBadSteps(t *testing.T) {
+	cases := map[string]string{
+		"unknown mode":  `{"steps": [{"mode": "disco"}]}`,
+		"bad color":     `{"steps": [{"mode": "normal", "color": "300,0,0"}]}`,
+		"bad duration":  `{"steps": [{"wait": "soon"}]}`,
+		"wait and mode": `{"steps": [{"mode": "normal", "wait": "1s"}]}`,
+		"nested":        `{"steps": [{"loop": 2, "steps": [{"effect": "fireworks"}]}]}`,
+		"empty":         `{"steps": []}`,
+		"busy loop":     `{"loop": -1, "steps": [{"mode": "normal", "color": "red"}, {"mode": "normal", "color": "blue"}]}`,
+		"busy nested":   `{"steps": [{"loop": -1, "steps": [{"mode": "strobe"}, {"loop": 3, "steps": [{"mode": "normal"}]}]}]}`,
+	}
+
+	for name, body := range cases {
+		if _, err := scene.Load(writeScene(t, body)); err == nil {
+			t.Errorf("%s: loaded without error", name)
+		}
+	}
+
+	// a wait anywhere in the loop paces it
+	if _, err := scene.Load(writeScene(t, `{"loop": -1, "steps": [{"mode": "normal"}, {"loop": 2, "steps": [{"wait": "100ms"}]}]}`)); err != nil {
+		t.Errorf("paced loop: %v", err)
+	}
+	if _, err := scene.Load(filepath.Join("..", "scenes", "andon.json")); err != nil {
+		t.Errorf("example scene: %v", err)
+	}
+}
+
+func TestScenePlay(t *testing.T) {
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+	lc.SetColor(0, 0, 0)
+
+	seq, err := scene.Load(writeScene(t, `{
+		"name": "test",
+		"steps": [
+			{"mode": "normal", "color": "200,0,0", "percent": 100, "fade": "200ms"},
+			{"loop": 2, "steps": [
+				{"mode": "single", "position": 4, "color": "0,0,9"},
+				{"wait": "10ms"}
+			]}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	finished := make(chan error, 1)
+	scene.NewPlayer(lc).Play(seq, func(err error) { finished <- err })
+
+	select {
+	case err := <-finished:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("scene did not finish")
+	}
+
+	dev.mu.Lock()
+	frames := dev.frames
+	dev.mu.Unlock()
+
+	// three fade steps, the target, then the single LED twice
+	var got [][2]byte
+	for _, f := range frames {
+		got = append(got, [2]byte{f[0], f[3]})
+	}
+	want := [][2]byte{{3, 50}, {3, 100}, {3, 150}, {3, 200}, {6, 0}, {6, 0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("(mode, red) frames %v, want %v", got, want)
+	}
+	if last := dev.lastFrame(); !bytes.Equal(last, []byte{0x06, 4, 0, 0, 9, 0}) {
+		t.Errorf("last frame % x", last)
+	}
+}
+
+func TestSceneStop(t *testing.T) {
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+
+	seq, err := scene.Load(writeScene(t, `{"loop": -1, "steps": [{"mode": "breathe", "duration": "1h"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := scene.NewPlayer(lc)
+	p.Play(seq, nil)
+	if p.Playing() == "" {
+		t.Fatal("not playing")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		p.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("stop did not interrupt the step")
+	}
+	if p.Playing() != "" {
+		t.Error("still playing after stop")
+	}
+}
@@ -0,0 +1,284 @@
+package test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"lampwith-tag/alert"
+	"lampwith-tag/config"
+	"lampwith-tag/lamp"
+	"lampwith-tag/preset"
+	"lampwith-tag/repl"
+	"lampwith-tag/scene"
+	"lampwith-tag/schedule"
+)
+
+func TestScheduleParse(t *testing.T) {
+	shanghai := &config.Location{Lat: 31.23, Lon: 121.47}
+	cst := time.FixedZone("CST", 8*3600)
+	from := time.Date(2024, 6, 21, 0, 0, 0, 0, cst) // a Friday
+
+	cases := map[string]time.Time{
+		"0 8 * * 1-5":   time.Date(2024, 6, 21, 8, 0, 0, 0, cst),
+		"30 18 * * 0":   time.Date(2024, 6, 23, 18, 30, 0, 0, cst),
+		"@daily":        time.Date(2024, 6, 22, 0, 0, 0, 0, cst),
+		"sunset":        time.Date(2024, 6, 21, 19, 1, 0, 0, cst),
+		"sunset-15m":    time.Date(2024, 6, 21, 18, 46, 0, 0, cst),
+		"sunrise+1h":    time.Date(2024, 6, 21, 5, 50, 0, 0, cst),
+		"sunrise 6":     time.Date(2024, 6, 22, 4, 50, 0, 0, cst),
+		"sunrise 0,6":   time.Date(2024, 6, 22, 4, 50, 0, 0, cst),
+		"sunset-1h 1-5": time.Date(2024, 6, 21, 18, 1, 0, 0, cst),
+	}
+	for s, want := range cases {
+		sp, err := schedule.Parse(s, shanghai)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", s, err)
+			continue
+		}
+		// sun times are good to a couple of minutes
+		if got := sp.Next(from); got.Sub(want).Abs() > 2*time.Minute {
+			t.Errorf("Parse(%q).Next = %v, want %v", s, got, want)
+		}
+	}
+
+	for _, s := range []string{"", "sunset", "0 25 * * *", "sunset+soon", "sunset 1-9", "sunset 1 2", "sundown"} {
+		loc := shanghai
+		if s == "sunset" {
+			loc = nil
+		}
+		if _, err := schedule.Parse(s, loc); err == nil {
+			t.Errorf("Parse(%q, %v) succeeded", s, loc)
+		}
+	}
+
+	// the sun does not set in the arctic summer
+	sp, _ := schedule.Parse("sunset", &config.Location{Lat: 78.2, Lon: 15.6})
+	if got := sp.Next(time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC)); got.Month() == time.June || got.Month() == time.July {
+		t.Errorf("sunset at Svalbard on %v", got)
+	}
+}
+
+func TestScheduleDue(t *testing.T) {
+	if _, err := schedule.New([]config.Rule{{Name: "a", When: "@daily"}}, nil); err == nil {
+		t.Error("rule without preset or scene accepted")
+	}
+	if _, err := schedule.New([]config.Rule{{Name: "a", When: "@daily", Preset: "x"}, {Name: "a", When: "@hourly", Preset: "y"}}, nil); err == nil {
+		t.Error("duplicate rule accepted")
+	}
+
+	s, err := schedule.New([]config.Rule{
+		{Name: "morning", When: "0 8 * * *", Preset: "warm"},
+		{Name: "night", When: "0 22 * * *", Until: "0 2 * * *", Preset: "dim"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2024, 6, 21, 0, 0, 0, 0, time.Local)
+	at := func(h int) time.Time { return day.Add(time.Duration(h) * time.Hour) }
+
+	names := func(actions []schedule.Action) string {
+		var b strings.Builder
+		for _, a := range actions {
+			b.WriteString(a.Rule.Name)
+			if a.End {
+				b.WriteString(" end")
+			}
+			b.WriteString(";")
+		}
+		return b.String()
+	}
+	if got := names(s.Due(at(7), at(9))); got != "morning;" {
+		t.Errorf("7-9: %s", got)
+	}
+	if got := names(s.Due(at(21), at(27))); got != "night;night end;" {
+		t.Errorf("21-27: %s", got)
+	}
+	// a long gap fires each rule once
+	if got := names(s.Due(at(0), at(24*3+9))); got != "night;night end;morning;" {
+		t.Errorf("three days: %s", got)
+	}
+	if got := s.Next(at(9)); !got.Equal(at(22)) {
+		t.Errorf("next after 9: %v", got)
+	}
+
+	s.Skip("morning", true)
+	if got := names(s.Due(at(7), at(9))); got != "" {
+		t.Errorf("skipped morning fired: %s", got)
+	}
+	if got := names(s.Due(at(31), at(33))); got != "morning;" {
+		t.Errorf("morning after the skip: %s", got)
+	}
+
+	s.Pause("")
+	if got := names(s.Due(at(0), at(48))); got != "" {
+		t.Errorf("paused rules fired: %s", got)
+	}
+	if got := s.Next(at(0)); !got.IsZero() {
+		t.Errorf("next with all paused: %v", got)
+	}
+	s.Resume("night")
+	if got := names(s.Due(at(0), at(12))); got != "night end;" {
+		t.Errorf("only night resumed: %s", got)
+	}
+	if err := s.Pause("nope"); err == nil {
+		t.Error("paused an unknown rule")
+	}
+}
+
+func TestScheduleRunner(t *testing.T) {
+	presets, err := preset.Load(filepath.Join(t.TempDir(), "presets.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := presets.Save(preset.Preset{Name: "dim", Mode: "normal", Percent: 20, Position: 1, Color: "10,10,10"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+	lc.SetQuantity(10)
+	opts := lc.State().Options
+	opts.ControlColor = "200,0,0"
+	if err := lc.Apply(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	player := scene.NewPlayer(lc)
+	r := &schedule.Runner{Presets: presets, Strips: func() []schedule.Strip {
+		return []schedule.Strip{{Lamp: lc, Player: player}}
+	}}
+	night := config.Rule{Name: "night", When: "0 22 * * *", Until: "0 2 * * *", Preset: "dim"}
+	if err := r.Check([]config.Rule{night, {Name: "gone", When: "@daily", Preset: "nope"}}); err == nil || !strings.Contains(err.Error(), `"gone"`) {
+		t.Errorf("Check: %v", err)
+	}
+
+	if err := r.Fire(schedule.Action{Rule: night}); err != nil {
+		t.Fatal(err)
+	}
+	if f := dev.lastFrame(); f[2] != 10 || f[3] != 10 || f[4] != 10 {
+		t.Errorf("in the window: % x", f)
+	}
+	// the end of the window brings back the red
+	if err := r.Fire(schedule.Action{Rule: night, End: true}); err != nil {
+		t.Fatal(err)
+	}
+	if f, want := dev.lastFrame(), []byte{3, 10, 0, 200, 0, 0}; !bytes.Equal(f, want) {
+		t.Errorf("after the window % x, want % x", f, want)
+	}
+}
+
+func TestReplSchedule(t *testing.T) {
+	sh, lc, _, out := newShell(t)
+	if err := sh.Exec("schedule"); err == nil {
+		t.Error("schedule without rules succeeded")
+	}
+
+	s, err := schedule.New([]config.Rule{{Name: "morning", When: "0 8 * * *", Preset: "warm"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	presets, _ := preset.Load(filepath.Join(t.TempDir(), "presets.json"))
+	sh.SetSchedule(s, &schedule.Runner{Presets: presets, Strips: func() []schedule.Strip {
+		return []schedule.Strip{{Lamp: lc, Player: scene.NewPlayer(lc)}}
+	}})
+
+	if err := sh.Exec("schedule list; schedule skip morning; schedule pause"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"morning", "0 8 * * *", "warm", "Paused"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output misses %q:\n%s", want, out)
+		}
+	}
+	if err := sh.Exec("schedule skip"); err == nil {
+		t.Error("skip without a rule succeeded")
+	}
+	if err := sh.Exec("schedule pause evening"); err == nil {
+		t.Error("paused an unknown rule")
+	}
+	// the preset is missing
+	if err := sh.Exec("schedule run morning"); err == nil {
+		t.Error("ran a rule whose preset is missing")
+	}
+	repl.ShowSchedule(out, s, time.Now())
+}
+
+func TestScheduleRunnerRestores(t *testing.T) {
+	presets, err := preset.Load(filepath.Join(t.TempDir(), "presets.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := presets.Save(preset.Preset{Name: "dim", Mode: "normal", Percent: 20, Position: 1, Color: "10,10,10"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+	lc.SetQuantity(30)
+	player := scene.NewPlayer(lc)
+	alerts := alert.NewManager(lc, player)
+	layers := lc.NewCompositor()
+	gauge := lc.NewGauge()
+	r := &schedule.Runner{Presets: presets, Strips: func() []schedule.Strip {
+		return []schedule.Strip{{Lamp: lc, Player: player, Alerts: alerts, Layers: layers, Gauge: gauge}}
+	}}
+	night := config.Rule{Name: "night", When: "0 22 * * *", Until: "0 2 * * *", Preset: "dim"}
+	dimFrame := []byte{3, 6, 10, 10, 10, 0}
+
+	// A scene that played before the window plays again after it.
+	idle := &scene.Sequence{Name: "idle", Loop: -1, Steps: []scene.Step{
+		{Mode: "normal", Color: "0,0,50", Percent: 100, Duration: scene.Duration(time.Hour)},
+	}}
+	player.Play(idle, nil)
+	r.Fire(schedule.Action{Rule: night})
+	if player.Playing() != "" || !bytes.Equal(dev.lastFrame(), dimFrame) {
+		t.Errorf("in the window: scene %q, frame % x", player.Playing(), dev.lastFrame())
+	}
+	r.Fire(schedule.Action{Rule: night, End: true})
+	if player.Playing() != "idle" {
+		t.Errorf("scene %q after the window, want idle", player.Playing())
+	}
+
+	// A rule firing during an alert leaves the alert on the strip and
+	// shows once it clears.
+	alerts.Raise(alert.Critical, "press-3", 0)
+	r.Fire(schedule.Action{Rule: night})
+	if f := dev.lastFrame(); !bytes.Equal(f, criticalFrame) {
+		t.Errorf("rule hid the alert: % x", f)
+	}
+	alerts.Clear("")
+	if f := dev.lastFrame(); !bytes.Equal(f, dimFrame) || player.Playing() != "" {
+		t.Errorf("after the alert % x, scene %q, want the rule's preset", f, player.Playing())
+	}
+	r.Fire(schedule.Action{Rule: night, End: true})
+	if player.Playing() != "idle" {
+		t.Errorf("scene %q after the window, want idle", player.Playing())
+	}
+	player.Stop()
+
+	// So do the gauge and the layers.
+	gauge.Set(50)
+	r.Fire(schedule.Action{Rule: night})
+	if gauge.Showing() {
+		t.Error("gauge still showing in the window")
+	}
+	r.Fire(schedule.Action{Rule: night, End: true})
+	if !gauge.Showing() {
+		t.Error("gauge not back after the window")
+	}
+
+	layers.Set(lamp.Layer{Name: "bg", Options: fill(lamp.ModeNormal, "", "0,9,0"), Opacity: 1})
+	eventually(layers.Showing)
+	r.Fire(schedule.Action{Rule: night})
+	if !eventually(func() bool { return !layers.Showing() }) {
+		t.Error("layers still showing in the window")
+	}
+	r.Fire(schedule.Action{Rule: night, End: true})
+	if !eventually(func() bool { return rgb(lc.State().Pixels[0]) == lamp.Color{G: 9} }) {
+		t.Errorf("LED 1 %v after the window, want the layers", lc.State().Pixels[0])
+	}
+	lc.StopMarquee()
+}
@@ -0,0 +1,136 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"lampwith-tag/lamp"
+	"lampwith-tag/preset"
+)
+
+func TestParsePeriod(t *testing.T) {
+	cases := map[string]time.Duration{
+		"500ms":   500 * time.Millisecond,
+		"2s":      2 * time.Second,
+		"4Hz":     250 * time.Millisecond,
+		"0.5 hz":  2 * time.Second,
+		"250":     250 * time.Millisecond,
+		"default": 0,
+	}
+	for s, want := range cases {
+		if got, err := lamp.ParsePeriod(s); err != nil || got != want {
+			t.Errorf("ParsePeriod(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	if got, err := lamp.ParseSpeed("4"); err != nil || got != 250*time.Millisecond {
+		t.Errorf("ParseSpeed(4) = %v, %v; want 250ms", got, err)
+	}
+
+	for _, s := range []string{"", "fast", "0Hz", "-2s", "0", "1x"} {
+		if _, err := lamp.ParsePeriod(s); !errors.Is(err, lamp.ErrInvalidOption) {
+			t.Errorf("ParsePeriod(%q): %v, want ErrInvalidOption", s, err)
+		}
+	}
+}
+
+func TestExecPeriod(t *testing.T) {
+	dev := newSimDevice()
+	lc := lamp.New(newSimClient(dev))
+
+	cases := []struct {
+		mode   int
+		period time.Duration
+		param  byte
+	}{
+		{lamp.ModeBreathe, 0, 5},
+		{lamp.ModeBreathe, 2 * time.Second, 2},
+		{lamp.ModeBreathe, 2400 * time.Millisecond, 2},
+		{lamp.ModeStrobe, 0, 100},
+		{lamp.ModeStrobe, 250 * time.Millisecond, 25},
+		{lamp.ModeStrobe, 2550 * time.Millisecond, 255},
+	}
+	for _, c := range cases {
+		opts := lc.State().Options
+		opts.ControlMode, opts.ControlPeriod = c.mode, c.period
+		if err := lc.Apply(opts); err != nil {
+			t.Fatalf("%s %v: %v", lamp.ModeName(c.mode), c.period, err)
+		}
+		if p := dev.lastFrame()[5]; p != c.param {
+			t.Errorf("%s %v: param %d, want %d", lamp.ModeName(c.mode), c.period, p, c.param)
+		}
+	}
+
+	for mode, period := range map[int]time.Duration{
+		lamp.ModeBreathe: 500 * time.Millisecond,
+		lamp.ModeStrobe:  3 * time.Second,
+	} {
+		opts := lc.State().Options
+		opts.ControlMode, opts.ControlPeriod = mode, period
+		if err := lc.SetOptions(opts); !errors.Is(err, lamp.ErrInvalidOption) {
+			t.Errorf("%s %v: %v, want ErrInvalidOption", lamp.ModeName(mode), period, err)
+		}
+	}
+
+	// a new mode starts at its default speed
+	lc.SetMode(lamp.ModeBreathe)
+	lc.SetPeriod(10 * time.Second)
+	if err := lc.SetMode(lamp.ModeStrobe); err != nil || lc.State().Period() != time.Second {
+		t.Errorf("strobe after a 10s breath: period %v, %v", lc.State().Period(), err)
+	}
+}
+
+func TestReplPeriod(t *testing.T) {
+	sh, lc, dev, out := newShell(t)
+
+	if err := sh.Exec("strobe speed=4Hz"); err != nil {
+		t.Fatal(err)
+	}
+	if f, want := dev.lastFrame(), []byte{5, 30, 4, 3, 5, 25}; !bytes.Equal(f, want) {
+		t.Errorf("frame % x, want % x", f, want)
+	}
+
+	if err := sh.Exec("show"); err != nil {
+		t.Fatal(err)
+	}
+	if s := out.String(); !strings.Contains(s, "period: 250ms (4Hz)") {
+		t.Errorf("show:\n%s", s)
+	}
+
+	// the period is checked against the mode before anything is sent
+	n := len(dev.frames)
+	if err := sh.Exec("set period 10s; run"); err == nil || len(dev.frames) != n {
+		t.Errorf("10s strobe: %v, %d frames sent", err, len(dev.frames)-n)
+	}
+	if err := sh.Exec("breathe period=10s"); err != nil {
+		t.Fatal(err)
+	}
+	if p := dev.lastFrame()[5]; p != 10 {
+		t.Errorf("breathe param %d, want 10", p)
+	}
+
+	out.Reset()
+	if err := sh.Exec("mode strobe; show"); err != nil {
+		t.Fatal(err)
+	}
+	if s := out.String(); !strings.Contains(s, "period: 1s (1Hz, default)") {
+		t.Errorf("show after mode strobe:\n%s", s)
+	}
+	if lc.State().ControlPeriod != 0 {
+		t.Errorf("period %v after a mode change, want the default", lc.State().ControlPeriod)
+	}
+}
+
+func TestPresetPeriod(t *testing.T) {
+	opts := lamp.Options{ControlMode: lamp.ModeBreathe, ControlPercentage: 100, ControlPosition: 1,
+		ControlColor: "0,0,9", ControlPeriod: 3 * time.Second}
+	p := preset.FromOptions("slow", opts)
+	if p.Period != "3s" {
+		t.Errorf("preset period %q, want 3s", p.Period)
+	}
+	if got, err := p.Options(); err != nil || got != opts {
+		t.Errorf("Options() = %+v, %v; want %+v", got, err, opts)
+	}
+}
@@ -0,0 +1,46 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/goburrow/modbus"
+
+	"lampwith-tag/link"
+)
+
+func TestTraceFrames(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	d := newSimDevice()
+	handler := modbus.NewRTUClientHandler("sim")
+	handler.SlaveId = d.slaveID
+	client := modbus.NewClient2(handler, link.Trace(d, d.slaveID, log))
+
+	if _, err := client.WriteMultipleRegisters(9, 3, []byte{3, 0x64, 0, 9, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	d.setFail(errors.New("sim: timeout"))
+	client.WriteMultipleRegisters(9, 3, []byte{3, 0x64, 0, 0, 0, 0})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("%d log lines, want 2:\n%s", len(lines), buf.String())
+	}
+
+	var ok, failed map[string]any
+	json.Unmarshal([]byte(lines[0]), &ok)
+	json.Unmarshal([]byte(lines[1]), &failed)
+
+	if ok["crc"] != "ok" || ok["slave"] != float64(1) || !strings.HasPrefix(ok["request"].(string), "01 10 00 09 00 03 06") {
+		t.Errorf("trace of a good frame: %s", lines[0])
+	}
+	if failed["level"] != "WARN" || failed["crc"] != "none" || failed["err"] != "sim: timeout" {
+		t.Errorf("trace of a failed frame: %s", lines[1])
+	}
+}
@@ -0,0 +1,109 @@
+package test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"lampwith-tag/i18n"
+	"lampwith-tag/lamp"
+	"lampwith-tag/web"
+)
+
+func newWebServer(t *testing.T) (*httptest.Server, *lamp.LampWithClient) {
+	lc := lamp.New(newSimClient(newSimDevice()))
+	lc.SetQuantity(10)
+	ts := httptest.NewServer(web.Handler(lc, nil))
+	t.Cleanup(ts.Close)
+	return ts, lc
+}
+
+func TestWebStatic(t *testing.T) {
+	ts, _ := newWebServer(t)
+	for path, want := range map[string]string{"/": "<html", "/app.js": "WebSocket", "/style.css": "{"} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), want) {
+			t.Errorf("%s: %s, body misses %q", path, resp.Status, want)
+		}
+	}
+}
+
+func TestWebLanguage(t *testing.T) {
+	ts, _ := newWebServer(t)
+	defer i18n.SetLocale("zh")
+
+	for locale, want := range map[string]string{"en": "Strip control panel", "zh": "灯带控制面板"} {
+		i18n.SetLocale(locale)
+		resp, err := http.Get(ts.URL + "/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if !strings.Contains(string(body), want) || !strings.Contains(string(body), `lang="`+locale+`"`) {
+			t.Errorf("%s page misses %q:\n%s", locale, want, body)
+		}
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	ts, lc := newWebServer(t)
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws"
+	opts := lc.State().Options
+	opts.ControlRanges = "2-3"
+	lc.SetOptions(opts)
+
+	ws, err := websocket.Dial(url, "", ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	ws.SetDeadline(time.Now().Add(5 * time.Second))
+
+	var m struct {
+		Type  string
+		State struct {
+			Quantity int
+			Ranges   string
+		}
+	}
+	if err := websocket.JSON.Receive(ws, &m); err != nil || m.Type != "state" || m.State.Quantity != 10 || m.State.Ranges != "2-3" {
+		t.Fatalf("first message %+v, %v", m, err)
+	}
+
+	// the length lights by percent again, as set percent on the console
+	if err := websocket.JSON.Send(ws, map[string]any{"cmd": "percent", "percent": 50}); err != nil {
+		t.Fatal(err)
+	}
+	if !eventually(func() bool { s := lc.State(); return s.ControlPercentage == 50 && s.ControlRanges == "" }) {
+		t.Errorf("options %+v after the percent command, want 50%% without ranges", lc.State().Options)
+	}
+
+	cmd := map[string]any{"cmd": "pixel", "position": 3, "color": map[string]int{"r": 0, "g": 0, "b": 200}}
+	if err := websocket.JSON.Send(ws, cmd); err != nil {
+		t.Fatal(err)
+	}
+	if !eventually(func() bool { return rgb(lc.State().Pixels[2]) == lamp.Color{B: 200} }) {
+		t.Errorf("LED 3 %v after the pixel command", lc.State().Pixels[2])
+	}
+}
+
+func TestWebSocketForeignOrigin(t *testing.T) {
+	ts, _ := newWebServer(t)
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws"
+
+	if ws, err := websocket.Dial(url, "", "http://attacker.example"); err == nil {
+		ws.Close()
+		t.Error("foreign origin connected")
+	}
+}
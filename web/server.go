@@ -0,0 +1,281 @@
+// Package web serves a browser control panel for a lamp controller. State is
+// pushed to every open panel over a WebSocket, so several operators see the
+// same strip.
+package web
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+
+	"golang.org/x/net/websocket"
+
+	"lampwith-tag/alert"
+	"lampwith-tag/i18n"
+	"lampwith-tag/lamp"
+)
+
+//go:embed static
+var static embed.FS
+
+// page is index.html, in the language of the console.
+var page = template.Must(template.New("index.html").Funcs(template.FuncMap{
+	"t":    func(key string) string { return i18n.T(key) },
+	"lang": i18n.Locale,
+}).ParseFS(static, "static/index.html"))
+
+// Handler serves the panel at / and its WebSocket at /ws. With alerts, the
+// panel sends through the manager so that an alert stays on the strip.
+func Handler(lc *lamp.LampWithClient, alerts *alert.Manager) http.Handler {
+	root, _ := fs.Sub(static, "static")
+	files := http.FileServer(http.FS(root))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/" && req.URL.Path != "/index.html" {
+			files.ServeHTTP(w, req)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := page.Execute(w, nil); err != nil {
+			slog.Warn("web panel page failed", "err", err)
+		}
+	})
+	mux.Handle("/ws", websocket.Server{
+		Handshake: checkOrigin,
+		Handler:   func(ws *websocket.Conn) { serve(lc, alerts, ws) },
+	})
+
+	return mux
+}
+
+// checkOrigin accepts only the panel's own pages, so a page from another
+// site a browser on the network has open cannot drive the strip.
+func checkOrigin(cfg *websocket.Config, req *http.Request) error {
+	origin, err := websocket.Origin(cfg, req)
+	if err != nil {
+		return err
+	}
+	if origin == nil {
+		return errors.New("websocket: no origin")
+	}
+	if origin.Host != req.Host {
+		return fmt.Errorf("websocket: origin %s is not %s", origin.Host, req.Host)
+	}
+	cfg.Origin = origin
+	return nil
+}
+
+// command is sent by the panel.
+type command struct {
+	Cmd      string `json:"cmd"`
+	Mode     int    `json:"mode"`
+	Percent  int    `json:"percent"`
+	Position int    `json:"position"`
+	Color    *color `json:"color"`
+	// Period is "2s", "250ms", "4Hz" or "default".
+	Period string `json:"period"`
+	// Fade is "500ms", "2s" or "off".
+	Fade string `json:"fade"`
+}
+
+type color struct {
+	R int `json:"r"`
+	G int `json:"g"`
+	B int `json:"b"`
+}
+
+type pixel struct {
+	R    byte `json:"r"`
+	G    byte `json:"g"`
+	B    byte `json:"b"`
+	Mode int  `json:"mode"`
+}
+
+type state struct {
+	Mode     int     `json:"mode"`
+	Percent  int     `json:"percent"`
+	Position int     `json:"position"`
+	Color    string  `json:"color"`
+	Quantity int     `json:"quantity"`
+	Pixels   []pixel `json:"pixels"`
+
+	// Period is the period breathe or strobe runs at, empty in the
+	// other modes.
+	Period string `json:"period"`
+	// Fade is the crossfade of every change, empty for none.
+	Fade string `json:"fade"`
+	// Ranges are the LEDs lit instead of Percent, empty for Percent.
+	Ranges string `json:"ranges"`
+}
+
+// message is sent to the panel.
+type message struct {
+	Type    string `json:"type"`
+	State   *state `json:"state,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+func serve(lc *lamp.LampWithClient, alerts *alert.Manager, ws *websocket.Conn) {
+	defer ws.Close()
+
+	events, cancel := lc.Subscribe()
+	defer cancel()
+
+	// replies carries errors for this panel only; events go to every panel.
+	replies := make(chan message, 4)
+	go func() {
+		defer ws.Close()
+		for {
+			var c command
+			if err := websocket.JSON.Receive(ws, &c); err != nil {
+				close(replies)
+				return
+			}
+			if err := handle(strip{lc, alerts}, c); err != nil {
+				select {
+				case replies <- message{Type: "error", Message: i18n.Error(err)}:
+				default:
+				}
+			}
+		}
+	}()
+
+	if websocket.JSON.Send(ws, stateMessage(lc.State())) != nil {
+		return
+	}
+
+	for {
+		var m message
+		select {
+		case r, ok := <-replies:
+			if !ok {
+				return
+			}
+			m = r
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			if ev.Err != nil {
+				m = message{Type: "error", Message: ev.Err.Error()}
+			} else {
+				m = stateMessage(ev.State)
+			}
+		}
+		if websocket.JSON.Send(ws, m) != nil {
+			return
+		}
+	}
+}
+
+func handle(lc strip, c command) error {
+	opts := lc.State().Options
+	if c.Color != nil {
+		opts.ControlColor = lamp.FormatColor(c.Color.R, c.Color.G, c.Color.B)
+	}
+
+	switch c.Cmd {
+	case "mode":
+		if opts.ControlMode != c.Mode {
+			opts.ControlPeriod = 0
+		}
+		opts.ControlMode = c.Mode
+	case "period":
+		d, err := lamp.ParsePeriod(c.Period)
+		if err != nil {
+			return err
+		}
+		opts.ControlPeriod = d
+	case "fade":
+		d, err := lamp.ParseFade(c.Fade)
+		if err != nil {
+			return err
+		}
+		opts.ControlFade = d
+	case "percent":
+		opts.ControlPercentage = c.Percent
+		// 按比例点亮, 与命令行的 set percent 一样清除 ranges
+		opts.ControlRanges = ""
+	case "color":
+	case "exec":
+		return lc.Apply(opts)
+	case "pixel":
+		opts.ControlMode = lamp.ModeSingle
+		opts.ControlPosition = c.Position
+		return lc.Apply(opts)
+	case "off":
+		return lc.Off()
+	default:
+		return nil
+	}
+
+	return lc.SetOptions(opts)
+}
+
+// strip sends the panel's commands through the alert manager, if any, so
+// that they change what the strip goes back to while an alert shows.
+type strip struct {
+	lc     *lamp.LampWithClient
+	alerts *alert.Manager
+}
+
+func (s strip) State() lamp.State {
+	if s.alerts != nil {
+		return s.alerts.State()
+	}
+	return s.lc.State()
+}
+
+func (s strip) SetOptions(opts lamp.Options) error {
+	if s.alerts != nil {
+		return s.alerts.SetOptions(opts)
+	}
+	return s.lc.SetOptions(opts)
+}
+
+func (s strip) Apply(opts lamp.Options) error {
+	if s.alerts != nil {
+		return s.alerts.Apply(opts)
+	}
+	return s.lc.Apply(opts)
+}
+
+// Off turns every LED off and keeps the options. While an alert shows,
+// the strip goes back dark instead.
+func (s strip) Off() error {
+	if s.alerts != nil && s.alerts.Showing() {
+		opts := s.alerts.State().Options
+		opts.ControlMode, opts.ControlPercentage, opts.ControlRanges, opts.ControlColor = lamp.ModeNormal, 100, "", "0,0,0"
+		return s.alerts.Apply(opts)
+	}
+	s.lc.StopMarquee()
+	return s.lc.Control([]byte{0x03, 0x64, 0x00, 0x00, 0x00, 0x00})
+}
+
+func stateMessage(s lamp.State) message {
+	st := &state{
+		Mode:     s.ControlMode,
+		Percent:  s.ControlPercentage,
+		Position: s.ControlPosition,
+		Color:    s.ControlColor,
+		Quantity: s.Quantity,
+		Pixels:   make([]pixel, len(s.Pixels)),
+		Ranges:   s.ControlRanges,
+	}
+	if d := s.Period(); d != 0 {
+		st.Period = lamp.FormatPeriod(d)
+	}
+	if s.ControlFade != 0 {
+		st.Fade = lamp.FormatPeriod(s.ControlFade)
+	}
+	for i, p := range s.Pixels {
+		st.Pixels[i] = pixel{R: p.R, G: p.G, B: p.B, Mode: p.Mode}
+	}
+
+	return message{Type: "state", State: st}
+}
@@ -0,0 +1,149 @@
+(function () {
+  "use strict";
+
+  var MODE_BREATHE = 4, MODE_STROBE = 5;
+
+  var $ = function (id) { return document.getElementById(id); };
+  var ws = null;
+  var state = null;
+  var errorTimer = null;
+
+  function send(cmd) {
+    if (ws && ws.readyState === WebSocket.OPEN) {
+      ws.send(JSON.stringify(cmd));
+    }
+  }
+
+  // currentColor is the picked color scaled by the brightness slider.
+  function currentColor() {
+    var hex = $("color").value;
+    var k = $("brightness").value / 100;
+    return {
+      r: Math.round(parseInt(hex.substr(1, 2), 16) * k),
+      g: Math.round(parseInt(hex.substr(3, 2), 16) * k),
+      b: Math.round(parseInt(hex.substr(5, 2), 16) * k)
+    };
+  }
+
+  function css(p) {
+    return "rgb(" + p.r + "," + p.g + "," + p.b + ")";
+  }
+
+  function showError(msg) {
+    $("error").textContent = msg;
+    $("error").hidden = false;
+    clearTimeout(errorTimer);
+    errorTimer = setTimeout(function () { $("error").hidden = true; }, 4000);
+  }
+
+  function render() {
+    var preview = $("preview"), grid = $("grid");
+
+    if (preview.children.length !== state.quantity) {
+      preview.innerHTML = "";
+      grid.innerHTML = "";
+      for (var i = 0; i < state.quantity; i++) {
+        preview.appendChild(document.createElement("div")).className = "led";
+
+        // position 0 is never addressed and the last LED is out of range
+        // for single mode, matching the REPL's position= check.
+        var b = grid.appendChild(document.createElement("button"));
+        b.textContent = i + 1;
+        b.dataset.position = i + 1;
+        b.disabled = i + 1 >= state.quantity;
+      }
+    }
+
+    state.pixels.forEach(function (p, i) {
+      var led = preview.children[i];
+      led.style.background = css(p);
+      led.className = "led" + (p.mode === MODE_BREATHE ? " breathe" : p.mode === MODE_STROBE ? " strobe" : "");
+      grid.children[i].style.background = css(p);
+    });
+
+    document.querySelectorAll("[data-mode]").forEach(function (b) {
+      b.classList.toggle("active", Number(b.dataset.mode) === state.mode);
+    });
+
+    if (document.activeElement !== $("percent")) {
+      $("percent").value = state.percent;
+      $("percent-value").value = state.percent;
+    }
+    $("ranges").value = state.ranges;
+    if (document.activeElement !== $("period")) {
+      $("period").value = state.period;
+      $("period").disabled = !state.period;
+    }
+    if (document.activeElement !== $("fade")) {
+      $("fade").value = state.fade;
+    }
+  }
+
+  function connect() {
+    var proto = location.protocol === "https:" ? "wss:" : "ws:";
+    ws = new WebSocket(proto + "//" + location.host + "/ws");
+
+    ws.onopen = function () {
+      $("status").textContent = $("status").dataset.online;
+      $("status").className = "online";
+    };
+    ws.onclose = function () {
+      $("status").textContent = $("status").dataset.offline;
+      $("status").className = "offline";
+      setTimeout(connect, 2000);
+    };
+    ws.onmessage = function (e) {
+      var m = JSON.parse(e.data);
+      if (m.type === "state") {
+        state = m.state;
+        render();
+      } else if (m.type === "error") {
+        showError(m.message);
+      }
+    };
+  }
+
+  document.querySelectorAll("[data-mode]").forEach(function (b) {
+    b.onclick = function () {
+      send({ cmd: "mode", mode: Number(b.dataset.mode), color: currentColor() });
+    };
+  });
+
+  $("color").oninput = $("brightness").oninput = function () {
+    $("brightness-value").value = $("brightness").value;
+  };
+  $("color").onchange = $("brightness").onchange = function () {
+    send({ cmd: "color", color: currentColor() });
+  };
+
+  $("percent").oninput = function () {
+    $("percent-value").value = $("percent").value;
+  };
+  $("percent").onchange = function () {
+    send({ cmd: "percent", percent: Number($("percent").value) });
+  };
+
+  $("period").onchange = function () {
+    send({ cmd: "period", period: $("period").value || "default" });
+  };
+
+  $("fade").onchange = function () {
+    send({ cmd: "fade", fade: $("fade").value || "off" });
+  };
+
+  $("exec").onclick = function () {
+    send({ cmd: "exec", color: currentColor() });
+  };
+  $("off").onclick = function () {
+    send({ cmd: "off" });
+  };
+
+  $("grid").onclick = function (e) {
+    var pos = e.target.dataset.position;
+    if (pos) {
+      send({ cmd: "pixel", position: Number(pos), color: currentColor() });
+    }
+  };
+
+  connect();
+})();